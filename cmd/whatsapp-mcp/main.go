@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	mcp "github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -16,6 +22,7 @@ import (
 	"github.com/eddmann/whatsapp-mcp/internal/service"
 	"github.com/eddmann/whatsapp-mcp/internal/store"
 	"github.com/eddmann/whatsapp-mcp/internal/wa"
+	"github.com/eddmann/whatsapp-mcp/internal/webhook"
 )
 
 func main() {
@@ -37,31 +44,63 @@ func main() {
 		"ffmpeg", cfg.FFmpegPath,
 	)
 
-	db, err := store.Open(cfg.DBDir)
+	db, err := store.Open(cfg.DBDir, cfg.RequireFTS)
 	if err != nil {
 		logger.Error("failed to open store", "err", err)
 		os.Exit(1)
 	}
 	defer db.Close()
+	if !db.FTS {
+		logger.Warn("SQLite FTS5 is not available: full-text search is DISABLED and search_messages falls back to slower substring matching. Rebuild with -tags sqlite_fts5 to enable it.")
+	}
 
-	waclient, err := wa.New(db, cfg.DBDir, cfg.LogLevelString(), logger)
+	waclient, err := wa.New(db, cfg.DBDir, cfg.WhatsApp.SessionDir, cfg.LogLevelString(), logger)
 	if err != nil {
 		logger.Error("failed to init wa client", "err", err)
 		os.Exit(1)
 	}
+	waclient.MaxMessagesPerChat = cfg.MaxMessagesPerChat
+	waclient.HistoryMaxAge = cfg.WhatsApp.HistoryMaxAge
+	if cfg.WebhookURL != "" {
+		waclient.Webhook = webhook.New(cfg.WebhookURL, cfg.WebhookSecret, logger)
+		waclient.WebhookRedactContent = cfg.WebhookRedact
+	}
+
+	chatService := service.NewChatService(db, waclient, cfg)
+	messageService := service.NewMessageService(db, waclient, cfg)
+	contactService := service.NewContactService(db, waclient)
+	groupService := service.NewGroupService(db, waclient, cfg)
+	profileService := service.NewProfileService(waclient)
+	subscriptionService := service.NewSubscriptionService()
+
+	// On first link the database is empty until history sync delivers something, so
+	// optionally hold tool calls until then rather than answering with nothing
+	history := newHistoryGate(cfg.WhatsApp.WaitForHistory > 0 && waclient.WA.Store.ID == nil)
 
-	chatService := service.NewChatService(db)
-	messageService := service.NewMessageService(db, waclient)
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptionService.RemoveSession(session.SessionID())
+	})
 
 	srv := server.NewMCPServer(
 		"whatsapp",
 		"1.0.0",
 		server.WithToolCapabilities(true),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(history.middleware),
 	)
 
+	waclient.OnMessage = func(m wa.IncomingMessage) {
+		for _, sessionID := range subscriptionService.Subscribers(m.ChatJID) {
+			if err := srv.SendNotificationToSpecificClient(sessionID, newMessageNotification, newMessageParams(m)); err != nil {
+				logger.Debug("failed to send message notification", "session", sessionID, "err", err)
+			}
+		}
+	}
+
 	srv.AddTool(mcp.NewTool(
 		"list_chats",
-		mcp.WithDescription("List recent WhatsApp conversations with message previews, sorted by most recent activity. Search by contact/group name or phone number to find specific conversations. Supports groups-only filtering and pagination."),
+		mcp.WithDescription("List recent WhatsApp conversations with message previews, sorted by most recent activity or by name. Search by contact/group name or phone number to find specific conversations. Archived chats are hidden unless include_archived is set. Supports groups-only and pinned-only filtering and pagination."),
 		mcp.WithString("query",
 			mcp.Description("Search term to filter chats by name, phone number, or JID. Examples: 'Bob', '447123456789', '44123', 'work group'. Case-insensitive partial match."),
 		),
@@ -69,11 +108,28 @@ func main() {
 			mcp.Description("Only return group chats (excludes direct/1-on-1 conversations)."),
 			mcp.DefaultBool(false),
 		),
+		mcp.WithBoolean("pinned_only",
+			mcp.Description("Only return chats pinned to the top of the chat list."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("include_archived",
+			mcp.Description("Include archived chats, which are hidden by default."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("include_muted",
+			mcp.Description("Include muted chats. Set false to leave them out."),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithString("sort",
+			mcp.Description("'recent' for most recent activity first, or 'name' for alphabetical by chat name (unnamed chats last)."),
+			mcp.Enum(domain.ChatSortRecent, domain.ChatSortName),
+			mcp.DefaultString(domain.ChatSortRecent),
+		),
 		mcp.WithNumber("limit",
-			mcp.Description("Maximum number of chats to return (1-200)"),
-			mcp.DefaultNumber(20),
+			mcp.Description(fmt.Sprintf("Maximum number of chats to return (1-%d)", cfg.MCP.MaxPageSize)),
+			mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)),
 			mcp.Min(1),
-			mcp.Max(200),
+			mcp.Max(float64(cfg.MCP.MaxPageSize)),
 		),
 		mcp.WithNumber("page",
 			mcp.Description("Page number for pagination, 0-based. Use with limit to browse through large chat lists."),
@@ -84,8 +140,13 @@ func main() {
 		opts := domain.ListChatsOptions{
 			Query:      mcp.ParseString(req, "query", ""),
 			OnlyGroups: mcp.ParseBoolean(req, "groups_only", false),
-			Limit:      mcp.ParseInt(req, "limit", 20),
+			Limit:      mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit),
 			Page:       mcp.ParseInt(req, "page", 0),
+
+			PinnedOnly:      mcp.ParseBoolean(req, "pinned_only", false),
+			IncludeArchived: mcp.ParseBoolean(req, "include_archived", false),
+			ExcludeMuted:    !mcp.ParseBoolean(req, "include_muted", true),
+			Sort:            mcp.ParseString(req, "sort", domain.ChatSortRecent),
 		}
 		chats, err := chatService.ListChats(opts)
 		if err != nil {
@@ -97,7 +158,7 @@ func main() {
 			}), nil
 		}
 
-		totalCount, _ := db.CountChats(opts.Query)
+		totalCount, _ := db.CountChats(opts)
 
 		return mcp.NewToolResultJSON(map[string]any{
 			"success":  true,
@@ -113,11 +174,17 @@ func main() {
 		"list_messages",
 		mcp.WithDescription("List messages from a conversation. Filter by contact/group name and optionally by date range. Returns messages with content, sender, timestamp, and media type."),
 		mcp.WithString("recipient", mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID. Uses fuzzy matching against chat history.")),
-		mcp.WithString("timeframe", mcp.Description("Natural time range (instead of after/before): 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month'. Cannot be combined with after/before.")),
+		mcp.WithString("timeframe", mcp.Description("Natural time range (instead of after/before): 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Cannot be combined with after/before.")),
 		mcp.WithString("after", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-15T00:00:00Z') - only messages after this time. Cannot be combined with timeframe.")),
 		mcp.WithString("before", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-20T23:59:59Z') - only messages before this time. Cannot be combined with timeframe.")),
-		mcp.WithNumber("limit", mcp.Description("Maximum messages to return (1-200)"), mcp.DefaultNumber(20), mcp.Min(1), mcp.Max(200)),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum messages to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
 		mcp.WithNumber("page", mcp.Description("Page number for pagination, 0-based"), mcp.DefaultNumber(0), mcp.Min(0)),
+		mcp.WithBoolean("include_system", mcp.Description("Include system/protocol messages and reactions, which are hidden by default for cleaner transcripts."), mcp.DefaultBool(false)),
+		mcp.WithString("from_sender", mcp.Description("Only messages sent by this person: contact name, phone number, or JID.")),
+		mcp.WithString("media_type", mcp.Description("Only messages with this kind of media."), mcp.Enum("image", "video", "audio", "document", "sticker")),
+		mcp.WithString("direction", mcp.Description("'incoming' for messages you received, 'outgoing' for messages you sent, or 'all'."), mcp.Enum(domain.DirectionAll, domain.DirectionIncoming, domain.DirectionOutgoing), mcp.DefaultString(domain.DirectionAll)),
+		mcp.WithString("sort", mcp.Description("'desc' for newest first or 'asc' for oldest first (read chronologically). Pages count from that end: page 0 with 'asc' is the oldest messages in range."), mcp.Enum(domain.SortDesc, domain.SortAsc), mcp.DefaultString(domain.SortDesc)),
+		mcp.WithBoolean("mark_read", mcp.Description("Side effect: after listing, send read receipts for the chat's incoming messages up to the newest one returned, like opening the chat on your phone. Senders see blue ticks and the chat's unread badge clears on all devices. Only applies when recipient is set."), mcp.DefaultBool(false)),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		recipient := mcp.ParseString(req, "recipient", "")
 
@@ -135,15 +202,38 @@ func main() {
 			chatJID = resolvedJID
 		}
 
+		var sender string
+		if fromSender := mcp.ParseString(req, "from_sender", ""); fromSender != "" {
+			senderJID, err := waclient.ResolveRecipient(fromSender)
+			if err == nil && strings.HasSuffix(senderJID, "@g.us") {
+				err = fmt.Errorf("%s is a group, not a person", fromSender)
+			}
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "from_sender resolution failed",
+					"details": err.Error(),
+					"hint":    "Provide a contact name, phone number, or JID for the sender.",
+				}), nil
+			}
+			sender, _, _ = strings.Cut(senderJID, "@")
+		}
+
 		opts := domain.ListMessagesOptions{
 			Timeframe: mcp.ParseString(req, "timeframe", ""),
 			After:     mcp.ParseString(req, "after", ""),
 			Before:    mcp.ParseString(req, "before", ""),
 			ChatJID:   chatJID,
-			Limit:     mcp.ParseInt(req, "limit", 20),
+			Sender:    sender,
+			MediaType: mcp.ParseString(req, "media_type", ""),
+			Direction: mcp.ParseString(req, "direction", domain.DirectionAll),
+			Sort:      mcp.ParseString(req, "sort", domain.SortDesc),
+			Limit:     mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit),
 			Page:      mcp.ParseInt(req, "page", 0),
+
+			IncludeSystem: mcp.ParseBoolean(req, "include_system", false),
 		}
-		messages, err := messageService.ListMessages(opts)
+		messages, total, err := messageService.ListMessages(opts)
 		if err != nil {
 			return mcp.NewToolResultStructuredOnly(map[string]any{
 				"success": false,
@@ -152,28 +242,71 @@ func main() {
 				"hint":    "Check your filter parameters. Ensure chat_jid is valid and timestamps are in ISO-8601 format. If using timeframe, ensure it's a valid preset (e.g., 'today', 'this_week').",
 			}), nil
 		}
-		return mcp.NewToolResultJSON(map[string]any{"success": true, "messages": messages})
+
+		result := map[string]any{
+			"success":  true,
+			"messages": messages,
+			"total":    total,
+			"page":     opts.Page,
+			"limit":    opts.Limit,
+			"has_more": (opts.Page+1)*opts.Limit < total,
+		}
+		if mcp.ParseBoolean(req, "mark_read", false) && chatJID != "" && len(messages) > 0 {
+			// Marking up to the newest listed message marks everything listed as read
+			newest := messages[0]
+			if opts.Sort == domain.SortAsc {
+				newest = messages[len(messages)-1]
+			}
+			if marked, err := messageService.MarkChatAsRead(chatJID, newest.ID); err != nil {
+				result["mark_read_error"] = err.Error()
+			} else {
+				result["marked_read"] = marked.MarkedCount
+			}
+		}
+		return mcp.NewToolResultJSON(result)
 	})
 
 	srv.AddTool(mcp.NewTool(
 		"search_messages",
 		mcp.WithDescription("Search message content across all conversations. Supports keywords, exact phrases (\"project meeting\"), boolean operators (OR/AND), exclusion (-word), and wildcards (vacat*). Returns matching messages with ±2 surrounding messages for context."),
 		mcp.WithString("query", mcp.Required(), mcp.Description("Search query string. Use simple keywords for best results. Examples: 'vacation', '\"project meeting\"', 'vacation OR holiday'.")),
-		mcp.WithString("timeframe", mcp.Description("Natural time range (instead of after/before): 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month'. Cannot be combined with after/before.")),
+		mcp.WithString("recipient", mcp.Description("Only search this conversation: contact/group name (e.g., 'Bob'), phone number, or JID. Omit to search all chats.")),
+		mcp.WithString("timeframe", mcp.Description("Natural time range (instead of after/before): 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Cannot be combined with after/before.")),
 		mcp.WithString("after", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-15T00:00:00Z') - only messages after this time. Cannot be combined with timeframe.")),
 		mcp.WithString("before", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-20T23:59:59Z') - only messages before this time. Cannot be combined with timeframe.")),
-		mcp.WithNumber("limit", mcp.Description("Maximum results to return (1-200)"), mcp.DefaultNumber(20), mcp.Min(1), mcp.Max(200)),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum results to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultSearchLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
 		mcp.WithNumber("page", mcp.Description("Page number for pagination, 0-based"), mcp.DefaultNumber(0), mcp.Min(0)),
+		mcp.WithBoolean("include_match_ranges", mcp.Description("Include a match_ranges array of character offsets ({start, end}) for matched terms in each matching message. Useful for rendering highlights."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("include_system", mcp.Description("Include system/protocol messages and reactions in results and context, which are hidden by default."), mcp.DefaultBool(false)),
+		mcp.WithString("sort", mcp.Description("Order of matches: 'recent' (newest first) or 'relevance' (best match first, by the bm25 score included on each match; lower is better). Relevance falls back to recent when full-text search is unavailable."), mcp.Enum(domain.SearchSortRecent, domain.SearchSortRelevance), mcp.DefaultString(domain.SearchSortRecent)),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var chatJID string
+		if recipient := mcp.ParseString(req, "recipient", ""); recipient != "" {
+			resolvedJID, err := waclient.ResolveRecipient(recipient)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient resolution failed",
+					"details": err.Error(),
+					"hint":    "Check the recipient identifier, or omit it to search all chats. Use list_chats to see available contacts and groups.",
+				}), nil
+			}
+			chatJID = resolvedJID
+		}
+
 		opts := domain.SearchMessagesOptions{
-			Query:     mcp.ParseString(req, "query", ""),
-			Timeframe: mcp.ParseString(req, "timeframe", ""),
-			After:     mcp.ParseString(req, "after", ""),
-			Before:    mcp.ParseString(req, "before", ""),
-			Limit:     mcp.ParseInt(req, "limit", 20),
-			Page:      mcp.ParseInt(req, "page", 0),
+			Query:              mcp.ParseString(req, "query", ""),
+			ChatJID:            chatJID,
+			Timeframe:          mcp.ParseString(req, "timeframe", ""),
+			After:              mcp.ParseString(req, "after", ""),
+			Before:             mcp.ParseString(req, "before", ""),
+			Limit:              mcp.ParseInt(req, "limit", cfg.MCP.DefaultSearchLimit),
+			Page:               mcp.ParseInt(req, "page", 0),
+			IncludeMatchRanges: mcp.ParseBoolean(req, "include_match_ranges", false),
+			IncludeSystem:      mcp.ParseBoolean(req, "include_system", false),
+			Sort:               mcp.ParseString(req, "sort", domain.SearchSortRecent),
 		}
-		messages, err := messageService.SearchMessages(opts)
+		messages, total, truncated, err := messageService.SearchMessages(opts)
 		if err != nil {
 			return mcp.NewToolResultStructuredOnly(map[string]any{
 				"success": false,
@@ -182,7 +315,20 @@ func main() {
 				"hint":    "Try simplifying your search query. Use simple keywords first, then try advanced FTS5 operators if needed. If using timeframe, ensure it's a valid preset (e.g., 'today', 'this_week').",
 			}), nil
 		}
-		return mcp.NewToolResultJSON(map[string]any{"success": true, "messages": messages})
+		// total counts matches only; messages also includes their surrounding context
+		result := map[string]any{
+			"success":  true,
+			"messages": messages,
+			"total":    total,
+			"page":     opts.Page,
+			"limit":    opts.Limit,
+			"has_more": (opts.Page+1)*opts.Limit < total,
+		}
+		if truncated {
+			result["truncated"] = true
+			result["hint"] = fmt.Sprintf("Results were cut off at %d rows including context. Lower the limit or narrow the query or timeframe to see the rest.", cfg.MCP.MaxContextRows)
+		}
+		return mcp.NewToolResultJSON(result)
 	})
 
 	srv.AddTool(mcp.NewTool(
@@ -192,11 +338,23 @@ func main() {
 		mcp.WithString("text", mcp.Description("Message text. If media_path provided, becomes caption for the media. If no media_path, sent as text message. Optional for media-only messages.")),
 		mcp.WithString("media_path", mcp.Description("Absolute path to media file. Supports images (jpg/png), videos (mp4), audio (ogg/mp3/wav/m4a), documents (pdf/docx). Audio files are sent as voice messages.")),
 		mcp.WithString("reply_to_message_id", mcp.Description("Optional message ID to reply to. Creates a quoted/threaded reply. Get message IDs from list_messages or search_messages.")),
+		mcp.WithString("reply_to_sender", mcp.Description("Author of the quoted message (phone number, JID, or name). Only used when reply_to_message_id isn't in local history (e.g. not yet synced); requires reply_to_text.")),
+		mcp.WithString("reply_to_text", mcp.Description("Text of the quoted message to show in the reply. Only used when reply_to_message_id isn't in local history; requires reply_to_sender.")),
+		mcp.WithArray("mentions", mcp.Description("Optional group participants to @mention (phone numbers without '+', JIDs, or contact names). Group recipients only. Missing '@<number>' tokens are prepended to the text."), mcp.WithStringItems()),
+		mcp.WithBoolean("force", mcp.Description("Send even during configured quiet hours. Only set this if the user explicitly asked to send now."), mcp.DefaultBool(false)),
+		mcp.WithString("filename", mcp.Description("Optional display filename for documents (e.g., 'Q3 Report.pdf'), overriding the file's own name. Must keep the same extension as media_path.")),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		recipient := mcp.ParseString(req, "recipient", "")
 		text := mcp.ParseString(req, "text", "")
 		mediaPath := mcp.ParseString(req, "media_path", "")
-		replyToMessageID := mcp.ParseString(req, "reply_to_message_id", "")
+		sendOpts := domain.SendMessageOptions{
+			ReplyToMessageID: mcp.ParseString(req, "reply_to_message_id", ""),
+			ReplyToSender:    mcp.ParseString(req, "reply_to_sender", ""),
+			ReplyToText:      mcp.ParseString(req, "reply_to_text", ""),
+			Mentions:         req.GetStringSlice("mentions", nil),
+			Force:            mcp.ParseBoolean(req, "force", false),
+			Filename:         mcp.ParseString(req, "filename", ""),
+		}
 
 		if recipient == "" {
 			return mcp.NewToolResultStructuredOnly(map[string]any{
@@ -214,6 +372,14 @@ func main() {
 			}), nil
 		}
 
+		if sendOpts.Filename != "" && mediaPath == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "'filename' requires 'media_path'",
+				"hint":    "filename only renames a document being sent; provide media_path as well.",
+			}), nil
+		}
+
 		resolvedRecipient, err := waclient.ResolveRecipient(recipient)
 		if err != nil {
 			return mcp.NewToolResultStructuredOnly(map[string]any{
@@ -227,7 +393,18 @@ func main() {
 		var result *domain.SendResult
 
 		if mediaPath != "" {
-			result, err = messageService.SendMedia(resolvedRecipient, mediaPath, text, replyToMessageID)
+			result, err = messageService.SendMedia(resolvedRecipient, mediaPath, text, sendOpts)
+			if errors.Is(err, service.ErrQuietHours) {
+				return quietHoursResult(err), nil
+			}
+			if errors.Is(err, service.ErrMediaTypeNotAllowed) {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "MEDIA_TYPE_NOT_ALLOWED",
+					"details": err.Error(),
+					"hint":    "This server is configured not to send this kind of file. Tell the user; do not retry with a renamed file.",
+				}), nil
+			}
 			if err != nil {
 				return mcp.NewToolResultStructuredOnly(map[string]any{
 					"success": false,
@@ -237,7 +414,10 @@ func main() {
 				}), nil
 			}
 		} else {
-			result, err = messageService.SendText(resolvedRecipient, text, replyToMessageID)
+			result, err = messageService.SendText(resolvedRecipient, text, sendOpts)
+			if errors.Is(err, service.ErrQuietHours) {
+				return quietHoursResult(err), nil
+			}
 			if err != nil {
 				return mcp.NewToolResultStructuredOnly(map[string]any{
 					"success": false,
@@ -251,6 +431,103 @@ func main() {
 		return mcp.NewToolResultJSON(result)
 	})
 
+	srv.AddTool(mcp.NewTool(
+		"list_media",
+		mcp.WithDescription("List the messages carrying media (images, videos, audio, documents, stickers) in one chat, with each one's message_id, chat_jid, media type, filename, size and whether it's already downloaded locally (with its path if so). Pass message_id and chat_jid to download_media to fetch one."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithString("media_type", mcp.Description("Only media of this kind."), mcp.Enum("image", "video", "audio", "document", "sticker")),
+		mcp.WithString("sort", mcp.Description("'desc' for newest first or 'asc' for oldest first."), mcp.Enum(domain.SortDesc, domain.SortAsc), mcp.DefaultString(domain.SortDesc)),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum items to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+		mcp.WithNumber("page", mcp.Description("Page number for pagination, 0-based"), mcp.DefaultNumber(0), mcp.Min(0)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		opts := domain.ListMediaOptions{
+			ChatJID:   chatJID,
+			MediaType: mcp.ParseString(req, "media_type", ""),
+			Sort:      mcp.ParseString(req, "sort", domain.SortDesc),
+			Limit:     mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit),
+			Page:      mcp.ParseInt(req, "page", 0),
+		}
+		items, total, err := messageService.ListMedia(opts)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list media",
+				"details": err.Error(),
+				"hint":    "Check media_type is one of image, video, audio, document or sticker, and sort is asc or desc.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"media":    items,
+			"total":    total,
+			"page":     opts.Page,
+			"limit":    opts.Limit,
+			"has_more": (opts.Page+1)*opts.Limit < total,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"download_all_media",
+		mcp.WithDescription(fmt.Sprintf("Download every media item in one chat that isn't saved locally yet into the chat's media folder, oldest first, optionally only one media type or timeframe. Reports each item's outcome (downloaded, expired or failed) and summary counts; items whose media has expired on WhatsApp's servers are skipped without stopping the rest. Downloads are spaced out to avoid throttling, and at most %d are attempted per call: if remaining is above 0, call again to continue.", service.MaxBulkDownloads)),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithString("media_type", mcp.Description("Only media of this kind."), mcp.Enum("image", "video", "audio", "document", "sticker")),
+		mcp.WithString("timeframe", mcp.Description("Only media sent in this range: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Omit for the whole conversation.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		result, err := messageService.DownloadAllMedia(chatJID, mcp.ParseString(req, "media_type", ""), mcp.ParseString(req, "timeframe", ""))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to download media",
+				"details": err.Error(),
+				"hint":    "Check media_type is one of image, video, audio, document or sticker, and the timeframe is a valid preset.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"result":  result,
+		})
+	})
+
 	srv.AddTool(mcp.NewTool(
 		"download_media",
 		mcp.WithDescription("Download media (image, video, audio, document) from a message to local storage. Returns the file path where the media was saved."),
@@ -288,54 +565,136 @@ func main() {
 	})
 
 	srv.AddTool(mcp.NewTool(
-		"get_connection_status",
-		mcp.WithDescription("Check WhatsApp connection status and server health."),
+		"export_conversation_archive",
+		mcp.WithDescription("Export everything from one chat into a zip: a readable transcript.txt, a messages.json dump and all media that can still be downloaded. Returns the zip path, file count and size. Media downloads can make this slow for busy chats."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithString("timeframe", mcp.Description("Only export messages in this range: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Omit for the whole conversation.")),
+		mcp.WithBoolean("include_media", mcp.Description("Download and include media attachments."), mcp.DefaultBool(true)),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		status := map[string]any{
-			"connected":      false,
-			"logged_in":      false,
-			"server_running": true,
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID.",
+			}), nil
 		}
 
-		if waclient.WA != nil {
-			status["connected"] = waclient.WA.IsConnected()
-			status["logged_in"] = waclient.WA.IsLoggedIn()
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
 
-			if waclient.WA.Store != nil && waclient.WA.Store.ID != nil {
-				status["device"] = map[string]any{
-					"user":   waclient.WA.Store.ID.User,
-					"device": waclient.WA.Store.ID.Device,
-				}
-			}
+		archive, err := messageService.ExportConversationArchive(chatJID, mcp.ParseString(req, "timeframe", ""), mcp.ParseBoolean(req, "include_media", true))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to export conversation",
+				"details": err.Error(),
+				"hint":    "Check the timeframe is a valid preset and the chat has messages in it.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"archive": archive,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"export_chat",
+		mcp.WithDescription("Write a full transcript of one chat to a file in the export directory (EXPORT_DIR), oldest message first, and return its path and the number of messages exported. Formats: json (one object per message), csv (timestamp, sender, direction, content, media_type) or txt (one '[time] sender: text' line per message). Media isn't downloaded; use export_conversation_archive for that."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithString("format", mcp.Description("Transcript format: 'json', 'csv' or 'txt'."), mcp.Enum(domain.ExportJSON, domain.ExportCSV, domain.ExportTXT), mcp.DefaultString(domain.ExportTXT)),
+		mcp.WithString("timeframe", mcp.Description("Only export messages in this range: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Omit for the whole conversation.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		export, err := messageService.ExportChat(chatJID, mcp.ParseString(req, "format", domain.ExportTXT), mcp.ParseString(req, "timeframe", ""))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to export chat",
+				"details": err.Error(),
+				"hint":    "Use format json, csv or txt, and check the timeframe is a valid preset and the chat has messages in it.",
+			}), nil
 		}
 
-		var chatCount, messageCount int
-		_ = db.Messages.QueryRow("SELECT COUNT(*) FROM chats").Scan(&chatCount)
-		_ = db.Messages.QueryRow("SELECT COUNT(*) FROM messages").Scan(&messageCount)
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"export":  export,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_connection_status",
+		mcp.WithDescription("Check WhatsApp connection status and server health, including history sync progress and any automatic reconnect in progress after a dropped connection. Always answers, even while other tools wait for the first history sync (WAIT_FOR_HISTORY)."),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultJSON(map[string]any{"status": connectionStatus(waclient, db, history)})
+	})
 
-		status["database"] = map[string]any{
-			"chats":    chatCount,
-			"messages": messageCount,
+	srv.AddTool(mcp.NewTool(
+		"reconnect",
+		mcp.WithDescription("Force WhatsApp to disconnect and reconnect using the existing session. Use this when get_connection_status shows the connection is down or messages stop arriving. Does not log in again: if the session was logged out, the server must be restarted to scan a QR code. Returns the resulting connection status."),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := waclient.Reconnect(); err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "reconnect failed",
+				"details": err.Error(),
+				"status":  connectionStatus(waclient, db, history),
+				"hint":    "Check network access. If logged_in is false, the session is gone and the server must be restarted to pair again.",
+			}), nil
 		}
 
-		return mcp.NewToolResultJSON(map[string]any{"status": status})
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"status":  connectionStatus(waclient, db, history),
+		})
 	})
 
 	srv.AddTool(mcp.NewTool(
 		"catch_up",
-		mcp.WithDescription("Get a summary of recent WhatsApp activity showing active conversations, total messages, questions directed at you, and media received."),
+		mcp.WithDescription("Get a summary of recent WhatsApp activity showing active conversations, total messages, questions directed at you, and media received. Chats far busier than their usual daily average over the previous four weeks are flagged unusually_active."),
 		mcp.WithString("timeframe",
-			mcp.Description("Time range to summarize: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month'"),
+			mcp.Description("Time range to summarize: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days')"),
 			mcp.DefaultString("today"),
 		),
 		mcp.WithBoolean("groups_only",
 			mcp.Description("Only return group chat activity (excludes direct/1-on-1 conversations)."),
 			mcp.DefaultBool(false),
 		),
+		mcp.WithBoolean("include_muted",
+			mcp.Description("Include muted chats in the active chat list. They are left out by default as low priority."),
+			mcp.DefaultBool(false),
+		),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		opts := domain.CatchUpOptions{
-			Timeframe:  mcp.ParseString(req, "timeframe", "today"),
-			OnlyGroups: mcp.ParseBoolean(req, "groups_only", false),
+			Timeframe:    mcp.ParseString(req, "timeframe", "today"),
+			OnlyGroups:   mcp.ParseBoolean(req, "groups_only", false),
+			IncludeMuted: mcp.ParseBoolean(req, "include_muted", false),
 		}
 
 		summary, err := messageService.CatchUp(opts)
@@ -354,34 +713,2024 @@ func main() {
 		})
 	})
 
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.WhatsApp.QRTimeout)
-		defer cancel()
-		if err := waclient.ConnectWithQR(ctx); err != nil {
-			logger.Error("WA connect error", "err", err)
+	srv.AddTool(mcp.NewTool(
+		"get_user_info",
+		mcp.WithDescription("Look up WhatsApp profile info (about/status text, profile picture ID, linked devices, verified business name) for several contacts in one call. Failures are reported per recipient."),
+		mcp.WithArray("recipients",
+			mcp.Required(),
+			mcp.Description("Contact names, phone numbers (without '+'), or JIDs to look up (max 200)."),
+			mcp.WithStringItems(),
+			mcp.MinItems(1),
+			mcp.MaxItems(200),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipients := req.GetStringSlice("recipients", nil)
+		if len(recipients) == 0 {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipients parameter is required",
+				"hint":    "Provide an array of contact names, phone numbers, or JIDs.",
+			}), nil
 		}
-	}()
-
-	stopped := make(chan struct{})
-	sigc := make(chan os.Signal, 1)
-	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
 
-	go func() {
-		<-sigc
-		if waclient != nil && waclient.WA != nil && waclient.WA.IsConnected() {
-			waclient.WA.Disconnect()
+		users, err := contactService.GetUserInfo(recipients)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get user info",
+				"details": err.Error(),
+				"hint":    "Verify WhatsApp connection with get_connection_status.",
+			}), nil
 		}
-		_ = db.Close()
-		close(stopped)
-	}()
 
-	go func() {
-		if err := server.ServeStdio(srv); err != nil {
-			logger.Error("MCP stdio error", "err", err)
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"users":   users,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_chat_timeline",
+		mcp.WithDescription("Get a conversation's messages grouped by day (newest day first), with a per-day message count and the messages under each day in chronological order. Useful for presenting a structured timeline of a chat. Day boundaries use the configured timezone."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID. Uses fuzzy matching against chat history.")),
+		mcp.WithString("timeframe", mcp.Description("Natural time range (instead of after/before): 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Cannot be combined with after/before.")),
+		mcp.WithString("after", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-15T00:00:00Z') - only messages after this time. Cannot be combined with timeframe.")),
+		mcp.WithString("before", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-20T23:59:59Z') - only messages before this time. Cannot be combined with timeframe.")),
+		mcp.WithNumber("days_per_page", mcp.Description("Number of days to return per page (1-31)"), mcp.DefaultNumber(7), mcp.Min(1), mcp.Max(31)),
+		mcp.WithNumber("page", mcp.Description("Page number for pagination by day, 0-based"), mcp.DefaultNumber(0), mcp.Min(0)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact name, phone number, or group JID. Use list_chats to find available recipients.",
+			}), nil
 		}
-		sigc <- syscall.SIGINT
-	}()
 
-	<-stopped
-	logger.Info("shutdown complete")
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		timeline, err := messageService.GetChatTimeline(domain.ChatTimelineOptions{
+			ChatJID:     chatJID,
+			Timeframe:   mcp.ParseString(req, "timeframe", ""),
+			After:       mcp.ParseString(req, "after", ""),
+			Before:      mcp.ParseString(req, "before", ""),
+			DaysPerPage: mcp.ParseInt(req, "days_per_page", 7),
+			Page:        mcp.ParseInt(req, "page", 0),
+		})
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to build chat timeline",
+				"details": err.Error(),
+				"hint":    "Check your filter parameters. Timestamps must be ISO-8601 and timeframe must be a valid preset (e.g., 'today', 'this_week').",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"timeline": timeline,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"list_groups",
+		mcp.WithDescription("List your group chats, most recently active first, with subject (name), JID, participant count, and whether you are an admin. Group metadata is cached and refreshed when older than the configured TTL."),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of groups to return (1-%d)", cfg.MCP.MaxPageSize)),
+			mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)),
+			mcp.Min(1),
+			mcp.Max(float64(cfg.MCP.MaxPageSize)),
+		),
+		mcp.WithNumber("page",
+			mcp.Description("Page number for pagination, 0-based."),
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limit := mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit)
+		page := mcp.ParseInt(req, "page", 0)
+		groups, total, err := groupService.ListGroups(limit, page)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list groups",
+				"details": err.Error(),
+				"hint":    fmt.Sprintf("Limit must be between 1 and %d. Verify WhatsApp connection with get_connection_status.", cfg.MCP.MaxPageSize),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"groups":   groups,
+			"total":    total,
+			"page":     page,
+			"limit":    limit,
+			"has_more": (page+1)*limit < total,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_my_groups_where_admin",
+		mcp.WithDescription("List the groups where you are an admin, with name, JID, and member count. Use this before admin actions (renaming, adding/removing members). Group metadata is cached and refreshed when older than the configured TTL (default 24h)."),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		groups, err := groupService.ListAdminGroups()
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list admin groups",
+				"details": err.Error(),
+				"hint":    "This may be a database error. Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"groups":  groups,
+			"count":   len(groups),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_group_picture",
+		mcp.WithDescription("Download a group's profile photo to local storage. Returns the file path, or has_picture=false if the group has no photo."),
+		mcp.WithString("group", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		group := mcp.ParseString(req, "group", "")
+		if group == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "group parameter is required",
+				"hint":    "Provide a group name or JID. Use list_chats with only_groups=true to see available groups.",
+			}), nil
+		}
+
+		groupJID, err := waclient.ResolveRecipient(group)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "group resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the group name. Use list_chats with only_groups=true to see available groups.",
+			}), nil
+		}
+
+		picture, err := groupService.GetGroupPicture(groupJID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get group picture",
+				"details": err.Error(),
+				"hint":    "The group parameter must resolve to a group chat (JID ending in @g.us). Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"picture": picture,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_group_info",
+		mcp.WithDescription("Get live details about a group: subject, description, creation time, owner, settings, invite-link state, and the participant list with names and admin flags. Only works for groups."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a group name or JID. Use list_groups to see available groups.",
+			}), nil
+		}
+
+		groupJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "group resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the group name. Use list_groups to see available groups.",
+			}), nil
+		}
+
+		info, err := groupService.GetGroupDetails(groupJID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get group info",
+				"details": err.Error(),
+				"hint":    "Only group chats (JID ending in @g.us) are supported. Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"group":   info,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_group_invite_link",
+		mcp.WithDescription("Get a group's invite link (https://chat.whatsapp.com/...). Set reset=true to revoke the current link and generate a new one; resetting requires being a group admin and breaks the old link for everyone, so confirm with the user first."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+		mcp.WithBoolean("reset", mcp.Description("Revoke the current invite link and generate a new one (admins only)."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		reset := mcp.ParseBoolean(req, "reset", false)
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a group name or JID. Use list_groups to see available groups.",
+			}), nil
+		}
+
+		groupJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "group resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the group name. Use list_groups to see available groups.",
+			}), nil
+		}
+
+		link, err := groupService.GetInviteLink(groupJID, reset)
+		if errors.Is(err, wa.ErrNotGroupAdmin) {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "permission denied",
+				"details": err.Error(),
+				"hint":    "Only group admins can get or reset the invite link. Use get_my_groups_where_admin to see groups you administer.",
+			}), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get group invite link",
+				"details": err.Error(),
+				"hint":    "Only group chats (JID ending in @g.us) are supported. Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":     true,
+			"group_jid":   groupJID,
+			"invite_link": link,
+			"reset":       reset,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"join_group_via_link",
+		mcp.WithDescription("Join a group using an invite link (https://chat.whatsapp.com/CODE) or just the invite code. Groups that require admin approval get a join request instead (pending_approval=true). Confirm with the user before joining."),
+		mcp.WithString("invite_link", mcp.Required(), mcp.Description("Invite URL (e.g., 'https://chat.whatsapp.com/AbC123...') or the invite code on its own.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		link := mcp.ParseString(req, "invite_link", "")
+		if link == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "invite_link parameter is required",
+				"hint":    "Provide the invite URL shared with you, e.g. https://chat.whatsapp.com/AbC123...",
+			}), nil
+		}
+
+		joined, err := groupService.JoinViaLink(link)
+		if errors.Is(err, wa.ErrInviteLinkRevoked) {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "invite link expired",
+				"details": err.Error(),
+				"hint":    "The link has been revoked or reset by a group admin. Ask them for a new one.",
+			}), nil
+		}
+		if errors.Is(err, wa.ErrInviteLinkInvalid) {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "invalid invite link",
+				"details": err.Error(),
+				"hint":    "Check the link was copied in full; invite codes are case-sensitive.",
+			}), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to join group",
+				"details": err.Error(),
+				"hint":    "Check the invite link and verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"group":   joined,
+		})
+	})
+
+	// Group info edits share one handler; only the field being set differs.
+	for _, t := range []struct {
+		name, description, param, paramDescription string
+		set                                        func(groupJID, value string) error
+	}{
+		{"set_group_subject", "Rename a group (change its subject).", "subject", "New group subject (1-100 characters).", groupService.SetSubject},
+		{"set_group_description", "Replace a group's description. An empty description clears it.", "description", "New group description (up to 2048 characters).", groupService.SetDescription},
+	} {
+		srv.AddTool(mcp.NewTool(
+			t.name,
+			mcp.WithDescription(t.description+" Groups can restrict editing group info to admins. Confirm with the user before calling; the change is announced to the whole group."),
+			mcp.WithString("recipient", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+			mcp.WithString(t.param, mcp.Required(), mcp.Description(t.paramDescription)),
+		), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			recipient := mcp.ParseString(req, "recipient", "")
+			value := mcp.ParseString(req, t.param, "")
+			if recipient == "" {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient parameter is required",
+					"hint":    "Provide a group name or JID. Use list_groups to see available groups.",
+				}), nil
+			}
+
+			groupJID, err := waclient.ResolveRecipient(recipient)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "group resolution failed",
+					"details": err.Error(),
+					"hint":    "Check the group name. Use list_groups to see available groups.",
+				}), nil
+			}
+
+			err = t.set(groupJID, value)
+			if errors.Is(err, wa.ErrNotGroupAdmin) {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "permission denied",
+					"details": err.Error(),
+					"hint":    "This group only lets admins edit group info. Use get_my_groups_where_admin to see groups you administer.",
+				}), nil
+			}
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   fmt.Sprintf("failed to set group %s", t.param),
+					"details": err.Error(),
+					"hint":    "Only group chats (JID ending in @g.us) are supported. Verify WhatsApp connection with get_connection_status.",
+				}), nil
+			}
+
+			return mcp.NewToolResultJSON(map[string]any{
+				"success":   true,
+				"group_jid": groupJID,
+				t.param:     strings.TrimSpace(value),
+			})
+		})
+	}
+
+	// Participant management tools share one handler; only the action differs.
+	for _, t := range []struct {
+		name, description string
+		action            wa.ParticipantAction
+	}{
+		{"add_group_participants", "Add people to a group you administer. Returns per-number success or failure, e.g. numbers not on WhatsApp or whose privacy settings block being added.", wa.ParticipantAdd},
+		{"remove_group_participants", "Remove people from a group you administer. Returns per-number success or failure.", wa.ParticipantRemove},
+		{"promote_group_admin", "Make group members admins of a group you administer. Returns per-number success or failure.", wa.ParticipantPromote},
+		{"demote_group_admin", "Remove admin rights from group members in a group you administer. Returns per-number success or failure.", wa.ParticipantDemote},
+	} {
+		srv.AddTool(mcp.NewTool(
+			t.name,
+			mcp.WithDescription(t.description+" Confirm with the user before calling; changes are visible to the whole group."),
+			mcp.WithString("recipient", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+			mcp.WithArray("participants",
+				mcp.Required(),
+				mcp.Description("Phone numbers in international format (e.g., '447123456789') or JIDs (max 50)."),
+				mcp.WithStringItems(),
+				mcp.MinItems(1),
+				mcp.MaxItems(50),
+			),
+		), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			recipient := mcp.ParseString(req, "recipient", "")
+			participants := req.GetStringSlice("participants", nil)
+			if recipient == "" || len(participants) == 0 {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient and participants parameters are required",
+					"hint":    "Provide the group and an array of phone numbers or JIDs.",
+				}), nil
+			}
+
+			groupJID, err := waclient.ResolveRecipient(recipient)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "group resolution failed",
+					"details": err.Error(),
+					"hint":    "Check the group name. Use list_groups to see available groups.",
+				}), nil
+			}
+
+			results, err := groupService.UpdateParticipants(groupJID, participants, t.action)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   fmt.Sprintf("failed to %s participants", t.action),
+					"details": err.Error(),
+					"hint":    "You must be an admin of the group (see get_my_groups_where_admin). Phone numbers need the country code. Verify WhatsApp connection with get_connection_status.",
+				}), nil
+			}
+
+			succeeded := 0
+			for _, r := range results {
+				if r.Success {
+					succeeded++
+				}
+			}
+			return mcp.NewToolResultJSON(map[string]any{
+				"success":   succeeded == len(results),
+				"group_jid": groupJID,
+				"results":   results,
+				"succeeded": succeeded,
+				"failed":    len(results) - succeeded,
+			})
+		})
+	}
+
+	srv.AddTool(mcp.NewTool(
+		"participant_last_seen",
+		mcp.WithDescription("Find when a participant last posted in a group. Returns the timestamp and content of their most recent message in that group, based on synced history."),
+		mcp.WithString("group", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+		mcp.WithString("participant", mcp.Required(), mcp.Description("Participant name (e.g., 'Dave'), phone number (e.g., '447123456789'), or JID.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		group := mcp.ParseString(req, "group", "")
+		participant := mcp.ParseString(req, "participant", "")
+		if group == "" || participant == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "group and participant parameters are required",
+				"hint":    "Provide a group name or JID and a participant name, phone number, or JID.",
+			}), nil
+		}
+
+		groupJID, err := waclient.ResolveRecipient(group)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "group resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the group name. Use list_chats with only_groups=true to see available groups.",
+			}), nil
+		}
+
+		participantJID, err := waclient.ResolveRecipient(participant)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "participant resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the participant identifier. A phone number (e.g., '447123456789') always works.",
+			}), nil
+		}
+
+		msg, err := messageService.GetParticipantLastMessage(groupJID, participantJID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to look up participant activity",
+				"details": err.Error(),
+				"hint":    "The group parameter must resolve to a group chat (JID ending in @g.us).",
+			}), nil
+		}
+
+		if msg == nil {
+			return mcp.NewToolResultJSON(map[string]any{
+				"success":         true,
+				"found":           false,
+				"group_jid":       groupJID,
+				"participant_jid": participantJID,
+				"message":         "No messages from this participant in the group's synced history.",
+			})
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":         true,
+			"found":           true,
+			"group_jid":       groupJID,
+			"participant_jid": participantJID,
+			"last_seen":       msg.Timestamp,
+			"last_message":    msg,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"reply_to_latest",
+		mcp.WithDescription("Reply to the most recent message in a conversation as a quoted/threaded reply, without needing its message ID. Returns the new message ID and the quoted message ID."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob', 'Project Team'), phone number without '+' (e.g., '447123456789'), or JID.")),
+		mcp.WithString("text", mcp.Required(), mcp.Description("Reply text")),
+		mcp.WithBoolean("force", mcp.Description("Send even during configured quiet hours. Only set this if the user explicitly asked to send now."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		text := mcp.ParseString(req, "text", "")
+		if recipient == "" || text == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient and text parameters are required",
+				"hint":    "Provide a contact name, phone number, or group JID and the reply text.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		result, err := messageService.ReplyToLatest(chatJID, text, mcp.ParseBoolean(req, "force", false))
+		if errors.Is(err, service.ErrQuietHours) {
+			return quietHoursResult(err), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to send reply",
+				"details": err.Error(),
+				"hint":    "The chat may have no synced messages yet. Use list_messages to check, or send_message with reply_to_message_id.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"list_chat_senders",
+		mcp.WithDescription("List everyone who has posted in a group, with resolved names and message counts, based on synced history. Includes former members who no longer appear in the live group info."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Group name (e.g., 'Project Team') or group JID. Uses fuzzy matching against chat history.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a group name or JID. Use list_chats with only_groups=true to find groups.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the group name. Use list_chats with only_groups=true to see available groups.",
+			}), nil
+		}
+
+		senders, err := chatService.ListChatSenders(chatJID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list chat senders",
+				"details": err.Error(),
+				"hint":    "The recipient must resolve to a group chat (JID ending in @g.us).",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"chat_jid": chatJID,
+			"senders":  senders,
+			"count":    len(senders),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"subscribe_messages",
+		mcp.WithDescription("Subscribe to push notifications ("+newMessageNotification+") when new messages arrive in the given chats, instead of polling. Each notification carries the chat JID and a short preview; call list_messages for full details. Requires a client that handles server notifications."),
+		mcp.WithArray("recipients", mcp.Required(), mcp.Description("Contacts/groups to watch: names, phone numbers, or JIDs."), mcp.WithStringItems(), mcp.MinItems(1)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipients := req.GetStringSlice("recipients", nil)
+		if len(recipients) == 0 {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipients parameter is required",
+				"hint":    "Provide one or more contact names, phone numbers, or group JIDs.",
+			}), nil
+		}
+
+		var jids []string
+		for _, r := range recipients {
+			jid, err := waclient.ResolveRecipient(r)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient resolution failed",
+					"details": fmt.Sprintf("%s: %s", r, err.Error()),
+					"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+				}), nil
+			}
+			jids = append(jids, jid)
+		}
+
+		var sessionID string
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			sessionID = session.SessionID()
+		}
+
+		chats, err := subscriptionService.Subscribe(sessionID, jids)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to subscribe",
+				"details": err.Error(),
+				"hint":    "Unsubscribe from some chats with unsubscribe_messages, or use catch_up to poll instead.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":      true,
+			"subscribed":   chats,
+			"notification": newMessageNotification,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"unsubscribe_messages",
+		mcp.WithDescription("Stop new-message notifications for the given chats, or for all chats if none are given."),
+		mcp.WithArray("recipients", mcp.Description("Contacts/groups to stop watching. Omit to unsubscribe from everything."), mcp.WithStringItems()),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var jids []string
+		for _, r := range req.GetStringSlice("recipients", nil) {
+			jid, err := waclient.ResolveRecipient(r)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient resolution failed",
+					"details": fmt.Sprintf("%s: %s", r, err.Error()),
+					"hint":    "Pass the chat JIDs returned by subscribe_messages.",
+				}), nil
+			}
+			jids = append(jids, jid)
+		}
+
+		var sessionID string
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			sessionID = session.SessionID()
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":    true,
+			"subscribed": subscriptionService.Unsubscribe(sessionID, jids),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"search_contacts",
+		mcp.WithDescription("Search your individual contacts (not groups) by name or phone number. Returns each match's JID, phone number and name, plus the total number of matches. Read-only."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Part of a name (e.g., 'ali') or phone number (e.g., '4471')")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum contacts to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		contacts, total, err := chatService.SearchContacts(mcp.ParseString(req, "query", ""), mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to search contacts",
+				"details": err.Error(),
+				"hint":    fmt.Sprintf("Provide a non-empty query and a limit between 1 and %d.", cfg.MCP.MaxPageSize),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"contacts": contacts,
+			"count":    len(contacts),
+			"total":    total,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"is_business",
+		mcp.WithDescription("Check whether a contact is a WhatsApp Business account, with the business name and verified-name certificate issuer when available. is_business is null when it cannot be determined (e.g. offline with no cached result)."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact name, phone number, or JID.",
+			}), nil
+		}
+
+		jid, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts.",
+			}), nil
+		}
+
+		info, err := contactService.IsBusiness(jid)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to check business status",
+				"details": err.Error(),
+				"hint":    "Business status is only available for individual contacts, not groups.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"business": info,
+		})
+	})
+
+	// Block and unblock share one handler; only the change differs.
+	for _, t := range []struct {
+		name, description, done string
+		set                     func(jid string) error
+	}{
+		{"block_contact", "Block a contact so they can no longer message or call you, see your last seen, online status or profile updates. Groups cannot be blocked.", "blocked", contactService.Block},
+		{"unblock_contact", "Unblock a previously blocked contact.", "unblocked", contactService.Unblock},
+	} {
+		srv.AddTool(mcp.NewTool(
+			t.name,
+			mcp.WithDescription(t.description+" Syncs to your phone and other linked devices."),
+			mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			recipient := mcp.ParseString(req, "recipient", "")
+			if recipient == "" {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient parameter is required",
+					"hint":    "Provide a contact name, phone number, or JID.",
+				}), nil
+			}
+
+			jid, err := waclient.ResolveRecipient(recipient)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient resolution failed",
+					"details": err.Error(),
+					"hint":    "Check the recipient identifier. Use list_chats to see available contacts.",
+				}), nil
+			}
+
+			if err := t.set(jid); err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   fmt.Sprintf("failed to %s contact", strings.TrimSuffix(t.name, "_contact")),
+					"details": err.Error(),
+					"hint":    "Only individual contacts can be blocked. Verify WhatsApp connection and login with get_connection_status.",
+				}), nil
+			}
+
+			return mcp.NewToolResultJSON(map[string]any{
+				"success": true,
+				"jid":     jid,
+				"message": fmt.Sprintf("contact %s", t.done),
+			})
+		})
+	}
+
+	srv.AddTool(mcp.NewTool(
+		"get_blocklist",
+		mcp.WithDescription("List the contacts you have blocked, with their names where known, most recently blocked first. Read-only."),
+		mcp.WithBoolean("refresh", mcp.Description("Fetch the blocklist from WhatsApp instead of using the locally synced copy."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		blocked, err := contactService.GetBlocklist(mcp.ParseBoolean(req, "refresh", false))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get blocklist",
+				"details": err.Error(),
+				"hint":    "Verify WhatsApp connection and login with get_connection_status, or retry without refresh to use the synced copy.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"blocked": blocked,
+			"count":   len(blocked),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_profile_picture",
+		mcp.WithDescription("Download a contact's or group's profile picture to local storage, returning the file path and WhatsApp image URL. An unchanged picture is not downloaded again. Contacts with no picture, or who hide it from you, are reported with has_picture=false and a message."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithBoolean("preview", mcp.Description("Fetch the low-resolution thumbnail instead of the full-size image."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID.",
+			}), nil
+		}
+
+		jid, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts.",
+			}), nil
+		}
+
+		picture, err := contactService.GetProfilePicture(jid, mcp.ParseBoolean(req, "preview", false))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get profile picture",
+				"details": err.Error(),
+				"hint":    "Verify WhatsApp connection and login with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"picture": picture,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"resolve_chat_jid",
+		mcp.WithDescription("Resolve a contact/group name or phone number to its canonical chat JID (e.g., for download_media's chat_jid). Read-only. If the name is ambiguous, returns the matching candidates instead."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob', 'Project Team'), phone number without '+' (e.g., '447123456789'), or JID.")),
+		mcp.WithBoolean("include_group_members", mcp.Description("If no contact or chat matches, also search people who have posted in your groups (by display name or partial number) and return their direct-message JID, so you can DM a group member who isn't a saved contact."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact name, phone number, or group name.",
+			}), nil
+		}
+
+		jid, err := waclient.ResolveRecipient(recipient)
+		var ambiguous *wa.AmbiguousRecipientError
+		if err != nil && !errors.As(err, &ambiguous) && mcp.ParseBoolean(req, "include_group_members", false) {
+			member, memberErr := waclient.ResolveGroupMember(recipient)
+			if memberErr == nil {
+				result := map[string]any{
+					"success":         true,
+					"jid":             member.JID,
+					"is_group":        false,
+					"source":          "group_member",
+					"has_direct_chat": member.HasDirectChat,
+				}
+				if member.Name != "" {
+					result["name"] = member.Name
+				}
+				if !member.HasDirectChat {
+					result["note"] = "Found in group history; no prior direct chat exists with this number. Sending will start a new conversation."
+				}
+				return mcp.NewToolResultJSON(result)
+			}
+			if errors.As(memberErr, &ambiguous) {
+				err = memberErr
+			}
+		}
+		if errors.As(err, &ambiguous) {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success":    false,
+				"error":      "ambiguous recipient",
+				"details":    err.Error(),
+				"candidates": ambiguous.Candidates,
+				"hint":       "Ask the user which one they meant, then use that candidate's jid.",
+			}), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"jid":      jid,
+			"is_group": strings.HasSuffix(jid, "@g.us"),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"resolve_recipients",
+		mcp.WithDescription("Resolve several contact/group names or phone numbers to chat JIDs in one call. Read-only. Results are returned in input order, each with status 'resolved' (with jid), 'ambiguous' (with candidates), or 'not_found' (with error)."),
+		mcp.WithArray("recipients",
+			mcp.Required(),
+			mcp.Description("Contact/group names, phone numbers (without '+'), or JIDs to resolve (max 200)."),
+			mcp.WithStringItems(),
+			mcp.MinItems(1),
+			mcp.MaxItems(200),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipients := req.GetStringSlice("recipients", nil)
+		if len(recipients) == 0 {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipients parameter is required",
+				"hint":    "Provide an array of contact names, phone numbers, or JIDs.",
+			}), nil
+		}
+		if len(recipients) > 200 {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "too many recipients",
+				"details": fmt.Sprintf("got %d, max 200", len(recipients)),
+				"hint":    "Split the list into batches of at most 200.",
+			}), nil
+		}
+
+		results := waclient.ResolveRecipients(recipients)
+		counts := map[string]int{wa.ResolutionResolved: 0, wa.ResolutionAmbiguous: 0, wa.ResolutionNotFound: 0}
+		for _, r := range results {
+			counts[r.Status]++
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":      true,
+			"results":      results,
+			"resolved":     counts[wa.ResolutionResolved],
+			"ambiguous":    counts[wa.ResolutionAmbiguous],
+			"not_found":    counts[wa.ResolutionNotFound],
+			"all_resolved": counts[wa.ResolutionResolved] == len(results),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"find_unread_in_chat",
+		mcp.WithDescription("Get the messages in one conversation that arrived after you last read it, oldest first, with the unread count. Use this to catch up on exactly what was missed in a single chat. Read state is tracked from your other devices' read receipts, mark-as-read actions, and your own replies."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum messages to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact name, phone number, or group JID. Use list_chats to find available recipients.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		unread, err := messageService.GetUnreadInChat(chatJID, mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get unread messages",
+				"details": err.Error(),
+				"hint":    "This may be a database error. Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		result := map[string]any{
+			"success": true,
+			"unread":  unread,
+		}
+		if !unread.HasReadMarker {
+			result["hint"] = "No read marker is known for this chat yet. Use list_messages to see recent messages instead."
+		}
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"mark_chat_as_read",
+		mcp.WithDescription("Mark a conversation as read, sending read receipts for its unread incoming messages so the unread badge clears on your devices. Optionally mark only up to a specific message. Returns how many messages were marked."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithString("up_to_message_id", mcp.Description("Optional message ID; only messages up to and including this one are marked read. Defaults to the latest message.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact name, phone number, or group JID. Use list_chats to find available recipients.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		result, err := messageService.MarkChatAsRead(chatJID, mcp.ParseString(req, "up_to_message_id", ""))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to mark chat as read",
+				"details": err.Error(),
+				"hint":    "Check that up_to_message_id belongs to this chat (use list_messages). Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":      true,
+			"chat_jid":     result.ChatJID,
+			"marked_count": result.MarkedCount,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"activity_heatmap",
+		mcp.WithDescription("Get message activity by day of week and hour of day as a 7x24 matrix (rows Monday-Sunday, columns hours 0-23) in the configured timezone, plus the peak slot. Use this to describe when a chat, or all chats, are most active."),
+		mcp.WithString("recipient", mcp.Description("Optional contact/group name, phone number, or JID. Omit for activity across all chats.")),
+		mcp.WithString("timeframe", mcp.Description("Natural time range (instead of after/before): 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Cannot be combined with after/before.")),
+		mcp.WithString("after", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-15T00:00:00Z') - only messages after this time. Cannot be combined with timeframe.")),
+		mcp.WithString("before", mcp.Description("ISO-8601 timestamp (e.g., '2025-01-20T23:59:59Z') - only messages before this time. Cannot be combined with timeframe.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var chatJID string
+		if recipient := mcp.ParseString(req, "recipient", ""); recipient != "" {
+			resolvedJID, err := waclient.ResolveRecipient(recipient)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient resolution failed",
+					"details": err.Error(),
+					"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+				}), nil
+			}
+			chatJID = resolvedJID
+		}
+
+		heatmap, err := messageService.GetActivityHeatmap(
+			chatJID,
+			mcp.ParseString(req, "timeframe", ""),
+			mcp.ParseString(req, "after", ""),
+			mcp.ParseString(req, "before", ""),
+		)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to build activity heatmap",
+				"details": err.Error(),
+				"hint":    "Check your filter parameters. Timestamps must be ISO-8601 and timeframe must be a valid preset (e.g., 'today', 'this_week').",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"heatmap": heatmap,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"acknowledge",
+		mcp.WithDescription("Quickly acknowledge a conversation by reacting to the latest incoming message with an emoji (default 👍), without needing its message ID. Returns the reacted-to message ID as quoted_message_id."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob', 'Project Team'), phone number without '+' (e.g., '447123456789'), or JID.")),
+		mcp.WithString("emoji", mcp.Description("Reaction emoji"), mcp.DefaultString("👍")),
+		mcp.WithBoolean("force", mcp.Description("Send even during configured quiet hours. Only set this if the user explicitly asked to send now."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact name, phone number, or group JID. Use list_chats to find available recipients.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		result, err := messageService.Acknowledge(chatJID, mcp.ParseString(req, "emoji", ""), mcp.ParseBoolean(req, "force", false))
+		if errors.Is(err, service.ErrQuietHours) {
+			return quietHoursResult(err), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to acknowledge",
+				"details": err.Error(),
+				"hint":    "The chat may have no incoming messages yet. Use list_messages to check.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"send_reaction",
+		mcp.WithDescription("React to a specific message with an emoji, or remove your reaction by passing an empty emoji. Returns the reacted-to message ID as quoted_message_id."),
+		mcp.WithString("message_id", mcp.Required(), mcp.Description("ID of the message to react to (from list_messages or search_messages).")),
+		mcp.WithString("chat_jid", mcp.Required(), mcp.Description("Chat identifier from the message object (the chat_jid field).")),
+		mcp.WithString("emoji", mcp.Required(), mcp.Description("A single emoji (e.g., '❤️'). Pass an empty string to remove a previously sent reaction.")),
+		mcp.WithBoolean("force", mcp.Description("Send even during configured quiet hours. Only set this if the user explicitly asked to send now."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		messageID := mcp.ParseString(req, "message_id", "")
+		chatJID := mcp.ParseString(req, "chat_jid", "")
+		if messageID == "" || chatJID == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "message_id and chat_jid parameters are required",
+				"hint":    "Get both from the message object returned by list_messages or search_messages.",
+			}), nil
+		}
+
+		result, err := messageService.SendReaction(chatJID, messageID, mcp.ParseString(req, "emoji", ""), mcp.ParseBoolean(req, "force", false))
+		if errors.Is(err, service.ErrQuietHours) {
+			return quietHoursResult(err), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to send reaction",
+				"details": err.Error(),
+				"hint":    "Use a single emoji such as '👍' or '❤️', or an empty string to remove your reaction.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"send_contact",
+		mcp.WithDescription("Share a contact card (vCard) with a WhatsApp contact or group. The recipient can tap the card to message or save the shared contact."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Who to send the card to: contact/group name (e.g., 'Bob'), phone number without '+' (e.g., '447123456789'), or JID.")),
+		mcp.WithString("contact_name", mcp.Required(), mcp.Description("Display name of the contact being shared (e.g., 'Alice Smith').")),
+		mcp.WithString("contact_phone", mcp.Required(), mcp.Description("Phone number of the contact being shared, in international format with country code (e.g., '+44 7123 456789' or '447123456789').")),
+		mcp.WithArray("additional_phones", mcp.Description("Optional further phone numbers for the same contact, in international format."), mcp.WithStringItems()),
+		mcp.WithBoolean("force", mcp.Description("Send even during configured quiet hours. Only set this if the user explicitly asked to send now."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		contactName := mcp.ParseString(req, "contact_name", "")
+		contactPhone := mcp.ParseString(req, "contact_phone", "")
+		if recipient == "" || contactName == "" || contactPhone == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient, contact_name and contact_phone parameters are required",
+				"hint":    "Provide who to send the card to, plus the shared contact's name and phone number.",
+			}), nil
+		}
+
+		resolvedRecipient, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		phones := append([]string{contactPhone}, req.GetStringSlice("additional_phones", nil)...)
+		result, err := messageService.SendContact(resolvedRecipient, contactName, phones, mcp.ParseBoolean(req, "force", false))
+		if errors.Is(err, service.ErrQuietHours) {
+			return quietHoursResult(err), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to send contact",
+				"details": err.Error(),
+				"hint":    "Phone numbers need the country code, e.g. '447123456789'. Verify WhatsApp connection with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"create_poll",
+		mcp.WithDescription("Send a poll to a WhatsApp contact or group. Returns the poll's message_id."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Project Team'), phone number without '+' (e.g., '447123456789'), or JID.")),
+		mcp.WithString("question", mcp.Required(), mcp.Description("The poll question (e.g., 'Where should we eat?').")),
+		mcp.WithArray("options",
+			mcp.Required(),
+			mcp.Description("Answer options, 2-12 distinct strings."),
+			mcp.WithStringItems(),
+			mcp.MinItems(2),
+			mcp.MaxItems(12),
+		),
+		mcp.WithBoolean("allow_multiple", mcp.Description("Let voters pick more than one option."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("force", mcp.Description("Send even during configured quiet hours. Only set this if the user explicitly asked to send now."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		question := mcp.ParseString(req, "question", "")
+		if recipient == "" || question == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient and question parameters are required",
+				"hint":    "Provide who to send the poll to and the question to ask.",
+			}), nil
+		}
+
+		resolvedRecipient, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available contacts and groups.",
+			}), nil
+		}
+
+		result, err := messageService.CreatePoll(resolvedRecipient, question, req.GetStringSlice("options", nil), mcp.ParseBoolean(req, "allow_multiple", false), mcp.ParseBoolean(req, "force", false))
+		if errors.Is(err, service.ErrQuietHours) {
+			return quietHoursResult(err), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to create poll",
+				"details": err.Error(),
+				"hint":    "Provide 2-12 distinct, non-empty options.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_poll_results",
+		mcp.WithDescription("Get the current results of a poll: each option with its vote count and who voted for it. Works for polls you created with create_poll and polls received in your chats. Read-only."),
+		mcp.WithString("message_id", mcp.Required(), mcp.Description("ID of the poll message (from create_poll, list_messages or search_messages).")),
+		mcp.WithString("chat_jid", mcp.Required(), mcp.Description("Chat identifier from the message object (the chat_jid field).")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		messageID := mcp.ParseString(req, "message_id", "")
+		chatJID := mcp.ParseString(req, "chat_jid", "")
+		if messageID == "" || chatJID == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "message_id and chat_jid parameters are required",
+				"hint":    "Get both from create_poll's result or the poll's message object.",
+			}), nil
+		}
+
+		results, err := messageService.GetPollResults(chatJID, messageID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get poll results",
+				"details": err.Error(),
+				"hint":    "Check that message_id is a poll message in this chat. Polls are only known once created or received while connected.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"poll":    results,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_message_reactions",
+		mcp.WithDescription("List everyone who reacted to a message, with the emoji, the reactor's name and when they reacted. Use this to answer \"who reacted to my announcement and with what\". Read-only."),
+		mcp.WithString("message_id", mcp.Required(), mcp.Description("ID of the message (from list_messages or search_messages).")),
+		mcp.WithString("chat_jid", mcp.Required(), mcp.Description("Chat identifier from the message object (the chat_jid field).")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		messageID := mcp.ParseString(req, "message_id", "")
+		chatJID := mcp.ParseString(req, "chat_jid", "")
+		if messageID == "" || chatJID == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "message_id and chat_jid parameters are required",
+				"hint":    "Get both from the message object returned by list_messages or search_messages.",
+			}), nil
+		}
+
+		reactions, err := messageService.GetMessageReactions(chatJID, messageID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get message reactions",
+				"details": err.Error(),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":   true,
+			"reactions": reactions,
+			"count":     len(reactions),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_recent_senders",
+		mcp.WithDescription("List the contacts and groups you most recently interacted with, newest first, with resolved names and JIDs. Use this to suggest recipients (\"who do you want to message?\") without searching."),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum chats to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(10), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+		mcp.WithBoolean("direct_only", mcp.Description("Only include one-to-one chats, leaving out groups."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		chats, err := chatService.ListRecentChats(mcp.ParseInt(req, "limit", 10), mcp.ParseBoolean(req, "direct_only", false))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list recent chats",
+				"details": err.Error(),
+				"hint":    fmt.Sprintf("Limit must be between 1 and %d.", cfg.MCP.MaxPageSize),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"chats":   chats,
+			"count":   len(chats),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"mute_chat",
+		mcp.WithDescription("Mute a chat's notifications for 8 hours, 1 week, or always. Muted chats are left out of catch_up by default. Syncs to your phone and other linked devices."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID. Uses fuzzy matching against chat history.")),
+		mcp.WithString("duration", mcp.Description("How long to mute: '8h', '1w' or 'always'."), mcp.Enum("8h", "1w", "always"), mcp.DefaultString("8h")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		duration := mcp.ParseString(req, "duration", "8h")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		if err := chatService.Mute(chatJID, duration); err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to mute chat",
+				"details": err.Error(),
+				"hint":    "Duration must be '8h', '1w' or 'always'. Verify WhatsApp connection and login with get_connection_status.",
+			}), nil
+		}
+
+		chat, _ := chatService.GetChat(chatJID, false)
+		result := map[string]any{
+			"success":  true,
+			"chat_jid": chatJID,
+			"duration": duration,
+		}
+		if chat != nil && chat.MutedUntil != nil {
+			result["muted_until"] = chat.MutedUntil
+		}
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"set_typing",
+		mcp.WithDescription("Show a typing or recording indicator in a chat before sending a long reply, or clear it with 'paused'. WhatsApp clears the indicator by itself after a while or when a message is sent."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID.")),
+		mcp.WithString("state", mcp.Description("'composing' (typing), 'recording' (recording a voice note) or 'paused' (clear)."), mcp.Enum(wa.TypingComposing, wa.TypingRecording, wa.TypingPaused), mcp.DefaultString(wa.TypingComposing)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		state := mcp.ParseString(req, "state", wa.TypingComposing)
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		if err := chatService.SetTyping(chatJID, state); err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to set typing indicator",
+				"details": err.Error(),
+				"hint":    "State must be 'composing', 'recording' or 'paused'. Verify WhatsApp connection and login with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"chat_jid": chatJID,
+			"state":    state,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"set_presence",
+		mcp.WithDescription("Set whether you appear online ('available') or offline ('unavailable') to your contacts. While unavailable, typing indicators and read receipts may not be shown to others."),
+		mcp.WithString("state", mcp.Required(), mcp.Description("'available' or 'unavailable'."), mcp.Enum("available", "unavailable")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		state := mcp.ParseString(req, "state", "")
+		if err := chatService.SetPresence(state); err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to set presence",
+				"details": err.Error(),
+				"hint":    "State must be 'available' or 'unavailable'. Verify WhatsApp connection and login with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"state":   state,
+		})
+	})
+
+	// Archive, pin and unmute tools share one handler; only the change differs.
+	for _, t := range []struct {
+		name, description, done string
+		set                     func(chatJID string) error
+	}{
+		{"archive_chat", "Archive a chat, hiding it from list_chats by default. Archiving also unpins it.", "archived",
+			func(jid string) error { return chatService.SetArchived(jid, true) }},
+		{"unarchive_chat", "Move an archived chat back to the main chat list.", "unarchived",
+			func(jid string) error { return chatService.SetArchived(jid, false) }},
+		{"pin_chat", "Pin a chat to the top of the chat list. WhatsApp allows at most 3 pinned chats.", "pinned",
+			func(jid string) error { return chatService.SetPinned(jid, true) }},
+		{"unpin_chat", "Unpin a chat.", "unpinned",
+			func(jid string) error { return chatService.SetPinned(jid, false) }},
+		{"unmute_chat", "Unmute a chat so its notifications show again.", "unmuted",
+			func(jid string) error { return chatService.Unmute(jid) }},
+	} {
+		srv.AddTool(mcp.NewTool(
+			t.name,
+			mcp.WithDescription(t.description+" Syncs to your phone and other linked devices."),
+			mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID. Uses fuzzy matching against chat history.")),
+		), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			recipient := mcp.ParseString(req, "recipient", "")
+			if recipient == "" {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient parameter is required",
+					"hint":    "Provide a contact/group name, phone number, or JID. Use list_chats to see available chats.",
+				}), nil
+			}
+
+			chatJID, err := waclient.ResolveRecipient(recipient)
+			if err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "recipient resolution failed",
+					"details": err.Error(),
+					"hint":    "Check the recipient identifier. Use list_chats with include_archived=true to see all chats.",
+				}), nil
+			}
+
+			if err := t.set(chatJID); err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   fmt.Sprintf("failed to %s chat", strings.TrimSuffix(t.name, "_chat")),
+					"details": err.Error(),
+					"hint":    "Verify WhatsApp connection and login with get_connection_status. Pinning fails once 3 chats are already pinned.",
+				}), nil
+			}
+
+			return mcp.NewToolResultJSON(map[string]any{
+				"success":  true,
+				"chat_jid": chatJID,
+				"message":  fmt.Sprintf("chat %s", t.done),
+			})
+		})
+	}
+
+	for _, t := range []struct {
+		name, description, done string
+		starred                 bool
+	}{
+		{"star_message", "Star a message so it can be found again with get_starred_messages, e.g. one the user flags as important.", "starred", true},
+		{"unstar_message", "Remove the star from a message.", "unstarred", false},
+	} {
+		srv.AddTool(mcp.NewTool(
+			t.name,
+			mcp.WithDescription(t.description+" Syncs to your phone and other linked devices."),
+			mcp.WithString("message_id", mcp.Required(), mcp.Description("ID of the message (from list_messages or search_messages).")),
+			mcp.WithString("chat_jid", mcp.Required(), mcp.Description("Chat identifier from the message object (the chat_jid field).")),
+		), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			messageID := mcp.ParseString(req, "message_id", "")
+			chatJID := mcp.ParseString(req, "chat_jid", "")
+			if messageID == "" || chatJID == "" {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   "message_id and chat_jid parameters are required",
+					"hint":    "Get both from the message object returned by list_messages or search_messages.",
+				}), nil
+			}
+
+			if err := messageService.SetStarred(chatJID, messageID, t.starred); err != nil {
+				return mcp.NewToolResultStructuredOnly(map[string]any{
+					"success": false,
+					"error":   fmt.Sprintf("failed to %s", strings.ReplaceAll(t.name, "_", " ")),
+					"details": err.Error(),
+					"hint":    "Check the message is stored locally (list_messages), and verify WhatsApp connection and login with get_connection_status.",
+				}), nil
+			}
+
+			return mcp.NewToolResultJSON(map[string]any{
+				"success":    true,
+				"message_id": messageID,
+				"chat_jid":   chatJID,
+				"message":    fmt.Sprintf("message %s", t.done),
+			})
+		})
+	}
+
+	srv.AddTool(mcp.NewTool(
+		"get_starred_messages",
+		mcp.WithDescription("List starred messages across all chats, newest first. Includes messages starred on your phone or other devices, as long as the message is stored locally."),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum messages to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+		mcp.WithNumber("page", mcp.Description("Page number (0-based)"), mcp.DefaultNumber(0), mcp.Min(0)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limit := mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit)
+		page := mcp.ParseInt(req, "page", 0)
+		messages, total, err := messageService.ListStarred(limit, page)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list starred messages",
+				"details": err.Error(),
+				"hint":    fmt.Sprintf("Limit must be between 1 and %d.", cfg.MCP.MaxPageSize),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"messages": messages,
+			"total":    total,
+			"page":     page,
+			"limit":    limit,
+			"has_more": (page+1)*limit < total,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"my_mentions_feed",
+		mcp.WithDescription("List messages across all chats that directly involve you: ones that @mention you or reply to one of your messages, newest first, with the chat name and a snippet. Read-only. Only covers messages received since mention tracking was added."),
+		mcp.WithString("timeframe", mcp.Description("Only include messages in this range: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Omit for all time.")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum messages to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+		mcp.WithNumber("page", mcp.Description("Page number (0-based)"), mcp.DefaultNumber(0), mcp.Min(0)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limit := mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit)
+		page := mcp.ParseInt(req, "page", 0)
+		items, total, err := messageService.MyMentionsFeed(mcp.ParseString(req, "timeframe", ""), limit, page)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list mentions",
+				"details": err.Error(),
+				"hint":    fmt.Sprintf("Limit must be between 1 and %d, and timeframe a valid preset (e.g., 'today', 'this_week').", cfg.MCP.MaxPageSize),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":  true,
+			"messages": items,
+			"count":    len(items),
+			"total":    total,
+			"page":     page,
+			"has_more": (page+1)*limit < total,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_last_interaction",
+		mcp.WithDescription("Get the single most recent message exchanged with a contact or group, whoever sent it, with a human-readable time like '3 days ago'. Answers \"when did I last talk to X\". Read-only."),
+		mcp.WithString("recipient", mcp.Required(), mcp.Description("Contact/group name (e.g., 'Bob'), phone number (e.g., '447123456789'), or JID. Uses fuzzy matching against chat history.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipient := mcp.ParseString(req, "recipient", "")
+		if recipient == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient parameter is required",
+				"hint":    "Provide a contact/group name, phone number, or JID.",
+			}), nil
+		}
+
+		chatJID, err := waclient.ResolveRecipient(recipient)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "recipient resolution failed",
+				"details": err.Error(),
+				"hint":    "Check the recipient identifier. Use list_chats to see available chats.",
+			}), nil
+		}
+
+		interaction, err := messageService.GetLastInteraction(chatJID)
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get last interaction",
+				"details": err.Error(),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success":     true,
+			"interaction": interaction,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"get_chat",
+		mcp.WithDescription("Get one chat's details by JID: name, whether it's a group, last activity time, archived/pinned/muted state, and optionally its last message. Use this to confirm a chat before sending. Read-only."),
+		mcp.WithString("chat_jid", mcp.Required(), mcp.Description("Chat JID (e.g., '447123456789@s.whatsapp.net' or '123456@g.us'). Use resolve_chat_jid to find it from a name.")),
+		mcp.WithBoolean("include_last_message", mcp.Description("Include the chat's most recent message, its sender and whether you sent it."), mcp.DefaultBool(false)),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		chatJID := mcp.ParseString(req, "chat_jid", "")
+		if chatJID == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "chat_jid parameter is required",
+				"hint":    "Provide a chat JID. Use resolve_chat_jid or list_chats to find it.",
+			}), nil
+		}
+
+		chat, err := chatService.GetChat(chatJID, mcp.ParseBoolean(req, "include_last_message", false))
+		if errors.Is(err, service.ErrChatNotFound) {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "CHAT_NOT_FOUND",
+				"details": err.Error(),
+				"hint":    "No chat with this JID has been seen. Check the JID with resolve_chat_jid or list_chats.",
+			}), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to get chat",
+				"details": err.Error(),
+				"hint":    "This is a database error rather than a missing chat; retry, or check get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"chat":    chat,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"list_unknown_senders",
+		mcp.WithDescription("List direct chats with people who aren't saved in your contacts (only a phone number, maybe a self-chosen profile name), newest first, with a preview of their last message, how many messages they sent and whether you ever replied. Use this to triage possible spam. Read-only."),
+		mcp.WithString("timeframe", mcp.Description("Only include people who messaged in this range: 'last_hour', 'today', 'yesterday', 'last_3_days', 'this_week', 'last_week', 'this_month', or a rolling 'last_N_minutes', 'last_N_hours', 'last_N_days' (e.g. 'last_7_days'). Omit for all time.")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum chats to return (1-%d)", cfg.MCP.MaxPageSize)), mcp.DefaultNumber(float64(cfg.MCP.DefaultListLimit)), mcp.Min(1), mcp.Max(float64(cfg.MCP.MaxPageSize))),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		chats, err := chatService.ListUnknownSenders(mcp.ParseString(req, "timeframe", ""), mcp.ParseInt(req, "limit", cfg.MCP.DefaultListLimit))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to list unknown senders",
+				"details": err.Error(),
+				"hint":    fmt.Sprintf("Limit must be between 1 and %d, and timeframe a valid preset (e.g., 'today', 'this_week').", cfg.MCP.MaxPageSize),
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"chats":   chats,
+			"count":   len(chats),
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"edit_message",
+		mcp.WithDescription("Edit the text of a message you sent, e.g. to fix a typo. WhatsApp only allows edits to your own messages within 15 minutes of sending."),
+		mcp.WithString("message_id", mcp.Required(), mcp.Description("ID of your message to edit (from send_message, list_messages or search_messages).")),
+		mcp.WithString("chat_jid", mcp.Required(), mcp.Description("Chat identifier from the message object (the chat_jid field).")),
+		mcp.WithString("new_text", mcp.Required(), mcp.Description("Replacement message text.")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		messageID := mcp.ParseString(req, "message_id", "")
+		chatJID := mcp.ParseString(req, "chat_jid", "")
+		if messageID == "" || chatJID == "" {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "message_id and chat_jid parameters are required",
+				"hint":    "Get both from the send_message result or the message object returned by list_messages.",
+			}), nil
+		}
+
+		result, err := messageService.EditMessage(chatJID, messageID, mcp.ParseString(req, "new_text", ""))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to edit message",
+				"details": err.Error(),
+				"hint":    "Provide the full replacement text in new_text.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(result)
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"set_profile_name",
+		mcp.WithDescription("Set your WhatsApp display name (push name) shown to other users. Returns the applied name."),
+		mcp.WithString("name", mcp.Required(), mcp.Description("New display name (1-25 characters).")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := profileService.SetProfileName(mcp.ParseString(req, "name", ""))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to set profile name",
+				"details": err.Error(),
+				"hint":    "Names must be 1-25 characters. Verify WhatsApp connection and login with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"name":    name,
+		})
+	})
+
+	srv.AddTool(mcp.NewTool(
+		"set_status",
+		mcp.WithDescription("Set your WhatsApp about/status text shown on your profile. Returns the applied text."),
+		mcp.WithString("status", mcp.Required(), mcp.Description("New about text (1-139 characters).")),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		status, err := profileService.SetStatus(mcp.ParseString(req, "status", ""))
+		if err != nil {
+			return mcp.NewToolResultStructuredOnly(map[string]any{
+				"success": false,
+				"error":   "failed to set status",
+				"details": err.Error(),
+				"hint":    "Status text must be 1-139 characters. Verify WhatsApp connection and login with get_connection_status.",
+			}), nil
+		}
+
+		return mcp.NewToolResultJSON(map[string]any{
+			"success": true,
+			"status":  status,
+		})
+	})
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.WhatsApp.QRTimeout)
+		defer cancel()
+		if err := waclient.Connect(ctx, cfg.WhatsApp.PairPhone); err != nil {
+			logger.Error("WA connect error", "err", err)
+			history.open()
+			return
+		}
+		if cfg.WhatsApp.Keepalive > 0 {
+			go waclient.RunKeepalive(context.Background(), cfg.WhatsApp.Keepalive)
+		}
+		if history.waiting() {
+			logger.Info("waiting for history sync before serving tool calls", "timeout", cfg.WhatsApp.WaitForHistory)
+			select {
+			case <-waclient.HistorySynced():
+				logger.Info("history sync received")
+			case <-time.After(cfg.WhatsApp.WaitForHistory):
+				logger.Warn("timed out waiting for history sync", "timeout", cfg.WhatsApp.WaitForHistory)
+			}
+			history.open()
+		}
+	}()
+
+	stopped := make(chan struct{})
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+
+	// Network transports are stopped gracefully on shutdown; stdio just ends with the process
+	var serve func() error
+	var shutdownHTTP func(context.Context) error
+	switch cfg.MCP.Transport {
+	case config.TransportSSE:
+		sse := server.NewSSEServer(srv)
+		serve = func() error { return sse.Start(cfg.MCP.Addr) }
+		shutdownHTTP = sse.Shutdown
+		logger.Info("serving MCP over SSE", "addr", cfg.MCP.Addr, "sse", "/sse", "message", "/message")
+	case config.TransportHTTP:
+		streamable := server.NewStreamableHTTPServer(srv)
+		serve = func() error { return streamable.Start(cfg.MCP.Addr) }
+		shutdownHTTP = streamable.Shutdown
+		logger.Info("serving MCP over streamable HTTP", "addr", cfg.MCP.Addr, "endpoint", "/mcp")
+	default:
+		serve = func() error { return server.ServeStdio(srv) }
+	}
+
+	go func() {
+		<-sigc
+		if shutdownHTTP != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+			if err := shutdownHTTP(ctx); err != nil {
+				logger.Warn("MCP server shutdown error", "err", err)
+			}
+			cancel()
+		}
+		if waclient != nil && waclient.WA != nil && waclient.WA.IsConnected() {
+			waclient.WA.Disconnect()
+		}
+		_ = db.Close()
+		close(stopped)
+	}()
+
+	go func() {
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("MCP server error", "transport", cfg.MCP.Transport, "err", err)
+		}
+		sigc <- syscall.SIGINT
+	}()
+
+	<-stopped
+	logger.Info("shutdown complete")
+}
+
+// httpShutdownTimeout bounds how long the sse and http transports wait for open
+// requests to finish on shutdown.
+const httpShutdownTimeout = 5 * time.Second
+
+// newMessageNotification is the MCP notification method sent to subscribed sessions.
+const newMessageNotification = "notifications/whatsapp/new_message"
+
+// newMessagePreviewLength caps the content preview included in notifications.
+const newMessagePreviewLength = 100
+
+// newMessageParams builds the minimal notification payload for a new message.
+func newMessageParams(m wa.IncomingMessage) map[string]any {
+	params := map[string]any{
+		"chat_jid":   m.ChatJID,
+		"chat_name":  m.ChatName,
+		"message_id": m.ID,
+		"sender":     m.Sender,
+		"timestamp":  m.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if m.MediaType != "" {
+		params["media_type"] = m.MediaType
+	}
+	if preview := []rune(m.Content); len(preview) > newMessagePreviewLength {
+		params["preview"] = string(preview[:newMessagePreviewLength]) + "…"
+	} else if m.Content != "" {
+		params["preview"] = m.Content
+	}
+	return params
+}
+
+// connectionStatus reports the WhatsApp connection state and database counts.
+func connectionStatus(waclient *wa.Client, db *store.DB, history *historyGate) map[string]any {
+	status := map[string]any{
+		"connected":      false,
+		"logged_in":      false,
+		"server_running": true,
+	}
+
+	if waclient.WA != nil {
+		status["connected"] = waclient.WA.IsConnected()
+		status["logged_in"] = waclient.WA.IsLoggedIn()
+
+		if waclient.WA.Store != nil && waclient.WA.Store.ID != nil {
+			status["device"] = map[string]any{
+				"user":   waclient.WA.Store.ID.User,
+				"device": waclient.WA.Store.ID.Device,
+			}
+		}
+	}
+
+	if last := waclient.LastKeepalive(); !last.IsZero() {
+		status["last_keepalive"] = last
+	}
+
+	rs := waclient.ReconnectState()
+	reconnect := map[string]any{
+		"active":     rs.Active,
+		"attempts":   rs.Attempts,
+		"logged_out": rs.LoggedOut,
+	}
+	if !rs.NextAttempt.IsZero() {
+		reconnect["next_attempt"] = rs.NextAttempt
+	}
+	if rs.LastError != "" {
+		reconnect["last_error"] = rs.LastError
+	}
+	status["reconnect"] = reconnect
+
+	var chatCount, messageCount int
+	_ = db.Messages.QueryRow("SELECT COUNT(*) FROM chats").Scan(&chatCount)
+	_ = db.Messages.QueryRow("SELECT COUNT(*) FROM messages").Scan(&messageCount)
+
+	if last, err := db.LastMessageTime(); err == nil && !last.IsZero() {
+		status["last_message_received_at"] = last
+	}
+
+	status["database"] = map[string]any{
+		"chats":    chatCount,
+		"messages": messageCount,
+	}
+
+	batches, percent := waclient.HistorySyncProgress()
+	status["history_sync"] = map[string]any{
+		"waiting":          history.waiting(),
+		"batches_received": batches,
+		"progress_percent": percent,
+		"completed":        waclient.HistorySyncComplete(),
+	}
+
+	backfilled := waclient.ChatNamesBackfilled()
+	status["chat_names_backfilled"] = !backfilled.IsZero()
+	if !backfilled.IsZero() {
+		status["chat_names_backfilled_at"] = backfilled
+	}
+
+	return status
+}
+
+// historyGate holds tool calls until history sync has started after a first link,
+// so the assistant doesn't see an empty database. get_connection_status is always
+// served so progress can be checked while waiting.
+type historyGate struct {
+	ready chan struct{}
+	once  sync.Once
+}
+
+// newHistoryGate returns a gate that is closed when wait is true, and open otherwise.
+func newHistoryGate(wait bool) *historyGate {
+	g := &historyGate{ready: make(chan struct{})}
+	if !wait {
+		g.open()
+	}
+	return g
+}
+
+// open releases any held tool calls; it is safe to call more than once.
+func (g *historyGate) open() {
+	g.once.Do(func() { close(g.ready) })
+}
+
+// waiting reports whether tool calls are still being held.
+func (g *historyGate) waiting() bool {
+	select {
+	case <-g.ready:
+		return false
+	default:
+		return true
+	}
+}
+
+// middleware blocks tool calls other than get_connection_status until the gate opens.
+func (g *historyGate) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if req.Params.Name != "get_connection_status" {
+			select {
+			case <-g.ready:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// quietHoursResult builds the structured tool error returned when a send is blocked by quiet hours.
+func quietHoursResult(err error) *mcp.CallToolResult {
+	return mcp.NewToolResultStructuredOnly(map[string]any{
+		"success": false,
+		"error":   "QUIET_HOURS",
+		"details": err.Error(),
+		"hint":    "Messages are not sent during quiet hours. Ask the user before retrying with force=true.",
+	})
 }
@@ -3,46 +3,146 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	_ "time/tzdata" // Allow TIMEZONE to resolve on minimal images without zoneinfo
 )
 
 // Config holds application configuration.
 type Config struct {
-	DBDir      string
-	LogLevel   slog.Level
-	FFmpegPath string
-	WhatsApp   WhatsAppConfig
-	MCP        MCPConfig
+	DBDir              string
+	ExportDir          string // Where export_chat writes transcripts; defaults to DBDir/exports
+	LogLevel           slog.Level
+	FFmpegPath         string
+	Location           *time.Location // Timezone used for quiet hours and day boundaries
+	QuietHours         *QuietHours    // Optional window during which sends are blocked
+	MaxMessagesPerChat int            // Retention cap per chat; 0 disables pruning
+	WebhookURL         string         // Optional URL that receives lifecycle and message events
+	WebhookRedact      bool           // Omit message content previews from webhook events
+	WebhookSecret      string         // Optional key for signing webhook bodies with HMAC-SHA256
+	RequireFTS         bool           // Fail startup when SQLite FTS5 is unavailable
+	SendAllowedMedia   []string       // Media types send_message may send; empty allows all
+	GroupCacheTTL      time.Duration  // How long cached group metadata is trusted before refetching
+	WhatsApp           WhatsAppConfig
+	MCP                MCPConfig
+}
+
+// QuietHours is a daily time-of-day window, expressed as minutes since midnight.
+// The window wraps past midnight when Start is after End (e.g. 22:00-07:00).
+type QuietHours struct {
+	Start int
+	End   int
 }
 
 // WhatsAppConfig holds WhatsApp-specific configuration.
 type WhatsAppConfig struct {
-	QRTimeout time.Duration
+	SessionDir     string // Directory for the whatsmeow session database; defaults to DBDir
+	QRTimeout      time.Duration
+	WaitForHistory time.Duration // On first link, hold tool calls up to this long for history sync; 0 disables
+	HistoryMaxAge  time.Duration // History sync skips messages older than this; 0 keeps everything
+	Keepalive      time.Duration // How often to check the connection and reconnect if it dropped; 0 disables
+	PairPhone      string        // E.164 number (digits only) to pair with a pairing code instead of a QR code
 }
 
 // MCPConfig holds MCP server configuration.
 type MCPConfig struct {
-	MaxPageSize int
+	MaxPageSize        int
+	DefaultListLimit   int    // Default limit for list_chats and list_messages
+	DefaultSearchLimit int    // Default limit for search_messages
+	MaxContextRows     int    // Cap on rows returned by search_messages, counting matches and their context
+	Transport          string // How MCP clients connect: TransportStdio, TransportSSE or TransportHTTP
+	Addr               string // Listen address for the sse and http transports
 }
 
+// MCP transports.
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+	TransportHTTP  = "http"
+)
+
 // Load loads configuration from environment variables.
 func Load() (*Config, error) {
 	cfg := &Config{
-		DBDir:      getEnv("DB_DIR", "store"),
-		FFmpegPath: getEnv("FFMPEG_PATH", "ffmpeg"),
+		DBDir:         getEnv("DB_DIR", "store"),
+		FFmpegPath:    getEnv("FFMPEG_PATH", "ffmpeg"),
+		WebhookURL:    getEnv("WEBHOOK_URL", ""),
+		WebhookSecret: getEnv("WEBHOOK_SECRET", ""),
 		WhatsApp: WhatsAppConfig{
 			QRTimeout: 3 * time.Minute,
 		},
 		MCP: MCPConfig{
 			MaxPageSize: 200,
+			Transport:   strings.ToLower(getEnv("TRANSPORT", TransportStdio)),
+			Addr:        getEnv("MCP_ADDR", "127.0.0.1:8080"),
 		},
 	}
 
+	cfg.WhatsApp.SessionDir = getEnv("WA_SESSION_DIR", cfg.DBDir)
+	cfg.ExportDir = getEnv("EXPORT_DIR", filepath.Join(cfg.DBDir, "exports"))
+	cfg.WhatsApp.PairPhone = strings.TrimPrefix(strings.TrimSpace(getEnv("PAIR_PHONE", "")), "+")
+
+	var err error
+	if cfg.MCP.DefaultListLimit, err = getEnvInt("DEFAULT_LIST_LIMIT", 20); err != nil {
+		return nil, err
+	}
+	if cfg.MCP.DefaultSearchLimit, err = getEnvInt("DEFAULT_SEARCH_LIMIT", 20); err != nil {
+		return nil, err
+	}
+	if cfg.MCP.MaxContextRows, err = getEnvInt("MAX_CONTEXT_ROWS", 500); err != nil {
+		return nil, err
+	}
+
+	if cfg.WebhookRedact, err = getEnvBool("WEBHOOK_REDACT_CONTENT", false); err != nil {
+		return nil, err
+	}
+	if cfg.MaxMessagesPerChat, err = getEnvInt("MAX_MESSAGES_PER_CHAT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.RequireFTS, err = getEnvBool("REQUIRE_FTS", true); err != nil {
+		return nil, err
+	}
+	if cfg.GroupCacheTTL, err = getEnvDuration("GROUP_CACHE_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.WhatsApp.WaitForHistory, err = getEnvDuration("WAIT_FOR_HISTORY", 0); err != nil {
+		return nil, err
+	}
+	if cfg.WhatsApp.HistoryMaxAge, err = getEnvDuration("HISTORY_SYNC_MAX_AGE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.WhatsApp.Keepalive, err = getEnvDuration("KEEPALIVE_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+
 	logLevelStr := getEnv("LOG_LEVEL", "INFO")
 	cfg.LogLevel = parseLogLevel(logLevelStr)
 
+	cfg.Location = time.Local
+	if tz := getEnv("TIMEZONE", ""); tz != "" {
+		if cfg.Location, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid TIMEZONE: %w", err)
+		}
+	}
+
+	if types := getEnv("SEND_ALLOWED_MEDIA_TYPES", ""); types != "" {
+		if cfg.SendAllowedMedia, err = parseMediaTypes(types); err != nil {
+			return nil, fmt.Errorf("invalid SEND_ALLOWED_MEDIA_TYPES: %w", err)
+		}
+	}
+
+	if qh := getEnv("QUIET_HOURS", ""); qh != "" {
+		if cfg.QuietHours, err = parseQuietHours(qh); err != nil {
+			return nil, fmt.Errorf("invalid QUIET_HOURS: %w", err)
+		}
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
@@ -58,9 +158,48 @@ func (c *Config) Validate() error {
 	if c.MCP.MaxPageSize < 1 {
 		return fmt.Errorf("MCP.MaxPageSize must be positive")
 	}
+	if c.MCP.DefaultListLimit < 1 || c.MCP.DefaultListLimit > c.MCP.MaxPageSize {
+		return fmt.Errorf("DEFAULT_LIST_LIMIT must be between 1 and %d", c.MCP.MaxPageSize)
+	}
+	if c.MCP.DefaultSearchLimit < 1 || c.MCP.DefaultSearchLimit > c.MCP.MaxPageSize {
+		return fmt.Errorf("DEFAULT_SEARCH_LIMIT must be between 1 and %d", c.MCP.MaxPageSize)
+	}
+	if c.MCP.MaxContextRows < 1 {
+		return fmt.Errorf("MAX_CONTEXT_ROWS must be positive")
+	}
+	if !slices.Contains([]string{TransportStdio, TransportSSE, TransportHTTP}, c.MCP.Transport) {
+		return fmt.Errorf("TRANSPORT must be one of %s, %s or %s", TransportStdio, TransportSSE, TransportHTTP)
+	}
+	if c.WhatsApp.WaitForHistory < 0 {
+		return fmt.Errorf("WAIT_FOR_HISTORY cannot be negative")
+	}
+	if c.WhatsApp.HistoryMaxAge < 0 {
+		return fmt.Errorf("HISTORY_SYNC_MAX_AGE cannot be negative")
+	}
+	if c.WhatsApp.Keepalive < 0 {
+		return fmt.Errorf("KEEPALIVE_INTERVAL cannot be negative")
+	}
+	if c.WhatsApp.PairPhone != "" && !e164Digits.MatchString(c.WhatsApp.PairPhone) {
+		return fmt.Errorf("PAIR_PHONE must be an international (E.164) number such as +447123456789")
+	}
+	if c.WebhookURL != "" {
+		if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("WEBHOOK_URL must be an absolute http(s) URL")
+		}
+	}
+	if c.MaxMessagesPerChat < 0 {
+		return fmt.Errorf("MAX_MESSAGES_PER_CHAT cannot be negative")
+	}
+	if c.GroupCacheTTL < 0 {
+		return fmt.Errorf("GROUP_CACHE_TTL cannot be negative")
+	}
 	return nil
 }
 
+// e164Digits matches an E.164 phone number without its leading '+': a country
+// code that doesn't start with 0, up to 15 digits in total.
+var e164Digits = regexp.MustCompile(`^[1-9]\d{6,14}$`)
+
 // getEnv gets an environment variable with a default value.
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -69,6 +208,120 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvInt gets an integer environment variable with a default value.
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvBool gets a boolean environment variable with a default value.
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "12h", "30m") with a default value.
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	// Go durations stop at hours, so accept a whole number of days too, e.g. "90d"
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a duration such as 12h, 30m or 90d", key)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 12h, 30m or 90d: %w", key, err)
+	}
+	return d, nil
+}
+
+// mediaTypes are the kinds of media send_message can send.
+var mediaTypes = []string{"image", "video", "audio", "document"}
+
+// parseMediaTypes parses a comma-separated list of media types, e.g. "image,video".
+func parseMediaTypes(s string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if !slices.Contains(mediaTypes, t) {
+			return nil, fmt.Errorf("unknown media type %q, expected one of %s", t, strings.Join(mediaTypes, ", "))
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no media types given")
+	}
+	return types, nil
+}
+
+// parseQuietHours parses a window in the form "HH:MM-HH:MM".
+func parseQuietHours(value string) (*QuietHours, error) {
+	startStr, endStr, ok := strings.Cut(value, "-")
+	if !ok {
+		return nil, fmt.Errorf("expected HH:MM-HH:MM, got %q", value)
+	}
+	start, err := parseClock(strings.TrimSpace(startStr))
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(strings.TrimSpace(endStr))
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("start and end cannot be the same")
+	}
+	return &QuietHours{Start: start, End: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether the time of day of t falls inside the window.
+func (q *QuietHours) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if q.Start < q.End {
+		return m >= q.Start && m < q.End
+	}
+	return m >= q.Start || m < q.End
+}
+
+// String returns the window in HH:MM-HH:MM form.
+func (q *QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
 // parseLogLevel parses a log level string to slog.Level.
 func parseLogLevel(level string) slog.Level {
 	switch strings.ToUpper(level) {
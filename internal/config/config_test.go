@@ -0,0 +1,111 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(2025, 3, 1, hour, min, 0, 0, time.UTC) }
+
+	tests := []struct {
+		window string
+		at     time.Time
+		want   bool
+	}{
+		// Same-day window: start is inclusive, end is exclusive
+		{window: "09:00-17:00", at: at(8, 59), want: false},
+		{window: "09:00-17:00", at: at(9, 0), want: true},
+		{window: "09:00-17:00", at: at(16, 59), want: true},
+		{window: "09:00-17:00", at: at(17, 0), want: false},
+		// Overnight window wraps past midnight
+		{window: "22:00-07:00", at: at(21, 59), want: false},
+		{window: "22:00-07:00", at: at(22, 0), want: true},
+		{window: "22:00-07:00", at: at(0, 0), want: true},
+		{window: "22:00-07:00", at: at(6, 59), want: true},
+		{window: "22:00-07:00", at: at(7, 0), want: false},
+		{window: "22:00-07:00", at: at(12, 0), want: false},
+		// Window ending at midnight
+		{window: "23:00-00:00", at: at(23, 59), want: true},
+		{window: "23:00-00:00", at: at(0, 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window+"@"+tt.at.Format("15:04"), func(t *testing.T) {
+			q, err := parseQuietHours(tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "22:00-07:00", want: "22:00-07:00"},
+		{value: " 9:30 - 17:05 ", want: "09:30-17:05"},
+		{value: "22:00", wantErr: true},
+		{value: "22:00-22:00", wantErr: true},
+		{value: "24:00-07:00", wantErr: true},
+		{value: "22:60-07:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			q, err := parseQuietHours(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseQuietHours(%q) = %v, want an error", tt.value, q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.String(); got != tt.want {
+				t.Errorf("window = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: time.Minute},
+		{value: "12h", want: 12 * time.Hour},
+		{value: "90d", want: 90 * 24 * time.Hour},
+		{value: "0", want: 0},
+		{value: "1.5d", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("HISTORY_SYNC_MAX_AGE", tt.value)
+			got, err := getEnvDuration("HISTORY_SYNC_MAX_AGE", time.Minute)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("getEnvDuration(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("getEnvDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,9 +11,51 @@ type Chat struct {
 	LastMessage     *string    `json:"last_message,omitempty"`
 	LastSender      *string    `json:"last_sender,omitempty"`
 	LastIsFromMe    *bool      `json:"last_is_from_me,omitempty"`
+	Archived        bool       `json:"archived,omitempty"`
+	Pinned          bool       `json:"pinned,omitempty"`
+	Muted           bool       `json:"muted,omitempty"`
+	MutedUntil      *time.Time `json:"muted_until,omitempty"` // Unset when muted indefinitely
 }
 
+// MutedForever is stored as a chat's muted_until when it is muted indefinitely.
+var MutedForever = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// SetMuted fills in Muted and MutedUntil from a stored muted_until time.
+func (c *Chat) SetMuted(until time.Time) {
+	if !until.After(time.Now()) {
+		return
+	}
+	c.Muted = true
+	if until.Before(MutedForever) {
+		c.MutedUntil = &until
+	}
+}
+
+// UnknownSenderChat is a direct chat with someone not saved in the address book,
+// for spam triage.
+type UnknownSenderChat struct {
+	JID             string     `json:"jid"`
+	Phone           string     `json:"phone"`
+	PushName        *string    `json:"push_name,omitempty"` // The name they set on their own profile
+	IsBusiness      bool       `json:"is_business"`
+	MessageCount    int        `json:"message_count"` // Messages received from them
+	HasReplied      bool       `json:"has_replied"`   // Whether you've ever sent a message in the chat
+	LastMessage     *string    `json:"last_message,omitempty"`
+	LastMessageTime *time.Time `json:"last_message_time,omitempty"`
+}
+
+// Content placeholders stored for non-conversational messages. Listings hide
+// these unless system messages are explicitly requested.
+// ReactionContentPrefix only appears on rows written by older versions, which
+// flattened reactions to text; reactions are now kept in the reactions table.
+const (
+	SystemMessageContent  = "🔧 System Message"
+	ReactionContentPrefix = "😊 Reaction: "
+)
+
 // Message represents a WhatsApp message.
+// Download metadata (url, media_key, file hashes, waveform) is deliberately not
+// part of this type; it stays in the store and is only read by DownloadMedia.
 type Message struct {
 	ID        string    `json:"id"`
 	ChatJID   string    `json:"chat_jid"`
@@ -24,6 +66,46 @@ type Message struct {
 	MediaType *string   `json:"media_type,omitempty"`
 	Filename  *string   `json:"filename,omitempty"`
 	ChatName  *string   `json:"chat_name,omitempty"`
+	IsGroup   bool      `json:"is_group"`             // Derived from ChatJID
+	IsDeleted bool      `json:"is_deleted,omitempty"` // Deleted for everyone by its sender; content is cleared
+
+	IsEdited bool       `json:"is_edited,omitempty"` // Content was changed by its sender after sending
+	EditedAt *time.Time `json:"edited_at,omitempty"` // Time of the latest edit
+
+	Reactions map[string]int `json:"reactions,omitempty"` // Emoji -> number of people who reacted with it
+
+	MatchRanges []MatchRange `json:"match_ranges,omitempty"` // Only set on search matches when requested
+	Score       *float64     `json:"score,omitempty"`        // FTS5 bm25 rank of a search match; lower is more relevant
+}
+
+// MentionFeedItem is a message from someone else that @mentions the user or
+// replies to one of their messages.
+type MentionFeedItem struct {
+	ID          string    `json:"id"`
+	ChatJID     string    `json:"chat_jid"`
+	ChatName    *string   `json:"chat_name,omitempty"`
+	IsGroup     bool      `json:"is_group"`
+	Sender      string    `json:"sender"`
+	SenderName  *string   `json:"sender_name,omitempty"`
+	Snippet     string    `json:"snippet"`
+	MediaType   *string   `json:"media_type,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	MentionsMe  bool      `json:"mentions_me"`
+	RepliesToMe bool      `json:"replies_to_me"`
+	ReplyToID   *string   `json:"reply_to_id,omitempty"` // The quoted message, if it is a reply
+}
+
+// LastInteraction is the most recent message exchanged in a chat, in either direction.
+type LastInteraction struct {
+	ChatJID string   `json:"chat_jid"`
+	Message *Message `json:"message,omitempty"` // Nil if the chat has no stored messages
+	Ago     string   `json:"ago,omitempty"`     // Human-readable, e.g. "3 days ago"
+}
+
+// MatchRange represents the character offsets [start, end) of a search term match within message content.
+type MatchRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // MessageContext represents a message with surrounding context.
@@ -40,6 +122,174 @@ type Contact struct {
 	Name  *string `json:"name,omitempty"`
 }
 
+// BusinessInfo reports whether a contact is a WhatsApp Business account.
+type BusinessInfo struct {
+	JID            string    `json:"jid"`
+	IsBusiness     *bool     `json:"is_business"` // nil when it could not be determined
+	BusinessName   *string   `json:"business_name,omitempty"`
+	VerifiedName   *string   `json:"verified_name,omitempty"`
+	VerifiedIssuer *string   `json:"verified_issuer,omitempty"` // Certificate issuer, e.g. "smb" or "ent"
+	Source         string    `json:"source"`                    // "live", "cache", or "unknown"
+	CheckedAt      time.Time `json:"checked_at,omitempty"`
+}
+
+// BlockedContact is an entry in the user's WhatsApp blocklist.
+type BlockedContact struct {
+	JID       string    `json:"jid"`
+	Phone     string    `json:"phone"`
+	Name      *string   `json:"name,omitempty"`
+	BlockedAt time.Time `json:"blocked_at"` // When the block was first seen by this server
+}
+
+// UnreadMessages holds the messages in a chat newer than the last-read marker.
+type UnreadMessages struct {
+	ChatJID       string     `json:"chat_jid"`
+	HasReadMarker bool       `json:"has_read_marker"`
+	LastReadTime  *time.Time `json:"last_read_time,omitempty"`
+	Count         int        `json:"count"`    // Total unread messages
+	Messages      []Message  `json:"messages"` // Oldest first, capped by limit
+	HasMore       bool       `json:"has_more"`
+}
+
+// MarkReadResult reports the outcome of marking a chat as read.
+type MarkReadResult struct {
+	ChatJID     string `json:"chat_jid"`
+	MarkedCount int    `json:"marked_count"` // Incoming messages acknowledged with read receipts
+}
+
+// ActivityHeatmap holds message counts by weekday (rows, Monday first) and hour of day
+// (columns, 0-23) in the configured timezone.
+type ActivityHeatmap struct {
+	ChatJID     string   `json:"chat_jid,omitempty"`
+	Timezone    string   `json:"timezone"`
+	Weekdays    []string `json:"weekdays"`
+	Hours       []int    `json:"hours"`
+	Matrix      [][]int  `json:"matrix"`
+	Total       int      `json:"total"`
+	PeakWeekday string   `json:"peak_weekday,omitempty"`
+	PeakHour    *int     `json:"peak_hour,omitempty"`
+}
+
+// ChatSender represents a distinct sender seen in a chat's message history.
+type ChatSender struct {
+	Sender          string    `json:"sender"`
+	Name            *string   `json:"name,omitempty"`
+	IsFromMe        bool      `json:"is_from_me"`
+	MessageCount    int       `json:"message_count"`
+	LastMessageTime time.Time `json:"last_message_time"`
+}
+
+// Group represents cached metadata about a WhatsApp group.
+type Group struct {
+	JID              string    `json:"jid"`
+	Name             string    `json:"name"`
+	ParticipantCount int       `json:"participant_count"`
+	IsAdmin          bool      `json:"is_admin"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// GroupDetails holds live metadata about a group, including its members.
+type GroupDetails struct {
+	JID                  string             `json:"jid"`
+	Name                 string             `json:"name"` // Group subject
+	Description          string             `json:"description,omitempty"`
+	CreatedAt            *time.Time         `json:"created_at,omitempty"`
+	Owner                string             `json:"owner,omitempty"` // Creator's JID
+	IsAdmin              bool               `json:"is_admin"`
+	AnnounceOnly         bool               `json:"announce_only"`          // Only admins can send messages
+	Locked               bool               `json:"locked"`                 // Only admins can edit group info
+	JoinApprovalRequired bool               `json:"join_approval_required"` // Admins must approve new members
+	InviteLinkState      string             `json:"invite_link_state"`      // "available", "admin_only", or "unavailable"
+	InviteLink           string             `json:"invite_link,omitempty"`
+	ParticipantCount     int                `json:"participant_count"`
+	Participants         []GroupParticipant `json:"participants"`
+}
+
+// GroupParticipant is a member of a group.
+type GroupParticipant struct {
+	JID          string  `json:"jid"`
+	Name         *string `json:"name,omitempty"`
+	IsAdmin      bool    `json:"is_admin"`
+	IsSuperAdmin bool    `json:"is_super_admin"` // Group creator
+	IsMe         bool    `json:"is_me,omitempty"`
+}
+
+// ParticipantChangeResult reports the outcome of adding, removing, promoting or
+// demoting one group participant.
+type ParticipantChangeResult struct {
+	Participant string `json:"participant"` // As given by the caller
+	JID         string `json:"jid,omitempty"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
+
+// GroupJoinResult describes a group joined (or requested to join) via an invite link.
+type GroupJoinResult struct {
+	JID             string `json:"jid"`
+	Name            string `json:"name"`
+	PendingApproval bool   `json:"pending_approval"` // The group requires an admin to approve the join request
+}
+
+// GroupPicture describes a group's profile photo saved to local storage.
+type GroupPicture struct {
+	JID        string `json:"jid"`
+	HasPicture bool   `json:"has_picture"`
+	PictureID  string `json:"picture_id,omitempty"`
+	Path       string `json:"path,omitempty"`
+}
+
+// ProfilePicture is a contact's or group's avatar downloaded to local storage.
+type ProfilePicture struct {
+	JID        string     `json:"jid"`
+	HasPicture bool       `json:"has_picture"`
+	Preview    bool       `json:"preview"` // Low-resolution thumbnail rather than full size
+	PictureID  string     `json:"picture_id,omitempty"`
+	URL        string     `json:"url,omitempty"` // WhatsApp CDN URL; expires after a while
+	Path       string     `json:"path,omitempty"`
+	Cached     bool       `json:"cached"` // Unchanged since the last download, so not fetched again
+	FetchedAt  *time.Time `json:"fetched_at,omitempty"`
+	Message    string     `json:"message,omitempty"` // Why there is no picture
+}
+
+// Poll represents a poll created in a chat, by the user or another participant.
+type Poll struct {
+	ChatJID       string    `json:"chat_jid"`
+	MessageID     string    `json:"message_id"`
+	Sender        string    `json:"sender"`
+	Question      string    `json:"question"`
+	Options       []string  `json:"options"`
+	AllowMultiple bool      `json:"allow_multiple"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PollResults holds the current vote tally for a poll.
+type PollResults struct {
+	Poll
+	Results     []PollOptionResult `json:"results"` // In the poll's option order
+	TotalVoters int                `json:"total_voters"`
+}
+
+// PollOptionResult is the vote count and voters for one poll option.
+type PollOptionResult struct {
+	Option string      `json:"option"`
+	Votes  int         `json:"votes"`
+	Voters []PollVoter `json:"voters"`
+}
+
+// PollVoter identifies someone who voted in a poll.
+type PollVoter struct {
+	Sender string  `json:"sender"`
+	Name   *string `json:"name,omitempty"`
+}
+
+// Reaction is one person's emoji reaction to a message.
+type Reaction struct {
+	Emoji      string    `json:"emoji"`
+	Sender     string    `json:"sender"`
+	SenderName *string   `json:"sender_name,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
 // SendResult represents the result of sending a message.
 type SendResult struct {
 	Success   bool    `json:"success"`
@@ -47,6 +297,8 @@ type SendResult struct {
 	MessageID *string `json:"message_id,omitempty"`
 	ChatJID   *string `json:"chat_jid,omitempty"`
 	Timestamp *string `json:"timestamp,omitempty"`
+
+	QuotedMessageID *string `json:"quoted_message_id,omitempty"` // Set when the send quoted or reacted to another message
 }
 
 // DownloadResult represents the result of downloading media.
@@ -57,23 +309,131 @@ type DownloadResult struct {
 	Path     string `json:"path,omitempty"`
 }
 
+// ConversationArchive describes a zip export of a chat's transcript, JSON and media.
+type ConversationArchive struct {
+	ChatJID     string `json:"chat_jid"`
+	Path        string `json:"path"`
+	Messages    int    `json:"messages"`
+	Truncated   bool   `json:"truncated,omitempty"` // Older messages beyond the export cap were left out
+	MediaFiles  int    `json:"media_files"`
+	MediaFailed int    `json:"media_failed,omitempty"` // Attachments that could no longer be downloaded
+	FileCount   int    `json:"file_count"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+// MediaItem is a message carrying media, as listed by list_media.
+type MediaItem struct {
+	MessageID  string    `json:"message_id"`
+	ChatJID    string    `json:"chat_jid"`
+	Sender     string    `json:"sender"`
+	IsFromMe   bool      `json:"is_from_me"`
+	Timestamp  time.Time `json:"timestamp"`
+	MediaType  string    `json:"media_type"`
+	Filename   string    `json:"filename"` // Name the file has, or will have, once downloaded
+	MimeType   string    `json:"mime_type,omitempty"`
+	Caption    string    `json:"caption,omitempty"`
+	SizeBytes  uint64    `json:"size_bytes,omitempty"`
+	Downloaded bool      `json:"downloaded"`     // The file is already in the chat's media folder
+	Path       string    `json:"path,omitempty"` // Local path, when downloaded
+}
+
+// ListMediaOptions contains options for listing a chat's media messages.
+type ListMediaOptions struct {
+	ChatJID   string
+	MediaType string // "image", "video", "audio", "document" or "sticker"; empty for all
+	After     string
+	Before    string
+	Sort      string // SortDesc (newest first, default) or SortAsc
+	Limit     int    // 0 returns every match
+	Page      int
+}
+
+// Bulk download outcomes for a single media item.
+const (
+	DownloadStatusDownloaded = "downloaded"
+	DownloadStatusExpired    = "expired" // WhatsApp no longer has the file
+	DownloadStatusFailed     = "failed"
+)
+
+// BulkDownloadItem is the outcome of downloading one media message.
+type BulkDownloadItem struct {
+	MessageID string `json:"message_id"`
+	MediaType string `json:"media_type"`
+	Status    string `json:"status"`
+	Path      string `json:"path,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkDownloadResult summarises a download_all_media run.
+type BulkDownloadResult struct {
+	ChatJID           string             `json:"chat_jid"`
+	AlreadyDownloaded int                `json:"already_downloaded"` // Matches skipped because they're saved locally
+	Downloaded        int                `json:"downloaded"`
+	Expired           int                `json:"expired"`
+	Failed            int                `json:"failed"`
+	Remaining         int                `json:"remaining"` // Not attempted this run because of the per-call cap
+	Items             []BulkDownloadItem `json:"items"`
+}
+
+// ChatExport describes a transcript file written by export_chat.
+type ChatExport struct {
+	ChatJID   string `json:"chat_jid"`
+	Format    string `json:"format"`
+	Path      string `json:"path"`
+	Messages  int    `json:"messages"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// Export formats for export_chat.
+const (
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+	ExportTXT  = "txt"
+)
+
+// SendMessageOptions contains options for sending a text or media message.
+type SendMessageOptions struct {
+	ReplyToMessageID string   // Message ID to quote in a threaded reply
+	ReplyToSender    string   // Quoted message's author, used when the message isn't stored locally
+	ReplyToText      string   // Quoted message's text, used when the message isn't stored locally
+	Mentions         []string // Participants to @mention (phone numbers, JIDs, or names); groups only
+	Force            bool     // Send even during configured quiet hours
+	Filename         string   // Display filename for documents; defaults to the file's base name
+}
+
 // ListChatsOptions contains options for listing chats.
 // Always sorted by last activity and includes last message preview.
 type ListChatsOptions struct {
-	Query      string
-	OnlyGroups bool
-	Limit      int
-	Page       int
+	Query           string
+	OnlyGroups      bool
+	PinnedOnly      bool
+	IncludeArchived bool
+	ExcludeMuted    bool
+	Sort            string // ChatSortRecent (default) or ChatSortName
+	Limit           int
+	Page            int
 }
 
+// Chat orderings for ListChatsOptions.Sort.
+const (
+	ChatSortRecent = "recent"
+	ChatSortName   = "name"
+)
+
 // ListMessagesOptions contains options for listing messages.
 type ListMessagesOptions struct {
 	After     string
 	Before    string
 	Timeframe string // Natural time range: "today", "yesterday", "this_week", etc.
 	ChatJID   string
+	Sender    string // Sender's phone number (JID user part)
+	MediaType string // "image", "video", "audio", "document" or "sticker"
+	Direction string // DirectionAll (default), DirectionIncoming or DirectionOutgoing
+	Sort      string // SortDesc (newest first, default) or SortAsc; pages count from that end
 	Limit     int
 	Page      int
+
+	IncludeSystem bool // Include system/protocol messages and reactions
 }
 
 // SearchMessagesOptions contains options for searching messages.
@@ -83,15 +443,52 @@ type SearchMessagesOptions struct {
 	After     string
 	Before    string
 	Timeframe string // Natural time range: "today", "yesterday", "this_week", etc.
+	ChatJID   string // Only search this chat; empty searches everywhere
 	Limit     int
 	Page      int
+
+	IncludeMatchRanges bool   // Include character offsets of matched terms in each result
+	IncludeSystem      bool   // Include system/protocol messages and reactions
+	Sort               string // SearchSortRecent (default) or SearchSortRelevance
+
+	MaxRows int // Cap on returned rows, matches plus context; 0 means no cap
+}
+
+// Message orderings for ListMessagesOptions.Sort.
+const (
+	SortDesc = "desc"
+	SortAsc  = "asc"
+)
+
+// Message directions for ListMessagesOptions.Direction.
+const (
+	DirectionAll      = "all"
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+// Search result orderings for SearchMessagesOptions.Sort.
+const (
+	SearchSortRecent    = "recent"
+	SearchSortRelevance = "relevance"
+)
+
+// ChatTimelineOptions contains options for building a day-by-day chat timeline.
+type ChatTimelineOptions struct {
+	ChatJID     string
+	After       string
+	Before      string
+	Timeframe   string // Natural time range: "today", "yesterday", "this_week", etc.
+	DaysPerPage int
+	Page        int
 }
 
 // CatchUpOptions contains options for the catch_up composite tool.
 // Always includes media summary with standard detail level.
 type CatchUpOptions struct {
-	Timeframe  string // Natural time range: "last_hour", "today", "yesterday", etc.
-	OnlyGroups bool   // Only include group chat activity
+	Timeframe    string // Natural time range: "last_hour", "today", "yesterday", etc.
+	OnlyGroups   bool   // Only include group chat activity
+	IncludeMuted bool   // Include muted chats in the active chat list
 }
 
 // CatchUpSummary represents the result of a catch_up operation.
@@ -102,7 +499,26 @@ type CatchUpSummary struct {
 	ActiveChats    []ActiveChatInfo `json:"active_chats"`
 	QuestionsForMe []Message        `json:"questions_for_me,omitempty"`
 	MediaSummary   *MediaSummary    `json:"media_summary,omitempty"`
-	NeedsAttention []string         `json:"needs_attention,omitempty"` // Chat names with unanswered questions
+	NeedsAttention []string         `json:"needs_attention,omitempty"`  // Chat names with unanswered questions
+	UnusuallyBusy  []string         `json:"unusually_active,omitempty"` // Chat names far busier than their baseline
+}
+
+// ChatTimeline represents a chat's messages grouped into day buckets, newest day first.
+type ChatTimeline struct {
+	ChatJID   string        `json:"chat_jid"`
+	Days      []TimelineDay `json:"days"`
+	TotalDays int           `json:"total_days"`
+	Page      int           `json:"page"`
+	HasMore   bool          `json:"has_more"`
+	Truncated bool          `json:"truncated,omitempty"` // Older messages beyond the scan cap were not included
+}
+
+// TimelineDay represents a single day of messages, oldest message first.
+type TimelineDay struct {
+	Date     string    `json:"date"` // YYYY-MM-DD in the configured timezone
+	Weekday  string    `json:"weekday"`
+	Count    int       `json:"count"`
+	Messages []Message `json:"messages"`
 }
 
 // ActiveChatInfo represents an active chat with recent activity.
@@ -115,7 +531,11 @@ type ActiveChatInfo struct {
 	LastMessageText *string   `json:"last_message_text,omitempty"`
 	LastIsFromMe    bool      `json:"last_is_from_me"`
 	HasQuestions    bool      `json:"has_questions"`
-	RecentMessages  []Message `json:"recent_messages,omitempty"`
+
+	BaselineDailyAverage *float64 `json:"baseline_daily_average,omitempty"` // Messages per day over the preceding weeks
+	UnusuallyActive      bool     `json:"unusually_active,omitempty"`       // Far busier than its baseline in this timeframe
+
+	RecentMessages []Message `json:"recent_messages,omitempty"`
 }
 
 // MediaSummary represents media activity in a timeframe.
@@ -126,3 +546,14 @@ type MediaSummary struct {
 	DocumentCount int      `json:"document_count"`
 	FromChats     []string `json:"from_chats,omitempty"` // Chat names with media
 }
+
+// UserInfo represents WhatsApp profile information for a single recipient.
+type UserInfo struct {
+	Recipient    string   `json:"recipient"`
+	JID          string   `json:"jid,omitempty"`
+	Status       *string  `json:"status,omitempty"`
+	PictureID    *string  `json:"picture_id,omitempty"`
+	Devices      []string `json:"devices,omitempty"`
+	VerifiedName *string  `json:"verified_name,omitempty"`
+	Error        *string  `json:"error,omitempty"`
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"time"
 )
 
@@ -18,7 +20,15 @@ const (
 	TimeframeThisMonth TimeframePreset = "this_month"
 )
 
-// ParseTimeframe converts a timeframe preset string into after/before timestamps.
+// relativeTimeframe matches rolling windows such as "last_7_days", "last_2_hours"
+// or "last_30_minutes".
+var relativeTimeframe = regexp.MustCompile(`^last_(\d+)_(minute|hour|day|week)s?$`)
+
+// maxRelativeCount bounds N in "last_N_<unit>" so the window can't overflow.
+const maxRelativeCount = 100000
+
+// ParseTimeframe converts a timeframe preset string, or a rolling window such as
+// "last_7_days", into after/before timestamps.
 // Returns ISO-8601 formatted timestamps suitable for database queries.
 // If the timeframe is empty or invalid, returns empty strings and an error.
 func ParseTimeframe(timeframe string) (after string, before string, err error) {
@@ -80,33 +90,46 @@ func ParseTimeframe(timeframe string) (after string, before string, err error) {
 		beforeTime = now
 
 	default:
-		return "", "", fmt.Errorf("invalid timeframe: %s (valid options: last_hour, today, yesterday, last_3_days, this_week, last_week, this_month)", timeframe)
+		var ok bool
+		afterTime, ok, err = parseRelativeTimeframe(timeframe, now)
+		if err != nil {
+			return "", "", err
+		}
+		if !ok {
+			return "", "", fmt.Errorf("invalid timeframe: %s (valid options: last_hour, today, yesterday, last_3_days, this_week, last_week, this_month, or last_N_minutes/hours/days/weeks)", timeframe)
+		}
+		beforeTime = now
 	}
 
 	return afterTime.Format(time.RFC3339), beforeTime.Format(time.RFC3339), nil
 }
 
-// ValidateTimeframe checks if a timeframe string is valid
-func ValidateTimeframe(timeframe string) error {
-	if timeframe == "" {
-		return nil
+// parseRelativeTimeframe returns the start of a "last_N_<unit>" window ending at
+// now. ok is false if timeframe isn't of that form.
+func parseRelativeTimeframe(timeframe string, now time.Time) (after time.Time, ok bool, err error) {
+	m := relativeTimeframe.FindStringSubmatch(timeframe)
+	if m == nil {
+		return time.Time{}, false, nil
 	}
-
-	validTimeframes := []TimeframePreset{
-		TimeframeLastHour,
-		TimeframeToday,
-		TimeframeYesterday,
-		TimeframeLast3Days,
-		TimeframeThisWeek,
-		TimeframeLastWeek,
-		TimeframeThisMonth,
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n < 1 || n > maxRelativeCount {
+		return time.Time{}, true, fmt.Errorf("invalid timeframe: %s (count must be between 1 and %d)", timeframe, maxRelativeCount)
 	}
 
-	for _, valid := range validTimeframes {
-		if TimeframePreset(timeframe) == valid {
-			return nil
-		}
+	switch m[2] {
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), true, nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), true, nil
+	case "day":
+		return now.AddDate(0, 0, -n), true, nil
+	default:
+		return now.AddDate(0, 0, -7*n), true, nil
 	}
+}
 
-	return fmt.Errorf("invalid timeframe: %s", timeframe)
+// ValidateTimeframe checks if a timeframe string is valid
+func ValidateTimeframe(timeframe string) error {
+	_, _, err := ParseTimeframe(timeframe)
+	return err
 }
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRelativeTimeframe(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		timeframe string
+		wantOK    bool
+		wantErr   bool
+		want      time.Time
+	}{
+		{timeframe: "last_30_minutes", wantOK: true, want: now.Add(-30 * time.Minute)},
+		{timeframe: "last_1_minute", wantOK: true, want: now.Add(-time.Minute)},
+		{timeframe: "last_2_hours", wantOK: true, want: now.Add(-2 * time.Hour)},
+		{timeframe: "last_7_days", wantOK: true, want: now.AddDate(0, 0, -7)},
+		{timeframe: "last_1_day", wantOK: true, want: now.AddDate(0, 0, -1)},
+		{timeframe: "last_3_weeks", wantOK: true, want: now.AddDate(0, 0, -21)},
+		{timeframe: "last_100000_days", wantOK: true, want: now.AddDate(0, 0, -100000)},
+		{timeframe: "last_0_days", wantOK: true, wantErr: true},
+		{timeframe: "last_100001_days", wantOK: true, wantErr: true},
+		{timeframe: "last_99999999999999999999_days", wantOK: true, wantErr: true},
+		{timeframe: "last_-3_days"},
+		{timeframe: "last_3_months"},
+		{timeframe: "last_3_years"},
+		{timeframe: "last_days"},
+		{timeframe: "last_3_Days"},
+		{timeframe: "last_3_days_ago"},
+		{timeframe: "next_3_days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeframe, func(t *testing.T) {
+			got, ok, err := parseRelativeTimeframe(tt.timeframe, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("after = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeframeRelative(t *testing.T) {
+	tests := []struct {
+		timeframe string
+		wantErr   bool
+		window    time.Duration
+	}{
+		{timeframe: "last_2_hours", window: 2 * time.Hour},
+		{timeframe: "last_45_minutes", window: 45 * time.Minute},
+		{timeframe: "last_0_days", wantErr: true},
+		{timeframe: "last_-1_hours", wantErr: true},
+		{timeframe: "last_5_fortnights", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeframe, func(t *testing.T) {
+			after, before, err := ParseTimeframe(tt.timeframe)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			a, err := time.Parse(time.RFC3339, after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := time.Parse(time.RFC3339, before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := b.Sub(a); got != tt.window {
+				t.Errorf("window = %v, want %v", got, tt.window)
+			}
+		})
+	}
+}
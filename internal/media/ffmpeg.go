@@ -5,15 +5,46 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
-var ffmpegBin = "ffmpeg"
+var (
+	ffmpegBin  = "ffmpeg"
+	ffprobeBin = "ffprobe"
+)
 
 // SetFFmpegPath allows overriding the ffmpeg binary path via configuration.
+// ffprobe is expected alongside it, e.g. /opt/bin/ffprobe for /opt/bin/ffmpeg.
 func SetFFmpegPath(path string) {
 	if path != "" {
 		ffmpegBin = path
+		dir, name := filepath.Split(path)
+		if strings.Contains(name, "ffmpeg") {
+			ffprobeBin = dir + strings.Replace(name, "ffmpeg", "ffprobe", 1)
+		}
+	}
+}
+
+// ProbeDuration returns a media file's container duration in seconds using ffprobe.
+func ProbeDuration(path string) (float64, error) {
+	if path == "" {
+		return 0, fmt.Errorf("no file to probe")
+	}
+	out, err := exec.Command(ffprobeBin,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("ffprobe reported no duration for %s", path)
 	}
+	return d, nil
 }
 
 // ConvertToOpusOgg converts an input audio file to .ogg (Opus) using ffmpeg.
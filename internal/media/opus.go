@@ -9,7 +9,9 @@ import (
 )
 
 // AnalyzeOggOpus computes duration seconds and a 64-byte waveform for WhatsApp PTT.
-func AnalyzeOggOpus(data []byte) (uint32, []byte, error) {
+// When the final granule position can't be read, the duration comes from probing
+// path with ffprobe, and only then from the file size.
+func AnalyzeOggOpus(data []byte, path string) (uint32, []byte, error) {
 	if len(data) < 4 || string(data[0:4]) != "OggS" {
 		return 0, nil, errors.New("not an Ogg file")
 	}
@@ -59,6 +61,8 @@ func AnalyzeOggOpus(data []byte) (uint32, []byte, error) {
 	if lastGranule > 0 {
 		d := float64(lastGranule-uint64(preSkip)) / float64(sampleRate)
 		duration = uint32(math.Ceil(d))
+	} else if d, err := ProbeDuration(path); err == nil {
+		duration = uint32(math.Ceil(d))
 	} else {
 		duration = uint32(float64(len(data)) / 2000.0)
 	}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// oggPage builds one Ogg page carrying payload at granule position granule.
+func oggPage(seq uint32, granule uint64, payload []byte) []byte {
+	var p bytes.Buffer
+	p.WriteString("OggS")
+	p.Write([]byte{0, 0})
+	binary.Write(&p, binary.LittleEndian, granule)
+	binary.Write(&p, binary.LittleEndian, uint32(1)) // serial
+	binary.Write(&p, binary.LittleEndian, seq)
+	binary.Write(&p, binary.LittleEndian, uint32(0)) // CRC, unchecked
+	var segments []byte
+	for n := len(payload); ; n -= 255 {
+		if n < 255 {
+			segments = append(segments, byte(n))
+			break
+		}
+		segments = append(segments, 255)
+	}
+	p.WriteByte(byte(len(segments)))
+	p.Write(segments)
+	p.Write(payload)
+	return p.Bytes()
+}
+
+// opusHead is an OpusHead packet with the given pre-skip at 48kHz.
+func opusHead(preSkip uint16) []byte {
+	var h bytes.Buffer
+	h.WriteString("OpusHead")
+	h.Write([]byte{1, 1})
+	binary.Write(&h, binary.LittleEndian, preSkip)
+	binary.Write(&h, binary.LittleEndian, uint32(48000))
+	h.Write([]byte{0, 0, 0})
+	// AnalyzeOggOpus wants a few bytes past the header before trusting it
+	h.Write(make([]byte, 8))
+	return h.Bytes()
+}
+
+// oggWithoutGranules is an Ogg stream of size roughly n bytes whose pages
+// never carry a granule position.
+func oggWithoutGranules(n int) []byte {
+	data := oggPage(0, 0, opusHead(312))
+	for seq := uint32(1); len(data) < n; seq++ {
+		data = append(data, oggPage(seq, 0, make([]byte, 1000))...)
+	}
+	return append(data, make([]byte, 64)...)
+}
+
+func TestAnalyzeOggOpusDuration(t *testing.T) {
+	// A path ffprobe can't read, so the size estimate is used without granules
+	unreadable := filepath.Join(t.TempDir(), "missing.ogg")
+
+	tests := []struct {
+		name string
+		data []byte
+		want uint32
+	}{
+		{
+			name: "from final granule",
+			data: append(append(oggPage(0, 0, opusHead(312)), oggPage(1, 48000*5+312, make([]byte, 100))...), make([]byte, 64)...),
+			want: 5,
+		},
+		{name: "no granules, estimated from size", data: oggWithoutGranules(20000), want: 10},
+		{name: "no granules, clamped to 1s", data: oggWithoutGranules(100), want: 1},
+		{name: "no granules, clamped to 300s", data: oggWithoutGranules(2000 * 400), want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dur, waveform, err := AnalyzeOggOpus(tt.data, unreadable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dur != tt.want {
+				t.Errorf("duration = %d, want %d", dur, tt.want)
+			}
+			if len(waveform) != 64 {
+				t.Errorf("waveform has %d values, want 64", len(waveform))
+			}
+		})
+	}
+}
+
+func TestAnalyzeOggOpusRejectsNonOgg(t *testing.T) {
+	if _, _, err := AnalyzeOggOpus([]byte("ID3\x04not ogg"), ""); err == nil {
+		t.Error("expected an error for non-Ogg data")
+	}
+}
+
+func TestProbeDurationUnreadable(t *testing.T) {
+	garbage := filepath.Join(t.TempDir(), "garbage.ogg")
+	if err := os.WriteFile(garbage, []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"", garbage, filepath.Join(t.TempDir(), "missing.ogg")} {
+		if d, err := ProbeDuration(path); err == nil {
+			t.Errorf("ProbeDuration(%q) = %v, want an error", path, d)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var (
+	pdfinfoBin  = "pdfinfo"
+	pdftoppmBin = "pdftoppm"
+)
+
+// pdfThumbnailSize is the longest edge, in pixels, of generated PDF thumbnails.
+const pdfThumbnailSize = 240
+
+var (
+	pdfinfoPagesRe = regexp.MustCompile(`(?m)^Pages:\s+(\d+)`)
+	pdfPagesRe     = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfPageRe      = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+// PDFPageCount returns the number of pages in a PDF, using pdfinfo when it is
+// installed and falling back to scanning the file's page tree otherwise.
+func PDFPageCount(path string) (int, error) {
+	if out, err := exec.Command(pdfinfoBin, path).Output(); err == nil {
+		if m := pdfinfoPagesRe.FindSubmatch(out); m != nil {
+			return strconv.Atoi(string(m[1]))
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return countPDFPages(b)
+}
+
+// countPDFPages extracts the page count from raw PDF bytes. The largest /Count of a
+// /Pages node is the root's total; if none is readable (e.g. compressed object
+// streams), individual /Type /Page objects are counted instead.
+func countPDFPages(b []byte) (int, error) {
+	if !bytes.HasPrefix(b, []byte("%PDF-")) {
+		return 0, fmt.Errorf("not a PDF file")
+	}
+
+	count := 0
+	for _, m := range pdfPagesRe.FindAllSubmatch(b, -1) {
+		digits := m[1]
+		if len(digits) == 0 {
+			digits = m[2]
+		}
+		if n, err := strconv.Atoi(string(digits)); err == nil && n > count {
+			count = n
+		}
+	}
+	if count == 0 {
+		count = len(pdfPageRe.FindAll(b, -1))
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("could not determine page count")
+	}
+	return count, nil
+}
+
+// PDFThumbnail renders the first page of a PDF as a small JPEG using pdftoppm.
+// Returns an error if pdftoppm is not installed or rendering fails.
+func PDFThumbnail(path string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "pdfthumb")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	prefix := filepath.Join(dir, "thumb")
+	cmd := exec.Command(pdftoppmBin,
+		"-jpeg",
+		"-f", "1",
+		"-l", "1",
+		"-singlefile",
+		"-scale-to", strconv.Itoa(pdfThumbnailSize),
+		path,
+		prefix,
+	)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %w", err)
+	}
+	return os.ReadFile(prefix + ".jpg")
+}
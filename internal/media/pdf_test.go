@@ -0,0 +1,87 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// minimalPDF is an uncompressed PDF with a flat three-page tree.
+const minimalPDF = `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R >> endobj
+4 0 obj << /Type /Page /Parent 2 0 R >> endobj
+5 0 obj << /Type /Page /Parent 2 0 R >> endobj
+trailer << /Root 1 0 R >>
+%%EOF
+`
+
+func TestCountPDFPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{name: "flat page tree", data: minimalPDF, want: 3},
+		{
+			name: "nested tree uses the root count",
+			data: "%PDF-1.7\n" +
+				"2 0 obj << /Type /Pages /Kids [6 0 R 7 0 R] /Count 5 >> endobj\n" +
+				"6 0 obj << /Type /Pages /Parent 2 0 R /Count 2 >> endobj\n" +
+				"7 0 obj << /Type /Pages /Parent 2 0 R /Count 3 >> endobj\n",
+			want: 5,
+		},
+		{name: "count before type", data: "%PDF-1.5\n2 0 obj << /Count 12 /Kids [] /Type /Pages >> endobj\n", want: 12},
+		{
+			name: "no readable page tree counts page objects",
+			data: "%PDF-1.5\n3 0 obj << /Type /Page >> endobj\n4 0 obj << /Type/Page >> endobj\n",
+			want: 2,
+		},
+		{name: "no pages", data: "%PDF-1.5\n1 0 obj << /Type /Catalog >> endobj\n", wantErr: true},
+		{name: "not a PDF", data: "PK\x03\x04" + strings.Repeat("/Type /Page ", 3), wantErr: true},
+		{name: "empty", data: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := countPDFPages([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("countPDFPages = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("countPDFPages = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFPageCountWithoutPdfinfo(t *testing.T) {
+	orig := pdfinfoBin
+	pdfinfoBin = filepath.Join(t.TempDir(), "no-pdfinfo")
+	t.Cleanup(func() { pdfinfoBin = orig })
+
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(path, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := PDFPageCount(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3 {
+		t.Errorf("PDFPageCount = %d, want 3", got)
+	}
+
+	if _, err := PDFPageCount(filepath.Join(t.TempDir(), "missing.pdf")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,121 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"os/exec"
+
+	// Registered so image.Decode understands the formats sent as images
+	_ "image/gif"
+	_ "image/png"
+)
+
+// thumbnailSize is the longest edge, in pixels, of generated image and video thumbnails.
+const thumbnailSize = 100
+
+// ImageThumbnail decodes an image file and returns a small JPEG thumbnail along
+// with the image's full width and height.
+func ImageThumbnail(path string) (thumb []byte, width, height int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("decode image: %w", err)
+	}
+	thumb, err = encodeThumbnail(img)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return thumb, img.Bounds().Dx(), img.Bounds().Dy(), nil
+}
+
+// VideoThumbnail extracts a frame about one second into a video with ffmpeg
+// (the first frame for shorter clips) and returns it as a small JPEG thumbnail,
+// along with the video's width and height.
+func VideoThumbnail(path string) (thumb []byte, width, height int, err error) {
+	var out []byte
+	for _, at := range []string{"1", "0"} {
+		out, err = exec.Command(ffmpegBin,
+			"-v", "error",
+			"-ss", at,
+			"-i", path,
+			"-frames:v", "1",
+			"-f", "image2pipe",
+			"-c:v", "mjpeg",
+			"-",
+		).Output()
+		if err == nil && len(out) > 0 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, 0, 0, fmt.Errorf("ffmpeg returned no frame for %s", path)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("decode frame: %w", err)
+	}
+	thumb, err = encodeThumbnail(img)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return thumb, img.Bounds().Dx(), img.Bounds().Dy(), nil
+}
+
+// encodeThumbnail downscales img to thumbnailSize and encodes it as JPEG.
+func encodeThumbnail(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, downscale(img, thumbnailSize), &jpeg.Options{Quality: 75}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale shrinks img so its longest edge is at most maxEdge, keeping the
+// aspect ratio and averaging each block of source pixels. Images already small
+// enough are returned unchanged.
+func downscale(img image.Image, maxEdge int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxEdge && h <= maxEdge {
+		return img
+	}
+
+	tw, th := maxEdge, max(1, h*maxEdge/w)
+	if h > w {
+		tw, th = max(1, w*maxEdge/h), maxEdge
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := range th {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := range tw {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
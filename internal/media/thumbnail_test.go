@@ -0,0 +1,61 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDownscale(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		maxEdge      int
+		wantW, wantH int
+	}{
+		{name: "landscape", w: 400, h: 200, maxEdge: 100, wantW: 100, wantH: 50},
+		{name: "portrait", w: 300, h: 900, maxEdge: 100, wantW: 33, wantH: 100},
+		{name: "square", w: 250, h: 250, maxEdge: 100, wantW: 100, wantH: 100},
+		{name: "one pixel tall", w: 1000, h: 1, maxEdge: 100, wantW: 100, wantH: 1},
+		{name: "one pixel wide", w: 1, h: 1000, maxEdge: 100, wantW: 1, wantH: 100},
+		{name: "thin edge rounds up to a pixel", w: 5000, h: 20, maxEdge: 100, wantW: 100, wantH: 1},
+		{name: "already small", w: 80, h: 60, maxEdge: 100, wantW: 80, wantH: 60},
+		{name: "exactly max edge", w: 100, h: 40, maxEdge: 100, wantW: 100, wantH: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			got := downscale(src, tt.maxEdge)
+			if b := got.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("downscale(%dx%d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.maxEdge, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			if tt.w <= tt.maxEdge && tt.h <= tt.maxEdge && got != image.Image(src) {
+				t.Error("image already within maxEdge was copied instead of returned unchanged")
+			}
+		})
+	}
+}
+
+func TestDownscaleAveragesBlocks(t *testing.T) {
+	// Left half black, right half white, shrunk to 2x1: each output pixel
+	// averages only its own half
+	src := image.NewRGBA(image.Rect(10, 10, 210, 110))
+	for y := 10; y < 110; y++ {
+		for x := 10; x < 210; x++ {
+			c := color.RGBA{A: 255}
+			if x >= 110 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	got := downscale(src, 2).(*image.RGBA)
+	if b := got.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("size = %dx%d, want 2x1", b.Dx(), b.Dy())
+	}
+	if left, right := got.RGBAAt(0, 0), got.RGBAAt(1, 0); left.R != 0 || right.R != 255 {
+		t.Errorf("left = %v, right = %v, want black and white", left, right)
+	}
+}
@@ -0,0 +1,67 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+)
+
+const (
+	// waveformBuckets is the number of values in a WhatsApp voice note waveform.
+	waveformBuckets = 64
+	// waveformSampleRate is the rate audio is decoded at for waveforms; an
+	// amplitude envelope doesn't need more.
+	waveformSampleRate = 8000
+)
+
+// AudioWaveform decodes an audio file with ffmpeg and returns its 64-value PTT
+// waveform: the RMS amplitude of each slice, scaled so the loudest is 100.
+func AudioWaveform(path string) ([]byte, error) {
+	out, err := exec.Command(ffmpegBin,
+		"-v", "error",
+		"-i", path,
+		"-f", "s16le",
+		"-ac", "1",
+		"-ar", strconv.Itoa(waveformSampleRate),
+		"-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	return pcmWaveform(out)
+}
+
+// pcmWaveform splits signed 16-bit little-endian mono PCM into waveformBuckets
+// equal slices and returns each slice's RMS amplitude, normalised to 0-100
+// against the loudest. Silence yields all zeros.
+func pcmWaveform(pcm []byte) ([]byte, error) {
+	samples := len(pcm) / 2
+	if samples < waveformBuckets {
+		return nil, errors.New("audio too short for a waveform")
+	}
+
+	rms := make([]float64, waveformBuckets)
+	var peak float64
+	for i := range rms {
+		start, end := i*samples/waveformBuckets, (i+1)*samples/waveformBuckets
+		var sum float64
+		for j := start; j < end; j++ {
+			s := float64(int16(binary.LittleEndian.Uint16(pcm[2*j:])))
+			sum += s * s
+		}
+		rms[i] = math.Sqrt(sum / float64(end-start))
+		peak = math.Max(peak, rms[i])
+	}
+
+	wf := make([]byte, waveformBuckets)
+	if peak == 0 {
+		return wf, nil
+	}
+	for i, r := range rms {
+		wf[i] = byte(math.Round(r / peak * 100))
+	}
+	return wf, nil
+}
@@ -0,0 +1,86 @@
+package media
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// synthPCM builds s16le mono PCM from sections of (samples, amplitude) square
+// wave, whose RMS is exactly its amplitude.
+func synthPCM(sections ...[2]int) []byte {
+	var pcm []byte
+	for _, s := range sections {
+		for i := 0; i < s[0]; i++ {
+			v := int16(s[1])
+			if i%2 == 1 {
+				v = -v
+			}
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+		}
+	}
+	return pcm
+}
+
+func TestPCMWaveform(t *testing.T) {
+	const bucket = 100 // samples per waveform bucket
+
+	tests := []struct {
+		name  string
+		pcm   []byte
+		check func(t *testing.T, wf []byte)
+	}{
+		{
+			name: "loud then silent",
+			pcm:  synthPCM([2]int{32 * bucket, 20000}, [2]int{32 * bucket, 0}),
+			check: func(t *testing.T, wf []byte) {
+				for i, v := range wf {
+					if want := byte(100); i < 32 && v != want {
+						t.Errorf("bucket %d = %d, want %d", i, v, want)
+					}
+					if i >= 32 && v != 0 {
+						t.Errorf("bucket %d = %d, want 0", i, v)
+					}
+				}
+			},
+		},
+		{
+			name: "quiet section scales against the loudest",
+			pcm:  synthPCM([2]int{32 * bucket, 4000}, [2]int{32 * bucket, 16000}),
+			check: func(t *testing.T, wf []byte) {
+				if wf[0] != 25 || wf[63] != 100 {
+					t.Errorf("quiet = %d, loud = %d, want 25 and 100", wf[0], wf[63])
+				}
+			},
+		},
+		{
+			name: "silence",
+			pcm:  synthPCM([2]int{64 * bucket, 0}),
+			check: func(t *testing.T, wf []byte) {
+				for i, v := range wf {
+					if v != 0 {
+						t.Errorf("bucket %d = %d, want 0", i, v)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf, err := pcmWaveform(tt.pcm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(wf) != waveformBuckets {
+				t.Fatalf("got %d buckets, want %d", len(wf), waveformBuckets)
+			}
+			tt.check(t, wf)
+		})
+	}
+}
+
+func TestPCMWaveformTooShort(t *testing.T) {
+	if _, err := pcmWaveform(synthPCM([2]int{waveformBuckets - 1, 1000})); err == nil {
+		t.Error("expected an error for fewer samples than buckets")
+	}
+}
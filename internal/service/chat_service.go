@@ -1,42 +1,189 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/eddmann/whatsapp-mcp/internal/config"
 	"github.com/eddmann/whatsapp-mcp/internal/domain"
 	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/wa"
 )
 
+// ErrChatNotFound is returned when a chat JID isn't in the local store.
+var ErrChatNotFound = errors.New("CHAT_NOT_FOUND")
+
 // ChatService handles chat-related business logic.
 type ChatService struct {
-	store *store.DB
+	store  *store.DB
+	client *wa.Client
+	cfg    *config.Config
 }
 
 // NewChatService creates a new ChatService.
-func NewChatService(store *store.DB) *ChatService {
-	return &ChatService{store: store}
+func NewChatService(store *store.DB, client *wa.Client, cfg *config.Config) *ChatService {
+	return &ChatService{store: store, client: client, cfg: cfg}
 }
 
 // ListChats lists chats with optional filtering, pagination and sorting.
 func (s *ChatService) ListChats(opts domain.ListChatsOptions) ([]domain.Chat, error) {
-	if opts.Limit > 200 {
-		return nil, fmt.Errorf("limit cannot exceed 200")
+	if opts.Limit > s.cfg.MCP.MaxPageSize {
+		return nil, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
 	}
 	if opts.Limit <= 0 {
-		opts.Limit = 20
+		opts.Limit = s.cfg.MCP.DefaultListLimit
 	}
 	if opts.Page < 0 {
 		opts.Page = 0
 	}
+	switch opts.Sort {
+	case "", domain.ChatSortRecent, domain.ChatSortName:
+	default:
+		return nil, fmt.Errorf("invalid sort %q: use %s or %s", opts.Sort, domain.ChatSortRecent, domain.ChatSortName)
+	}
 
 	return s.store.ListChats(opts)
 }
 
-// GetChat retrieves a single chat by JID.
+// GetChat retrieves a single chat by JID, returning ErrChatNotFound if it isn't stored.
 func (s *ChatService) GetChat(chatJID string, includeLast bool) (*domain.Chat, error) {
 	if chatJID == "" {
 		return nil, fmt.Errorf("chat_jid cannot be empty")
 	}
 
-	return s.store.GetChat(chatJID, includeLast)
+	chat, err := s.store.GetChat(chatJID, includeLast)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, chatJID)
+	}
+	return chat, err
+}
+
+// ListRecentChats returns the chats most recently interacted with, for suggesting
+// recipients; directOnly leaves out groups.
+func (s *ChatService) ListRecentChats(limit int, directOnly bool) ([]domain.Chat, error) {
+	if limit > s.cfg.MCP.MaxPageSize {
+		return nil, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
+	}
+	if limit <= 0 {
+		limit = s.cfg.MCP.DefaultListLimit
+	}
+
+	return s.store.ListRecentChats(directOnly, limit)
+}
+
+// SearchContacts finds individual contacts by name or phone number, returning up
+// to limit matches and the total number found.
+func (s *ChatService) SearchContacts(query string, limit int) ([]domain.Contact, int, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, fmt.Errorf("query cannot be empty")
+	}
+	if limit < 1 || limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, fmt.Errorf("limit must be between 1 and %d", s.cfg.MCP.MaxPageSize)
+	}
+	return s.store.SearchContacts(query, limit)
+}
+
+// SetArchived archives or unarchives a chat. The stored state only changes once
+// WhatsApp has accepted the change.
+func (s *ChatService) SetArchived(chatJID string, archived bool) error {
+	if chatJID == "" {
+		return fmt.Errorf("chat_jid cannot be empty")
+	}
+	return s.client.SetChatArchived(chatJID, archived)
+}
+
+// SetPinned pins or unpins a chat. The stored state only changes once WhatsApp
+// has accepted the change.
+func (s *ChatService) SetPinned(chatJID string, pinned bool) error {
+	if chatJID == "" {
+		return fmt.Errorf("chat_jid cannot be empty")
+	}
+	return s.client.SetChatPinned(chatJID, pinned)
+}
+
+// muteDurations are the mute lengths WhatsApp offers; 0 mutes indefinitely.
+var muteDurations = map[string]time.Duration{
+	"8h":     8 * time.Hour,
+	"1w":     7 * 24 * time.Hour,
+	"always": 0,
+}
+
+// Mute mutes a chat for one of WhatsApp's mute durations: "8h", "1w" or "always".
+func (s *ChatService) Mute(chatJID, duration string) error {
+	if chatJID == "" {
+		return fmt.Errorf("chat_jid cannot be empty")
+	}
+	d, ok := muteDurations[strings.ToLower(strings.TrimSpace(duration))]
+	if !ok {
+		return fmt.Errorf("invalid duration %q: use 8h, 1w or always", duration)
+	}
+	return s.client.SetChatMuted(chatJID, true, d)
+}
+
+// Unmute unmutes a chat.
+func (s *ChatService) Unmute(chatJID string) error {
+	if chatJID == "" {
+		return fmt.Errorf("chat_jid cannot be empty")
+	}
+	return s.client.SetChatMuted(chatJID, false, 0)
+}
+
+// SetTyping shows "typing…" (composing) or "recording audio…" (recording) in a
+// chat, or clears the indicator (paused). Nothing is stored.
+func (s *ChatService) SetTyping(chatJID, state string) error {
+	if chatJID == "" {
+		return fmt.Errorf("chat_jid cannot be empty")
+	}
+	switch state {
+	case wa.TypingComposing, wa.TypingRecording, wa.TypingPaused:
+	default:
+		return fmt.Errorf("invalid state %q: use composing, recording or paused", state)
+	}
+	return s.client.SetTyping(chatJID, state)
+}
+
+// SetPresence marks the account as "available" (online) or "unavailable" for all contacts.
+func (s *ChatService) SetPresence(state string) error {
+	switch state {
+	case "available":
+		return s.client.SetPresence(true)
+	case "unavailable":
+		return s.client.SetPresence(false)
+	}
+	return fmt.Errorf("invalid state %q: use available or unavailable", state)
+}
+
+// ListUnknownSenders returns direct chats with people not saved in the address
+// book, optionally limited to those who messaged within timeframe, for spam triage.
+func (s *ChatService) ListUnknownSenders(timeframe string, limit int) ([]domain.UnknownSenderChat, error) {
+	if limit > s.cfg.MCP.MaxPageSize {
+		return nil, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
+	}
+	if limit <= 0 {
+		limit = s.cfg.MCP.DefaultListLimit
+	}
+
+	var after, before string
+	if timeframe != "" {
+		var err error
+		if after, before, err = domain.ParseTimeframe(timeframe); err != nil {
+			return nil, fmt.Errorf("invalid timeframe: %w", err)
+		}
+	}
+
+	return s.store.ListUnknownSenderChats(after, before, limit)
+}
+
+// ListChatSenders returns everyone who has posted in a group according to the
+// stored history, including former members no longer in the live group info.
+func (s *ChatService) ListChatSenders(chatJID string) ([]domain.ChatSender, error) {
+	if !strings.HasSuffix(chatJID, "@g.us") {
+		return nil, fmt.Errorf("%s is not a group", chatJID)
+	}
+
+	return s.store.ListChatSenders(chatJID)
 }
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/wa"
+)
+
+// maxUserInfoRecipients caps how many recipients can be looked up in one call.
+const maxUserInfoRecipients = 200
+
+// businessCacheTTL is how long a cached business lookup is trusted.
+const businessCacheTTL = 7 * 24 * time.Hour
+
+// ContactService handles contact-related business logic.
+type ContactService struct {
+	store  *store.DB
+	client *wa.Client
+}
+
+// NewContactService creates a new ContactService.
+func NewContactService(store *store.DB, client *wa.Client) *ContactService {
+	return &ContactService{
+		store:  store,
+		client: client,
+	}
+}
+
+// GetUserInfo looks up status, picture ID and devices for many recipients at once.
+// Recipients that fail to resolve or fetch are reported individually rather than
+// failing the whole request.
+func (s *ContactService) GetUserInfo(recipients []string) ([]domain.UserInfo, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("recipients cannot be empty")
+	}
+	if len(recipients) > maxUserInfoRecipients {
+		return nil, fmt.Errorf("cannot look up more than %d recipients at once", maxUserInfoRecipients)
+	}
+
+	infos := make([]domain.UserInfo, len(recipients))
+	var jids []string
+	var idx []int
+
+	for i, r := range recipients {
+		infos[i].Recipient = r
+		jid, err := s.client.ResolveRecipient(r)
+		if err != nil {
+			infos[i].Error = ptrIfNotEmpty(err.Error())
+			continue
+		}
+		infos[i].JID = jid
+		jids = append(jids, jid)
+		idx = append(idx, i)
+	}
+
+	if len(jids) == 0 {
+		return infos, nil
+	}
+
+	results, err := s.client.GetUserInfo(jids)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, res := range results {
+		info := &infos[idx[n]]
+		if res.Err != nil {
+			info.Error = ptrIfNotEmpty(res.Err.Error())
+			continue
+		}
+		info.Status = ptrIfNotEmpty(res.Status)
+		info.PictureID = ptrIfNotEmpty(res.PictureID)
+		info.Devices = res.Devices
+		info.VerifiedName = ptrIfNotEmpty(res.VerifiedName)
+	}
+
+	return infos, nil
+}
+
+// IsBusiness reports whether a contact is a WhatsApp Business account, using the
+// verified-name certificate from a live lookup and the contact store's business name.
+// Results are cached per JID; when offline, a stale cache entry is preferred over
+// nothing, and an unknown result is returned if there is no cache at all.
+func (s *ContactService) IsBusiness(jid string) (*domain.BusinessInfo, error) {
+	if strings.HasSuffix(jid, "@g.us") {
+		return nil, fmt.Errorf("business status is only available for individual contacts")
+	}
+
+	cached, err := s.store.GetBusinessInfo(jid)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && time.Since(cached.CheckedAt) < businessCacheTTL {
+		return cached, nil
+	}
+
+	results, err := s.client.GetUserInfo([]string{jid})
+	if err != nil || len(results) == 0 || results[0].Err != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		info := &domain.BusinessInfo{JID: jid, Source: "unknown", BusinessName: ptrIfNotEmpty(s.client.ContactBusinessName(jid))}
+		if info.BusinessName != nil {
+			isBusiness := true
+			info.IsBusiness = &isBusiness
+		}
+		return info, nil
+	}
+
+	res := results[0]
+	info := domain.BusinessInfo{
+		JID:            jid,
+		BusinessName:   ptrIfNotEmpty(s.client.ContactBusinessName(jid)),
+		VerifiedName:   ptrIfNotEmpty(res.VerifiedName),
+		VerifiedIssuer: ptrIfNotEmpty(res.VerifiedIssuer),
+		Source:         "live",
+		CheckedAt:      time.Now().UTC(),
+	}
+	isBusiness := info.VerifiedName != nil || info.BusinessName != nil
+	info.IsBusiness = &isBusiness
+
+	phone, _, _ := strings.Cut(jid, "@")
+	if err := s.store.SaveBusinessInfo(phone, info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// Block blocks an individual contact on WhatsApp.
+func (s *ContactService) Block(jid string) error {
+	if err := requireContactJID(jid); err != nil {
+		return err
+	}
+	return s.client.SetBlocked(jid, true)
+}
+
+// Unblock unblocks an individual contact on WhatsApp.
+func (s *ContactService) Unblock(jid string) error {
+	if err := requireContactJID(jid); err != nil {
+		return err
+	}
+	return s.client.SetBlocked(jid, false)
+}
+
+// GetBlocklist returns the blocked contacts. The cached list is kept current by
+// blocklist events; refresh fetches it from WhatsApp first.
+func (s *ContactService) GetBlocklist(refresh bool) ([]domain.BlockedContact, error) {
+	if refresh {
+		if err := s.client.RefreshBlocklist(); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.ListBlocked()
+}
+
+// requireContactJID rejects group, broadcast and newsletter JIDs, which can't be blocked.
+func requireContactJID(jid string) error {
+	_, server, _ := strings.Cut(jid, "@")
+	if server != "s.whatsapp.net" && server != "lid" {
+		return fmt.Errorf("%s is not an individual contact; only contacts can be blocked, not groups or channels", jid)
+	}
+	return nil
+}
+
+// GetProfilePicture downloads a contact's or group's profile picture. A missing or
+// privacy-restricted picture is reported with HasPicture false and a message rather
+// than an error.
+func (s *ContactService) GetProfilePicture(jid string, preview bool) (*domain.ProfilePicture, error) {
+	pic, err := s.client.DownloadProfilePicture(jid, preview)
+	switch {
+	case errors.Is(err, wa.ErrNoProfilePicture):
+		return &domain.ProfilePicture{JID: jid, Preview: preview, Message: "no profile picture is set"}, nil
+	case errors.Is(err, wa.ErrProfilePictureHidden):
+		return &domain.ProfilePicture{JID: jid, Preview: preview, Message: "the profile picture is hidden by their privacy settings"}, nil
+	case err != nil:
+		return nil, err
+	}
+	return pic, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import "unicode"
+
+// isSingleGrapheme reports whether s is exactly one user-perceived character,
+// covering the emoji sequences WhatsApp reactions use: variation selectors,
+// skin tone modifiers, keycaps, tag sequences, ZWJ sequences and flag pairs.
+func isSingleGrapheme(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return false
+	}
+
+	// Flags are a pair of regional indicator symbols
+	if isRegionalIndicator(runes[0]) {
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	}
+
+	for i := 1; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isGraphemeExtender(r):
+			continue
+		case r == '\u200d':
+			// A zero-width joiner must join onto another base character
+			if i+1 >= len(runes) || runes[i+1] == '\u200d' || isGraphemeExtender(runes[i+1]) {
+				return false
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isGraphemeExtender reports whether r extends the preceding character rather than
+// starting a new one.
+func isGraphemeExtender(r rune) bool {
+	switch {
+	case r == '\ufe0e' || r == '\ufe0f': // variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // skin tone modifiers
+		return true
+	case r >= 0xe0020 && r <= 0xe007f: // tag characters (subdivision flags)
+		return true
+	}
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) // combining marks, incl. keycap
+}
+
+// isRegionalIndicator reports whether r is a regional indicator symbol (A-Z).
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
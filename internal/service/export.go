@@ -0,0 +1,382 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// maxExportMessages caps how many messages one conversation archive includes.
+const maxExportMessages = 10000
+
+// errExportFull stops reading messages once a conversation archive is full.
+var errExportFull = errors.New("export full")
+
+// ExportConversationArchive writes a chat's messages in a timeframe (all time if
+// empty) to a zip containing a text transcript, a JSON dump and, optionally, every
+// media attachment that can still be downloaded. The zip is saved in the chat's
+// media folder. Media already saved locally is copied as is; the rest is downloaded
+// with the same spacing as DownloadAllMedia, and failures are counted rather than
+// failing the export.
+func (s *MessageService) ExportConversationArchive(chatJID, timeframe string, includeMedia bool) (*domain.ConversationArchive, error) {
+	opts := domain.ListMessagesOptions{ChatJID: chatJID, Sort: domain.SortDesc}
+	if timeframe != "" {
+		after, before, err := domain.ParseTimeframe(timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeframe: %w", err)
+		}
+		opts.After, opts.Before = after, before
+	}
+
+	// Newest first, so the cap drops the oldest messages
+	var messages []domain.Message
+	truncated := false
+	err := s.store.EachMessage(opts, func(m domain.Message) error {
+		if len(messages) == maxExportMessages {
+			truncated = true
+			return errExportFull
+		}
+		messages = append(messages, m)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errExportFull) {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages found in %s for that timeframe", chatJID)
+	}
+	slices.Reverse(messages)
+
+	tmp, err := os.MkdirTemp("", "whatsapp-export-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	archive := &domain.ConversationArchive{ChatJID: chatJID, Messages: len(messages), Truncated: truncated}
+
+	if includeMedia {
+		if err := os.Mkdir(filepath.Join(tmp, "media"), 0755); err != nil {
+			return nil, err
+		}
+		if err := s.archiveMedia(archive, messages, opts.After, opts.Before, filepath.Join(tmp, "media")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(tmp, "transcript.txt"), []byte(formatTranscript(messages, s.cfg.Location)), 0644); err != nil {
+		return nil, err
+	}
+	dump, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "messages.json"), dump, 0644); err != nil {
+		return nil, err
+	}
+
+	outDir := filepath.Join(s.client.BaseDir, strings.ReplaceAll(chatJID, ":", "_"))
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	out := filepath.Join(outDir, fmt.Sprintf("conversation_%s.zip", time.Now().Format("20060102_150405")))
+	files, err := zipDir(tmp, out)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		return nil, err
+	}
+
+	archive.Path, _ = filepath.Abs(out)
+	archive.FileCount = files
+	archive.SizeBytes = info.Size()
+	return archive, nil
+}
+
+// archiveMedia copies the media attachments of messages, read with the after and
+// before bounds, into dir, downloading those not already in the chat's media folder.
+func (s *MessageService) archiveMedia(archive *domain.ConversationArchive, messages []domain.Message, after, before, dir string) error {
+	wanted := map[string]bool{}
+	for _, m := range messages {
+		if m.MediaType != nil && !m.IsDeleted {
+			wanted[m.ID] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	items, err := s.store.ListMedia(domain.ListMediaOptions{ChatJID: archive.ChatJID, After: after, Before: before, Sort: domain.SortAsc})
+	if err != nil {
+		return err
+	}
+
+	attempted := 0
+	for i := range items {
+		item := &items[i]
+		if !wanted[item.MessageID] {
+			continue
+		}
+		s.client.LocateMedia(item)
+		path, name := item.Path, item.Filename
+		if !item.Downloaded {
+			if attempted > 0 {
+				time.Sleep(bulkDownloadDelay)
+			}
+			attempted++
+			result, err := s.client.DownloadMedia(item.MessageID, archive.ChatJID)
+			if err != nil {
+				archive.MediaFailed++
+				continue
+			}
+			path, name = result.Path, result.Filename
+		}
+		if err := copyFile(path, filepath.Join(dir, item.MessageID+"_"+name)); err != nil {
+			return err
+		}
+		archive.MediaFiles++
+	}
+	return nil
+}
+
+// ExportChat writes a chat's messages in a timeframe (all time if empty) to a
+// transcript file in the export directory, oldest first, as JSON, CSV (timestamp,
+// sender, direction, content, media_type) or formatMessageLine text. Messages are
+// streamed from the store to the file, so large chats aren't held in memory.
+func (s *MessageService) ExportChat(chatJID, format, timeframe string) (*domain.ChatExport, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case domain.ExportJSON, domain.ExportCSV, domain.ExportTXT:
+	default:
+		return nil, fmt.Errorf("invalid format %q: use %s, %s or %s", format, domain.ExportJSON, domain.ExportCSV, domain.ExportTXT)
+	}
+
+	opts := domain.ListMessagesOptions{ChatJID: chatJID, Sort: domain.SortAsc}
+	if timeframe != "" {
+		after, before, err := domain.ParseTimeframe(timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeframe: %w", err)
+		}
+		opts.After, opts.Before = after, before
+	}
+
+	if err := os.MkdirAll(s.cfg.ExportDir, 0755); err != nil {
+		return nil, err
+	}
+	base := fmt.Sprintf("%s_%s", strings.NewReplacer(":", "_", "@", "_").Replace(chatJID), time.Now().Format("20060102_150405"))
+	f, err := createUnique(s.cfg.ExportDir, base, "."+format)
+	if err != nil {
+		return nil, err
+	}
+	out := f.Name()
+
+	count, err := s.writeExport(f, format, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && count == 0 {
+		err = fmt.Errorf("no messages found in %s for that timeframe", chatJID)
+	}
+	if err != nil {
+		os.Remove(out)
+		return nil, err
+	}
+
+	info, err := os.Stat(out)
+	if err != nil {
+		return nil, err
+	}
+	export := &domain.ChatExport{ChatJID: chatJID, Format: format, Messages: count, SizeBytes: info.Size()}
+	export.Path, _ = filepath.Abs(out)
+	return export, nil
+}
+
+// writeExport streams the messages matching opts to w in format and returns how
+// many were written.
+func (s *MessageService) writeExport(w io.Writer, format string, opts domain.ListMessagesOptions) (int, error) {
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+
+	switch format {
+	case domain.ExportJSON:
+		bw.WriteString("[")
+	case domain.ExportCSV:
+		cw.Write([]string{"timestamp", "sender", "direction", "content", "media_type"})
+	}
+
+	count := 0
+	err := s.store.EachMessage(opts, func(m domain.Message) error {
+		switch format {
+		case domain.ExportJSON:
+			line, err := json.Marshal(m)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				bw.WriteString(",")
+			}
+			bw.WriteString("\n  ")
+			bw.Write(line)
+		case domain.ExportCSV:
+			direction := domain.DirectionIncoming
+			if m.IsFromMe {
+				direction = domain.DirectionOutgoing
+			}
+			var content, mediaType string
+			if m.Content != nil {
+				content = *m.Content
+			}
+			if m.MediaType != nil {
+				mediaType = *m.MediaType
+			}
+			cw.Write([]string{m.Timestamp.In(s.cfg.Location).Format(time.RFC3339), m.Sender, direction, content, mediaType})
+		case domain.ExportTXT:
+			bw.WriteString(formatMessageLine(m, s.cfg.Location))
+			bw.WriteByte('\n')
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if format == domain.ExportJSON {
+		bw.WriteString("\n]\n")
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+// createUnique creates dir/base+ext, adding _2, _3, ... before ext if that name
+// is taken, so exports started in the same second don't overwrite each other.
+func createUnique(dir, base, ext string) (*os.File, error) {
+	name := base + ext
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+		name = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// formatTranscript renders messages as one formatMessageLine line each, in loc.
+func formatTranscript(messages []domain.Message, loc *time.Location) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(formatMessageLine(m, loc))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// formatMessageLine renders a message as "[time] sender: text" in loc, with
+// placeholders for media and deleted messages.
+func formatMessageLine(m domain.Message, loc *time.Location) string {
+	sender := m.Sender
+	if m.IsFromMe {
+		sender = "Me"
+	}
+	var text string
+	switch {
+	case m.IsDeleted:
+		text = "<message deleted>"
+	case m.MediaType != nil && *m.MediaType != "":
+		text = "<" + *m.MediaType
+		if m.Filename != nil && *m.Filename != "" {
+			text += ": " + *m.Filename
+		}
+		text += ">"
+		if m.Content != nil && *m.Content != "" {
+			text += " " + *m.Content
+		}
+	case m.Content != nil:
+		text = *m.Content
+	}
+	if m.IsEdited {
+		text += " (edited)"
+	}
+	return fmt.Sprintf("[%s] %s: %s", m.Timestamp.In(loc).Format("2006-01-02 15:04"), sender, text)
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// zipDir writes every file under dir to a new zip at out, using paths relative to
+// dir, and returns how many files were added.
+func zipDir(dir, out string) (int, error) {
+	f, err := os.Create(out)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	files := 0
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if _, err := io.Copy(w, in); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		os.Remove(out)
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		os.Remove(out)
+		return 0, err
+	}
+	return files, nil
+}
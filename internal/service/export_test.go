@@ -0,0 +1,149 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/config"
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/wa"
+)
+
+func newTestStore(t *testing.T) *store.DB {
+	t.Helper()
+	db, err := store.Open(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestWriteExportSameSecondMessages(t *testing.T) {
+	db := newTestStore(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat(chat, "Test", at); err != nil {
+		t.Fatal(err)
+	}
+	// Far more than one store batch, ten messages to each second
+	const total = 1234
+	for i := 0; i < total; i++ {
+		if _, err := db.Messages.Exec(`INSERT INTO messages (id, chat_jid, sender, content, timestamp, is_from_me, media_type)
+			VALUES (?, ?, '447700900001', 'hi', ?, 0, '')`, fmt.Sprintf("m%04d", i), chat, store.FormatTime(at.Add(time.Duration(i/10)*time.Second))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &MessageService{store: db, cfg: &config.Config{Location: time.UTC}}
+	var buf bytes.Buffer
+	count, err := s.writeExport(&buf, domain.ExportJSON, domain.ListMessagesOptions{ChatJID: chat, Sort: domain.SortAsc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != total {
+		t.Errorf("count = %d, want %d", count, total)
+	}
+
+	var exported []domain.Message
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+	seen := map[string]bool{}
+	for i, m := range exported {
+		if seen[m.ID] {
+			t.Fatalf("message %s exported twice", m.ID)
+		}
+		seen[m.ID] = true
+		if i > 0 && m.Timestamp.Before(exported[i-1].Timestamp) {
+			t.Fatalf("message %s out of order", m.ID)
+		}
+	}
+	if len(seen) != total {
+		t.Errorf("exported %d distinct messages, want %d", len(seen), total)
+	}
+}
+
+func TestExportConversationArchive(t *testing.T) {
+	db := newTestStore(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat(chat, "Test", at); err != nil {
+		t.Fatal(err)
+	}
+	// Same-second text, one image already saved locally and one that can't be downloaded
+	for i, media := range []string{"", "", "image", "", "image"} {
+		if _, err := db.Messages.Exec(`INSERT INTO messages (id, chat_jid, sender, content, timestamp, is_from_me, media_type, mime_type, file_length)
+			VALUES (?, ?, '447700900001', 'hi', ?, 0, ?, 'image/jpeg', 4)`, fmt.Sprintf("m%d", i), chat, store.FormatTime(at), media); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := &wa.Client{Store: db, BaseDir: t.TempDir()}
+	saved := domain.MediaItem{MessageID: "m2", ChatJID: chat, MediaType: "image", MimeType: "image/jpeg"}
+	client.LocateMedia(&saved)
+	if err := os.MkdirAll(filepath.Join(client.BaseDir, chat), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(client.BaseDir, chat, saved.Filename), []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &MessageService{store: db, client: client, cfg: &config.Config{Location: time.UTC}}
+	archive, err := s.ExportConversationArchive(chat, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.Messages != 5 || archive.Truncated {
+		t.Errorf("messages = %d (truncated %v), want 5", archive.Messages, archive.Truncated)
+	}
+	if archive.MediaFiles != 1 || archive.MediaFailed != 1 {
+		t.Errorf("media files = %d, failed = %d, want 1 and 1", archive.MediaFiles, archive.MediaFailed)
+	}
+
+	zr, err := zip.OpenReader(archive.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files["media/m2_"+saved.Filename] == nil {
+		t.Errorf("archive is missing the saved image; has %v", files)
+	}
+	f := files["messages.json"]
+	if f == nil {
+		t.Fatal("archive has no messages.json")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []domain.Message
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, m := range exported {
+		ids = append(ids, m.ID)
+	}
+	if got := strings.Join(ids, ","); got != "m0,m1,m2,m3,m4" {
+		t.Errorf("exported %s, want m0,m1,m2,m3,m4", got)
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eddmann/whatsapp-mcp/internal/config"
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/wa"
+)
+
+// GroupService handles group-related business logic.
+type GroupService struct {
+	store  *store.DB
+	client *wa.Client
+	cfg    *config.Config
+}
+
+// NewGroupService creates a new GroupService.
+func NewGroupService(store *store.DB, client *wa.Client, cfg *config.Config) *GroupService {
+	return &GroupService{
+		store:  store,
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// ListGroups returns a page of the user's group chats, most recently active first,
+// with cached metadata (refreshed once older than GROUP_CACHE_TTL), plus the total
+// number of group chats. If metadata can't be fetched (e.g. the group was left),
+// stale cached metadata is used, or failing that just the JID and chat name.
+func (s *GroupService) ListGroups(limit, page int) ([]domain.Group, int, error) {
+	if limit < 1 || limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, fmt.Errorf("limit must be between 1 and %d", s.cfg.MCP.MaxPageSize)
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	total, err := s.store.CountGroupChats()
+	if err != nil {
+		return nil, 0, err
+	}
+	chats, err := s.store.ListGroupChats(limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groups := make([]domain.Group, 0, len(chats))
+	for _, chat := range chats {
+		if g, err := s.client.GetGroupMetadata(chat.JID, s.cfg.GroupCacheTTL); err == nil {
+			groups = append(groups, *g)
+			continue
+		}
+		if cached, _ := s.store.GetGroup(chat.JID); cached != nil {
+			groups = append(groups, *cached)
+			continue
+		}
+		groups = append(groups, chat)
+	}
+
+	return groups, total, nil
+}
+
+// ListAdminGroups returns the stored groups where the logged-in user is an admin.
+// Group metadata is served from the local cache and refreshed when stale; groups
+// whose metadata cannot be fetched (e.g. left or deleted) are skipped.
+func (s *GroupService) ListAdminGroups() ([]domain.Group, error) {
+	jids, err := s.store.ListGroupChatJIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []domain.Group{}
+	for _, jid := range jids {
+		g, err := s.client.GetGroupMetadata(jid, s.cfg.GroupCacheTTL)
+		if err != nil {
+			continue
+		}
+		if g.IsAdmin {
+			groups = append(groups, *g)
+		}
+	}
+
+	return groups, nil
+}
+
+// GetGroupDetails returns a group's subject, description, owner, settings,
+// invite-link state and participants. Only group chats are supported.
+func (s *GroupService) GetGroupDetails(groupJID string) (*domain.GroupDetails, error) {
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return nil, fmt.Errorf("%s is not a group chat; get_group_info only supports groups", groupJID)
+	}
+	return s.client.GetGroupDetails(groupJID)
+}
+
+// GetInviteLink returns a group's invite URL, optionally revoking the current one
+// and generating a new one (admins only).
+func (s *GroupService) GetInviteLink(groupJID string, reset bool) (string, error) {
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return "", fmt.Errorf("%s is not a group chat", groupJID)
+	}
+	return s.client.GetGroupInviteLink(groupJID, reset)
+}
+
+// JoinViaLink joins a group from an invite URL (https://chat.whatsapp.com/CODE)
+// or a bare invite code.
+func (s *GroupService) JoinViaLink(link string) (*domain.GroupJoinResult, error) {
+	code := inviteCode(link)
+	if code == "" {
+		return nil, fmt.Errorf("invalid invite link %q: expected https://chat.whatsapp.com/<code> or the code itself", link)
+	}
+	return s.client.JoinGroupWithLink(code)
+}
+
+// inviteCode extracts the invite code from a group invite URL or bare code.
+func inviteCode(link string) string {
+	link = strings.TrimSpace(link)
+	link, _, _ = strings.Cut(link, "?")
+	link, _, _ = strings.Cut(link, "#")
+	link = strings.TrimRight(link, "/")
+	if i := strings.LastIndex(link, "/"); i >= 0 {
+		if !strings.Contains(link[:i], "chat.whatsapp.com") {
+			return ""
+		}
+		link = link[i+1:]
+	}
+	if strings.ContainsAny(link, " :.") {
+		return ""
+	}
+	return link
+}
+
+// WhatsApp's limits on group subject and description length, in characters.
+const (
+	maxGroupSubjectLength     = 100
+	maxGroupDescriptionLength = 2048
+)
+
+// SetSubject renames a group.
+func (s *GroupService) SetSubject(groupJID, subject string) error {
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return fmt.Errorf("%s is not a group chat", groupJID)
+	}
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return fmt.Errorf("subject cannot be empty")
+	}
+	if n := utf8.RuneCountInString(subject); n > maxGroupSubjectLength {
+		return fmt.Errorf("subject is %d characters; WhatsApp allows at most %d", n, maxGroupSubjectLength)
+	}
+	return s.client.SetGroupSubject(groupJID, subject)
+}
+
+// SetDescription replaces a group's description; an empty description clears it.
+func (s *GroupService) SetDescription(groupJID, description string) error {
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return fmt.Errorf("%s is not a group chat", groupJID)
+	}
+	description = strings.TrimSpace(description)
+	if n := utf8.RuneCountInString(description); n > maxGroupDescriptionLength {
+		return fmt.Errorf("description is %d characters; WhatsApp allows at most %d", n, maxGroupDescriptionLength)
+	}
+	return s.client.SetGroupDescription(groupJID, description)
+}
+
+// maxParticipantChanges caps how many participants one call may change.
+const maxParticipantChanges = 50
+
+// UpdateParticipants adds, removes, promotes or demotes group participants given
+// as phone numbers (international format) or user JIDs, returning the outcome for
+// each in input order.
+func (s *GroupService) UpdateParticipants(groupJID string, participants []string, action wa.ParticipantAction) ([]domain.ParticipantChangeResult, error) {
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return nil, fmt.Errorf("%s is not a group chat", groupJID)
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("participants cannot be empty")
+	}
+	if len(participants) > maxParticipantChanges {
+		return nil, fmt.Errorf("at most %d participants can be changed at once, got %d", maxParticipantChanges, len(participants))
+	}
+
+	users := make([]string, len(participants))
+	for i, p := range participants {
+		jid, err := participantJID(p)
+		if err != nil {
+			return nil, err
+		}
+		users[i] = jid
+	}
+
+	results, err := s.client.UpdateGroupParticipants(groupJID, users, action)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Participant = participants[i]
+	}
+	return results, nil
+}
+
+// participantJID converts a phone number or user JID into a user JID string.
+func participantJID(participant string) (string, error) {
+	if user, server, ok := strings.Cut(strings.TrimSpace(participant), "@"); ok {
+		if server != "s.whatsapp.net" || user == "" {
+			return "", fmt.Errorf("invalid participant %q: expected a phone number or a JID ending in @s.whatsapp.net", participant)
+		}
+		user, _, _ = strings.Cut(user, ":")
+		return user + "@s.whatsapp.net", nil
+	}
+	phone, err := normalizeE164(participant)
+	if err != nil {
+		return "", err
+	}
+	return phone + "@s.whatsapp.net", nil
+}
+
+// GetGroupPicture downloads a group's profile photo to local storage.
+// Groups without a photo are reported with HasPicture false rather than an error.
+func (s *GroupService) GetGroupPicture(groupJID string) (*domain.GroupPicture, error) {
+	pictureID, path, err := s.client.DownloadGroupPicture(groupJID)
+	if errors.Is(err, wa.ErrNoGroupPicture) {
+		return &domain.GroupPicture{JID: groupJID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GroupPicture{
+		JID:        groupJID,
+		HasPicture: true,
+		PictureID:  pictureID,
+		Path:       path,
+	}, nil
+}
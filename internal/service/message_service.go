@@ -1,65 +1,375 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/eddmann/whatsapp-mcp/internal/config"
 	"github.com/eddmann/whatsapp-mcp/internal/domain"
 	"github.com/eddmann/whatsapp-mcp/internal/store"
 	"github.com/eddmann/whatsapp-mcp/internal/wa"
 )
 
+// ErrQuietHours is returned when a send is attempted during configured quiet hours without force.
+var ErrQuietHours = errors.New("QUIET_HOURS")
+
+// ErrMediaTypeNotAllowed is returned when a file's media type is not in SEND_ALLOWED_MEDIA_TYPES.
+var ErrMediaTypeNotAllowed = errors.New("MEDIA_TYPE_NOT_ALLOWED")
+
 // MessageService handles message-related business logic.
 type MessageService struct {
 	store  *store.DB
 	client *wa.Client
+	cfg    *config.Config
 }
 
 // NewMessageService creates a new MessageService.
-func NewMessageService(store *store.DB, client *wa.Client) *MessageService {
+func NewMessageService(store *store.DB, client *wa.Client, cfg *config.Config) *MessageService {
 	return &MessageService{
 		store:  store,
 		client: client,
+		cfg:    cfg,
+	}
+}
+
+// listMediaTypes are the stored media types messages can be filtered by.
+var listMediaTypes = []string{"image", "video", "audio", "document", "sticker"}
+
+// ListMedia lists a chat's media messages, noting which are already downloaded,
+// along with the total number matching the filters.
+func (s *MessageService) ListMedia(opts domain.ListMediaOptions) ([]domain.MediaItem, int, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = s.cfg.MCP.DefaultListLimit
+	}
+	if opts.Limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
+	}
+	if opts.Page < 0 {
+		opts.Page = 0
+	}
+	if opts.MediaType != "" && !slices.Contains(listMediaTypes, opts.MediaType) {
+		return nil, 0, fmt.Errorf("invalid media_type %q: use one of %s", opts.MediaType, strings.Join(listMediaTypes, ", "))
 	}
+	switch opts.Sort {
+	case "", domain.SortDesc, domain.SortAsc:
+	default:
+		return nil, 0, fmt.Errorf("invalid sort %q: use %s or %s", opts.Sort, domain.SortAsc, domain.SortDesc)
+	}
+
+	items, err := s.store.ListMedia(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.store.CountMedia(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		s.client.LocateMedia(&items[i])
+	}
+	return items, total, nil
 }
 
-// ListMessages lists messages with filters and pagination.
-func (s *MessageService) ListMessages(opts domain.ListMessagesOptions) ([]domain.Message, error) {
+// ListMessages lists messages with filters and pagination, along with the total
+// number of messages matching the filters.
+func (s *MessageService) ListMessages(opts domain.ListMessagesOptions) ([]domain.Message, int, error) {
 	if opts.Limit <= 0 {
-		opts.Limit = 20
+		opts.Limit = s.cfg.MCP.DefaultListLimit
 	}
-	if opts.Limit > 200 {
-		return nil, fmt.Errorf("limit cannot exceed 200")
+	if opts.Limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
 	}
 	if opts.Page < 0 {
 		opts.Page = 0
 	}
+	if opts.MediaType != "" && !slices.Contains(listMediaTypes, opts.MediaType) {
+		return nil, 0, fmt.Errorf("invalid media_type %q: use one of %s", opts.MediaType, strings.Join(listMediaTypes, ", "))
+	}
+	switch opts.Sort {
+	case "", domain.SortDesc, domain.SortAsc:
+	default:
+		return nil, 0, fmt.Errorf("invalid sort %q: use %s or %s", opts.Sort, domain.SortAsc, domain.SortDesc)
+	}
+	switch opts.Direction {
+	case "", domain.DirectionAll, domain.DirectionIncoming, domain.DirectionOutgoing:
+	default:
+		return nil, 0, fmt.Errorf("invalid direction %q: use %s, %s or %s", opts.Direction, domain.DirectionAll, domain.DirectionIncoming, domain.DirectionOutgoing)
+	}
 
 	if opts.Timeframe != "" {
 		if opts.After != "" || opts.Before != "" {
-			return nil, fmt.Errorf("cannot specify both timeframe and after/before parameters")
+			return nil, 0, fmt.Errorf("cannot specify both timeframe and after/before parameters")
 		}
 		after, before, err := domain.ParseTimeframe(opts.Timeframe)
 		if err != nil {
-			return nil, fmt.Errorf("invalid timeframe: %w", err)
+			return nil, 0, fmt.Errorf("invalid timeframe: %w", err)
 		}
 		opts.After = after
 		opts.Before = before
 	}
 
-	return s.store.ListMessages(opts)
+	messages, err := s.store.ListMessages(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.store.CountMessages(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
 }
 
-// SearchMessages performs full-text search on message content.
-func (s *MessageService) SearchMessages(opts domain.SearchMessagesOptions) ([]domain.Message, error) {
+// SearchMessages performs full-text search on message content. The result is
+// capped at MaxContextRows rows, counting context; the bool reports whether
+// matches or context were dropped to fit.
+func (s *MessageService) SearchMessages(opts domain.SearchMessagesOptions) ([]domain.Message, int, bool, error) {
 	if opts.Query == "" {
-		return nil, fmt.Errorf("query cannot be empty")
+		return nil, 0, false, fmt.Errorf("query cannot be empty")
 	}
 
 	if opts.Limit <= 0 {
-		opts.Limit = 20
+		opts.Limit = s.cfg.MCP.DefaultSearchLimit
+	}
+	if opts.Limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, false, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
+	}
+	if opts.Page < 0 {
+		opts.Page = 0
+	}
+	switch opts.Sort {
+	case "":
+		opts.Sort = domain.SearchSortRecent
+	case domain.SearchSortRecent, domain.SearchSortRelevance:
+	default:
+		return nil, 0, false, fmt.Errorf("invalid sort %q: use %s or %s", opts.Sort, domain.SearchSortRecent, domain.SearchSortRelevance)
+	}
+
+	if opts.Timeframe != "" {
+		if opts.After != "" || opts.Before != "" {
+			return nil, 0, false, fmt.Errorf("cannot specify both timeframe and after/before parameters")
+		}
+		after, before, err := domain.ParseTimeframe(opts.Timeframe)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("invalid timeframe: %w", err)
+		}
+		opts.After = after
+		opts.Before = before
+	}
+
+	opts.MaxRows = s.cfg.MCP.MaxContextRows
+
+	messages, truncated, err := s.store.SearchMessages(opts)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	total, err := s.store.CountSearchMessages(opts)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return messages, total, truncated, nil
+}
+
+// MyMentionsFeed returns a page of messages across all chats that @mention the
+// user or reply to their messages, newest first, with the total number of matches.
+func (s *MessageService) MyMentionsFeed(timeframe string, limit, page int) ([]domain.MentionFeedItem, int, error) {
+	if limit < 1 || limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, fmt.Errorf("limit must be between 1 and %d", s.cfg.MCP.MaxPageSize)
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	var after, before string
+	if timeframe != "" {
+		var err error
+		if after, before, err = domain.ParseTimeframe(timeframe); err != nil {
+			return nil, 0, fmt.Errorf("invalid timeframe: %w", err)
+		}
+	}
+	return s.store.ListMentionsFeed(after, before, limit, page)
+}
+
+// GetLastInteraction returns the most recent message sent or received in a chat,
+// with how long ago it was.
+func (s *MessageService) GetLastInteraction(chatJID string) (*domain.LastInteraction, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	msg, err := s.store.GetLatestMessage(chatJID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.LastInteraction{ChatJID: chatJID, Message: msg}
+	if msg != nil {
+		result.Ago = relativeTime(msg.Timestamp, time.Now())
+	}
+	return result, nil
+}
+
+// relativeTime describes how long before now t was, in its largest whole unit
+// (e.g. "just now", "5 minutes ago", "3 days ago").
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	if d < time.Minute {
+		return "just now"
+	}
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, u := range units {
+		if n := int(d / u.size); n >= 1 {
+			if n == 1 {
+				return "1 " + u.name + " ago"
+			}
+			return fmt.Sprintf("%d %ss ago", n, u.name)
+		}
+	}
+	return "just now"
+}
+
+// GetParticipantLastMessage returns a participant's most recent message in a group,
+// or nil if they have not posted there.
+func (s *MessageService) GetParticipantLastMessage(groupJID, participantJID string) (*domain.Message, error) {
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return nil, fmt.Errorf("%s is not a group", groupJID)
+	}
+	sender, _, _ := strings.Cut(participantJID, "@")
+	if sender == "" {
+		return nil, fmt.Errorf("participant cannot be empty")
+	}
+	return s.store.GetLastMessageFromSender(groupJID, sender)
+}
+
+// MarkChatAsRead sends read receipts for a chat's unread incoming messages, optionally
+// only up to upToMessageID, clearing the unread badge on the user's devices.
+func (s *MessageService) MarkChatAsRead(chatJID, upToMessageID string) (*domain.MarkReadResult, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+
+	marked, err := s.client.MarkRead(chatJID, upToMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.MarkReadResult{ChatJID: chatJID, MarkedCount: marked}, nil
+}
+
+// GetUnreadInChat returns incoming messages newer than the chat's last-read marker,
+// oldest first. Without a marker (e.g. a chat never seen read), nothing is returned
+// and HasReadMarker is false.
+func (s *MessageService) GetUnreadInChat(chatJID string, limit int) (*domain.UnreadMessages, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if limit <= 0 {
+		limit = s.cfg.MCP.DefaultListLimit
+	}
+	if limit > s.cfg.MCP.MaxPageSize {
+		return nil, fmt.Errorf("limit cannot exceed %d", s.cfg.MCP.MaxPageSize)
+	}
+
+	result := &domain.UnreadMessages{ChatJID: chatJID, Messages: []domain.Message{}}
+
+	readTime, err := s.store.GetChatReadTime(chatJID)
+	if err != nil {
+		return nil, err
+	}
+	if readTime == nil {
+		return result, nil
+	}
+	result.HasReadMarker = true
+	result.LastReadTime = readTime
+
+	messages, total, err := s.store.ListUnreadMessages(chatJID, *readTime, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Messages = messages
+	result.Count = total
+	result.HasMore = total > len(messages)
+
+	return result, nil
+}
+
+// GetActivityHeatmap returns a 7x24 matrix of message counts by weekday and hour
+// in the configured timezone, optionally limited to one chat and a time range.
+func (s *MessageService) GetActivityHeatmap(chatJID, timeframe, after, before string) (*domain.ActivityHeatmap, error) {
+	if timeframe != "" {
+		if after != "" || before != "" {
+			return nil, fmt.Errorf("cannot specify both timeframe and after/before parameters")
+		}
+		var err error
+		if after, before, err = domain.ParseTimeframe(timeframe); err != nil {
+			return nil, fmt.Errorf("invalid timeframe: %w", err)
+		}
+	}
+
+	buckets, err := s.store.ActivityBuckets(chatJID, after, before)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &domain.ActivityHeatmap{
+		ChatJID:  chatJID,
+		Timezone: s.cfg.Location.String(),
+		Weekdays: make([]string, 7),
+		Hours:    make([]int, 24),
+		Matrix:   make([][]int, 7),
+	}
+	for i := range heatmap.Matrix {
+		heatmap.Matrix[i] = make([]int, 24)
+		heatmap.Weekdays[i] = time.Weekday((i + 1) % 7).String()
+	}
+	for h := range heatmap.Hours {
+		heatmap.Hours[h] = h
 	}
-	if opts.Limit > 200 {
-		return nil, fmt.Errorf("limit cannot exceed 200")
+
+	for start, count := range buckets {
+		local := start.In(s.cfg.Location)
+		row := (int(local.Weekday()) + 6) % 7 // Monday first
+		heatmap.Matrix[row][local.Hour()] += count
+		heatmap.Total += count
+	}
+
+	peak := 0
+	for row, hours := range heatmap.Matrix {
+		for hour, count := range hours {
+			if count > peak {
+				peak = count
+				heatmap.PeakWeekday = heatmap.Weekdays[row]
+				heatmap.PeakHour = &hour
+			}
+		}
+	}
+
+	return heatmap, nil
+}
+
+// maxTimelineMessages caps how many messages are scanned when building a timeline.
+const maxTimelineMessages = 2000
+
+// GetChatTimeline returns a chat's messages grouped by day in the configured timezone,
+// paginated by day (newest day first, messages within a day oldest first).
+func (s *MessageService) GetChatTimeline(opts domain.ChatTimelineOptions) (*domain.ChatTimeline, error) {
+	if opts.ChatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if opts.DaysPerPage <= 0 {
+		opts.DaysPerPage = 7
 	}
 	if opts.Page < 0 {
 		opts.Page = 0
@@ -77,19 +387,79 @@ func (s *MessageService) SearchMessages(opts domain.SearchMessagesOptions) ([]do
 		opts.Before = before
 	}
 
-	return s.store.SearchMessages(opts)
+	timeline := &domain.ChatTimeline{ChatJID: opts.ChatJID, Page: opts.Page, Days: []domain.TimelineDay{}}
+
+	pageSize := s.cfg.MCP.MaxPageSize
+	var messages []domain.Message
+	for page := 0; len(messages) < maxTimelineMessages; page++ {
+		batch, err := s.store.ListMessages(domain.ListMessagesOptions{
+			ChatJID: opts.ChatJID,
+			After:   opts.After,
+			Before:  opts.Before,
+			Limit:   pageSize,
+			Page:    page,
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, batch...)
+		if len(batch) < pageSize {
+			break
+		}
+	}
+	if len(messages) >= maxTimelineMessages {
+		messages = messages[:maxTimelineMessages]
+		timeline.Truncated = true
+	}
+
+	// Messages arrive newest first; group into days in that order.
+	var days []domain.TimelineDay
+	for _, msg := range messages {
+		local := msg.Timestamp.In(s.cfg.Location)
+		date := local.Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, domain.TimelineDay{Date: date, Weekday: local.Weekday().String()})
+		}
+		day := &days[len(days)-1]
+		day.Messages = append(day.Messages, msg)
+		day.Count++
+	}
+
+	timeline.TotalDays = len(days)
+	start := opts.Page * opts.DaysPerPage
+	if start >= len(days) {
+		return timeline, nil
+	}
+	end := min(start+opts.DaysPerPage, len(days))
+	timeline.HasMore = end < len(days)
+
+	for _, day := range days[start:end] {
+		for i, j := 0, len(day.Messages)-1; i < j; i, j = i+1, j-1 {
+			day.Messages[i], day.Messages[j] = day.Messages[j], day.Messages[i]
+		}
+		timeline.Days = append(timeline.Days, day)
+	}
+
+	return timeline, nil
 }
 
 // SendText sends a text message to a recipient.
-func (s *MessageService) SendText(recipient, message, replyToMessageID string) (*domain.SendResult, error) {
+func (s *MessageService) SendText(recipient, message string, opts domain.SendMessageOptions) (*domain.SendResult, error) {
 	if recipient == "" {
 		return nil, fmt.Errorf("recipient cannot be empty")
 	}
 	if message == "" {
 		return nil, fmt.Errorf("message cannot be empty")
 	}
+	if err := s.checkQuietHours(time.Now(), opts.Force); err != nil {
+		return nil, err
+	}
+	sendOpts, err := s.buildSendOptions(recipient, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := s.client.SendText(recipient, message, replyToMessageID)
+	result, err := s.client.SendText(recipient, message, sendOpts)
 	if err != nil {
 		return &domain.SendResult{Success: false, Message: err.Error()}, nil
 	}
@@ -103,16 +473,183 @@ func (s *MessageService) SendText(recipient, message, replyToMessageID string) (
 	}, nil
 }
 
+// EditMessage replaces the text of a message the user sent.
+func (s *MessageService) EditMessage(chatJID, messageID, newText string) (*domain.SendResult, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if messageID == "" {
+		return nil, fmt.Errorf("message_id cannot be empty")
+	}
+	if strings.TrimSpace(newText) == "" {
+		return nil, fmt.Errorf("new_text cannot be empty")
+	}
+
+	result, err := s.client.EditMessage(chatJID, messageID, newText)
+	if err != nil {
+		return &domain.SendResult{Success: false, Message: err.Error()}, nil
+	}
+
+	return &domain.SendResult{
+		Success:   result.Success,
+		Message:   result.Message,
+		MessageID: ptrIfNotEmpty(result.MessageID),
+		ChatJID:   ptrIfNotEmpty(result.ChatJID),
+		Timestamp: ptrIfNotEmpty(result.Timestamp),
+	}, nil
+}
+
+// ReplyToLatest sends text as a threaded reply quoting the most recent message in a chat.
+func (s *MessageService) ReplyToLatest(chatJID, text string, force bool) (*domain.SendResult, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	latest, err := s.store.ListMessages(domain.ListMessagesOptions{ChatJID: chatJID, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 {
+		return nil, fmt.Errorf("no messages found in chat %s to reply to", chatJID)
+	}
+
+	result, err := s.SendText(chatJID, text, domain.SendMessageOptions{
+		ReplyToMessageID: latest[0].ID,
+		Force:            force,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Success {
+		result.QuotedMessageID = &latest[0].ID
+	}
+
+	return result, nil
+}
+
+// defaultAckEmoji is the reaction used by Acknowledge when none is given.
+const defaultAckEmoji = "👍"
+
+// SendReaction reacts to a message with an emoji; an empty emoji removes the reaction.
+func (s *MessageService) SendReaction(chatJID, messageID, emoji string, force bool) (*domain.SendResult, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if messageID == "" {
+		return nil, fmt.Errorf("message_id cannot be empty")
+	}
+	if emoji != "" && !isSingleGrapheme(emoji) {
+		return nil, fmt.Errorf("emoji must be a single emoji, got %q", emoji)
+	}
+	if err := s.checkQuietHours(time.Now(), force); err != nil {
+		return nil, err
+	}
+
+	result, err := s.client.SendReaction(chatJID, messageID, emoji)
+	if err != nil {
+		return &domain.SendResult{Success: false, Message: err.Error()}, nil
+	}
+
+	return &domain.SendResult{
+		Success:         result.Success,
+		Message:         result.Message,
+		MessageID:       ptrIfNotEmpty(result.MessageID),
+		ChatJID:         ptrIfNotEmpty(result.ChatJID),
+		Timestamp:       ptrIfNotEmpty(result.Timestamp),
+		QuotedMessageID: &messageID,
+	}, nil
+}
+
+// Acknowledge reacts to the latest incoming message in a chat, defaulting to 👍.
+// The reacted-to message ID is returned in the result's QuotedMessageID.
+func (s *MessageService) Acknowledge(chatJID, emoji string, force bool) (*domain.SendResult, error) {
+	if emoji == "" {
+		emoji = defaultAckEmoji
+	}
+
+	latest, err := s.store.GetLatestIncomingMessage(chatJID)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no incoming messages in chat %s to acknowledge", chatJID)
+	}
+
+	return s.SendReaction(chatJID, latest.ID, emoji, force)
+}
+
 // SendMedia sends a media file to a recipient with optional caption.
-func (s *MessageService) SendMedia(recipient, mediaPath, caption, replyToMessageID string) (*domain.SendResult, error) {
+func (s *MessageService) SendMedia(recipient, mediaPath, caption string, opts domain.SendMessageOptions) (*domain.SendResult, error) {
 	if recipient == "" {
 		return nil, fmt.Errorf("recipient cannot be empty")
 	}
 	if mediaPath == "" {
 		return nil, fmt.Errorf("media_path cannot be empty")
 	}
+	if err := s.checkMediaAllowed(mediaPath); err != nil {
+		return nil, err
+	}
+	if err := s.checkQuietHours(time.Now(), opts.Force); err != nil {
+		return nil, err
+	}
+	if err := validateDocumentFilename(opts.Filename, mediaPath); err != nil {
+		return nil, err
+	}
+	sendOpts, err := s.buildSendOptions(recipient, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.client.SendMedia(recipient, mediaPath, caption, sendOpts)
+	if err != nil {
+		return &domain.SendResult{Success: false, Message: err.Error()}, nil
+	}
+
+	return &domain.SendResult{
+		Success:   result.Success,
+		Message:   result.Message,
+		MessageID: ptrIfNotEmpty(result.MessageID),
+		ChatJID:   ptrIfNotEmpty(result.ChatJID),
+		Timestamp: ptrIfNotEmpty(result.Timestamp),
+	}, nil
+}
+
+// SendContact shares a contact card for name with one or more phone numbers.
+// Phones may include '+', spaces, dashes, dots or parentheses; they are reduced
+// to digits and must form an international (E.164) number.
+func (s *MessageService) SendContact(recipient, name string, phones []string, force bool) (*domain.SendResult, error) {
+	if recipient == "" {
+		return nil, fmt.Errorf("recipient cannot be empty")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("contact_name cannot be empty")
+	}
+	if len(phones) == 0 {
+		return nil, fmt.Errorf("contact_phone cannot be empty")
+	}
+
+	normalized := make([]string, 0, len(phones))
+	seen := make(map[string]bool, len(phones))
+	for _, p := range phones {
+		phone, err := normalizeE164(p)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[phone] {
+			seen[phone] = true
+			normalized = append(normalized, phone)
+		}
+	}
+
+	if err := s.checkQuietHours(time.Now(), force); err != nil {
+		return nil, err
+	}
 
-	result, err := s.client.SendMedia(recipient, mediaPath, caption, replyToMessageID)
+	result, err := s.client.SendContact(recipient, name, normalized)
 	if err != nil {
 		return &domain.SendResult{Success: false, Message: err.Error()}, nil
 	}
@@ -126,6 +663,200 @@ func (s *MessageService) SendMedia(recipient, mediaPath, caption, replyToMessage
 	}, nil
 }
 
+// Bounds WhatsApp enforces on the number of poll options.
+const (
+	minPollOptions = 2
+	maxPollOptions = 12
+)
+
+// CreatePoll sends a poll with a question and 2-12 distinct options.
+func (s *MessageService) CreatePoll(recipient, question string, options []string, allowMultiple, force bool) (*domain.SendResult, error) {
+	if recipient == "" {
+		return nil, fmt.Errorf("recipient cannot be empty")
+	}
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if len(options) < minPollOptions || len(options) > maxPollOptions {
+		return nil, fmt.Errorf("a poll needs between %d and %d options, got %d", minPollOptions, maxPollOptions, len(options))
+	}
+
+	trimmed := make([]string, len(options))
+	seen := make(map[string]bool, len(options))
+	for i, o := range options {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			return nil, fmt.Errorf("option %d is empty", i+1)
+		}
+		key := strings.ToLower(o)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate option %q", o)
+		}
+		seen[key] = true
+		trimmed[i] = o
+	}
+
+	if err := s.checkQuietHours(time.Now(), force); err != nil {
+		return nil, err
+	}
+
+	result, err := s.client.SendPoll(recipient, question, trimmed, allowMultiple)
+	if err != nil {
+		return &domain.SendResult{Success: false, Message: err.Error()}, nil
+	}
+
+	return &domain.SendResult{
+		Success:   result.Success,
+		Message:   result.Message,
+		MessageID: ptrIfNotEmpty(result.MessageID),
+		ChatJID:   ptrIfNotEmpty(result.ChatJID),
+		Timestamp: ptrIfNotEmpty(result.Timestamp),
+	}, nil
+}
+
+// GetPollResults returns each option of a poll with its vote count and voters.
+func (s *MessageService) GetPollResults(chatJID, messageID string) (*domain.PollResults, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if messageID == "" {
+		return nil, fmt.Errorf("message_id cannot be empty")
+	}
+
+	results, err := s.store.GetPollResults(chatJID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return nil, fmt.Errorf("no poll %s found in chat %s", messageID, chatJID)
+	}
+	return results, nil
+}
+
+// GetMessageReactions returns who reacted to a message and with which emoji.
+func (s *MessageService) GetMessageReactions(chatJID, messageID string) ([]domain.Reaction, error) {
+	if chatJID == "" {
+		return nil, fmt.Errorf("chat_jid cannot be empty")
+	}
+	if messageID == "" {
+		return nil, fmt.Errorf("message_id cannot be empty")
+	}
+	return s.store.GetMessageReactions(chatJID, messageID)
+}
+
+// normalizeE164 strips common phone punctuation and checks the remainder is an
+// international number: 7-15 digits with no leading zero.
+func normalizeE164(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case '+', ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	if len(digits) < 7 || len(digits) > 15 || digits[0] == '0' || strings.Trim(digits, "0123456789") != "" {
+		return "", fmt.Errorf("invalid phone number %q: use the international format with country code, e.g. 447123456789", phone)
+	}
+	return digits, nil
+}
+
+// buildSendOptions converts send options to client options, resolving each mention
+// and any explicit quoted sender to a user JID. Mentions are only allowed when
+// sending to a group.
+func (s *MessageService) buildSendOptions(recipient string, opts domain.SendMessageOptions) (wa.SendOptions, error) {
+	sendOpts := wa.SendOptions{ReplyToMessageID: opts.ReplyToMessageID, Filename: opts.Filename}
+
+	if (opts.ReplyToSender == "") != (opts.ReplyToText == "") {
+		return sendOpts, fmt.Errorf("reply_to_sender and reply_to_text must be provided together")
+	}
+	if opts.ReplyToSender != "" {
+		if opts.ReplyToMessageID == "" {
+			return sendOpts, fmt.Errorf("reply_to_sender and reply_to_text require reply_to_message_id")
+		}
+		jid, err := s.client.ResolveRecipient(opts.ReplyToSender)
+		if err != nil {
+			return sendOpts, fmt.Errorf("failed to resolve reply_to_sender '%s': %w", opts.ReplyToSender, err)
+		}
+		if strings.HasSuffix(jid, "@g.us") {
+			return sendOpts, fmt.Errorf("reply_to_sender '%s' resolved to a group, not a participant", opts.ReplyToSender)
+		}
+		sendOpts.QuotedSender = jid
+		sendOpts.QuotedText = opts.ReplyToText
+	}
+
+	if len(opts.Mentions) == 0 {
+		return sendOpts, nil
+	}
+
+	if !strings.HasSuffix(recipient, "@g.us") {
+		return sendOpts, fmt.Errorf("mentions are only supported when sending to a group")
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range opts.Mentions {
+		jid, err := s.client.ResolveRecipient(m)
+		if err != nil {
+			return sendOpts, fmt.Errorf("failed to resolve mention '%s': %w", m, err)
+		}
+		if strings.HasSuffix(jid, "@g.us") {
+			return sendOpts, fmt.Errorf("mention '%s' resolved to a group, not a participant", m)
+		}
+		if !seen[jid] {
+			seen[jid] = true
+			sendOpts.Mentions = append(sendOpts.Mentions, jid)
+		}
+	}
+
+	return sendOpts, nil
+}
+
+// validateDocumentFilename checks that a display filename override is a plain file
+// name whose extension matches the file being sent, so the recipient's client
+// opens it with the right application.
+func validateDocumentFilename(filename, mediaPath string) error {
+	if filename == "" {
+		return nil
+	}
+	if strings.ContainsAny(filename, `/\`) || strings.TrimSpace(filename) != filename {
+		return fmt.Errorf("filename must be a plain file name without directories or surrounding spaces")
+	}
+	ext := filepath.Ext(filename)
+	if ext == "" || ext == filename {
+		return fmt.Errorf("filename must have a name and an extension (e.g. 'report.pdf')")
+	}
+	if want := filepath.Ext(mediaPath); !strings.EqualFold(ext, want) {
+		return fmt.Errorf("filename extension %s does not match the file being sent (%s)", ext, want)
+	}
+	return nil
+}
+
+// checkMediaAllowed returns ErrMediaTypeNotAllowed if the file would be sent as a
+// media type outside the configured allowlist. An empty allowlist allows everything.
+func (s *MessageService) checkMediaAllowed(mediaPath string) error {
+	if len(s.cfg.SendAllowedMedia) == 0 {
+		return nil
+	}
+	mediaType := wa.MediaTypeOf(mediaPath)
+	if !slices.Contains(s.cfg.SendAllowedMedia, mediaType) {
+		return fmt.Errorf("%w: %s files cannot be sent; allowed types: %s", ErrMediaTypeNotAllowed, mediaType, strings.Join(s.cfg.SendAllowedMedia, ", "))
+	}
+	return nil
+}
+
+// checkQuietHours returns ErrQuietHours if now falls within the configured quiet hours,
+// evaluated in the configured timezone. Forced sends are always allowed.
+func (s *MessageService) checkQuietHours(now time.Time, force bool) error {
+	if force || s.cfg.QuietHours == nil {
+		return nil
+	}
+	if s.cfg.QuietHours.Contains(now.In(s.cfg.Location)) {
+		return fmt.Errorf("%w: sending is blocked during quiet hours %s (%s)", ErrQuietHours, s.cfg.QuietHours, s.cfg.Location)
+	}
+	return nil
+}
+
 // DownloadMedia downloads media from a message.
 func (s *MessageService) DownloadMedia(messageID, chatJID string) (*domain.DownloadResult, error) {
 	if messageID == "" {
@@ -148,6 +879,100 @@ func (s *MessageService) DownloadMedia(messageID, chatJID string) (*domain.Downl
 	}, nil
 }
 
+// SetStarred stars or unstars a message. The stored flag only changes once
+// WhatsApp has accepted the change.
+func (s *MessageService) SetStarred(chatJID, messageID string, starred bool) error {
+	if chatJID == "" {
+		return fmt.Errorf("chat_jid cannot be empty")
+	}
+	if messageID == "" {
+		return fmt.Errorf("message_id cannot be empty")
+	}
+	return s.client.SetMessageStarred(chatJID, messageID, starred)
+}
+
+// ListStarred lists starred messages across all chats, newest first, along with
+// the total number starred.
+func (s *MessageService) ListStarred(limit, page int) ([]domain.Message, int, error) {
+	if limit < 1 || limit > s.cfg.MCP.MaxPageSize {
+		return nil, 0, fmt.Errorf("limit must be between 1 and %d", s.cfg.MCP.MaxPageSize)
+	}
+	if page < 0 {
+		page = 0
+	}
+	return s.store.ListStarredMessages(limit, page)
+}
+
+const (
+	// MaxBulkDownloads caps how many files one DownloadAllMedia call fetches, so
+	// a tool call on a busy chat finishes; calling again picks up the rest.
+	MaxBulkDownloads = 100
+	// bulkDownloadDelay spaces out bulk downloads to stay clear of WhatsApp's
+	// media server throttling.
+	bulkDownloadDelay = 500 * time.Millisecond
+)
+
+// DownloadAllMedia downloads a chat's media messages in a timeframe (all time if
+// empty) that aren't saved locally yet, oldest first, up to MaxBulkDownloads per
+// call. A failed item, such as one whose media has expired, is recorded and the
+// rest carry on.
+func (s *MessageService) DownloadAllMedia(chatJID, mediaType, timeframe string) (*domain.BulkDownloadResult, error) {
+	if mediaType != "" && !slices.Contains(listMediaTypes, mediaType) {
+		return nil, fmt.Errorf("invalid media_type %q: use one of %s", mediaType, strings.Join(listMediaTypes, ", "))
+	}
+	opts := domain.ListMediaOptions{ChatJID: chatJID, MediaType: mediaType, Sort: domain.SortAsc}
+	if timeframe != "" {
+		after, before, err := domain.ParseTimeframe(timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeframe: %w", err)
+		}
+		opts.After, opts.Before = after, before
+	}
+
+	items, err := s.store.ListMedia(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.BulkDownloadResult{ChatJID: chatJID, Items: []domain.BulkDownloadItem{}}
+	attempted := 0
+	for i := range items {
+		item := &items[i]
+		s.client.LocateMedia(item)
+		if item.Downloaded {
+			result.AlreadyDownloaded++
+			continue
+		}
+		if attempted == MaxBulkDownloads {
+			result.Remaining++
+			continue
+		}
+		if attempted > 0 {
+			time.Sleep(bulkDownloadDelay)
+		}
+		attempted++
+
+		outcome := domain.BulkDownloadItem{MessageID: item.MessageID, MediaType: item.MediaType}
+		dl, err := s.client.DownloadMedia(item.MessageID, chatJID)
+		switch {
+		case err == nil:
+			outcome.Status = domain.DownloadStatusDownloaded
+			outcome.Path = dl.Path
+			result.Downloaded++
+		case wa.IsMediaExpired(err):
+			outcome.Status = domain.DownloadStatusExpired
+			outcome.Error = err.Error()
+			result.Expired++
+		default:
+			outcome.Status = domain.DownloadStatusFailed
+			outcome.Error = err.Error()
+			result.Failed++
+		}
+		result.Items = append(result.Items, outcome)
+	}
+	return result, nil
+}
+
 // CatchUp provides an intelligent summary of recent WhatsApp activity.
 // Uses standard detail level: up to 10 active chats with 3 recent messages each,
 // and up to 10 questions directed at the user.
@@ -171,12 +996,11 @@ func (s *MessageService) CatchUp(opts domain.CatchUpOptions) (*domain.CatchUpSum
 		Timeframe: opts.Timeframe,
 	}
 
-	var totalCount int
-	query := "SELECT COUNT(*) FROM messages WHERE datetime(timestamp) > datetime(?) AND datetime(timestamp) < datetime(?)"
-	s.store.Messages.QueryRow(query, after, before).Scan(&totalCount)
-	summary.TotalMessages = totalCount
+	if summary.TotalMessages, err = s.store.CountMessagesBetween(after, before); err != nil {
+		return nil, err
+	}
 
-	activeChats, err := s.store.GetActiveChats(after, before, opts.OnlyGroups, maxActiveChats)
+	activeChats, err := s.store.GetActiveChats(after, before, opts.OnlyGroups, opts.IncludeMuted, maxActiveChats)
 	if err == nil {
 		if maxRecentPerChat > 0 {
 			for i := range activeChats {
@@ -192,6 +1016,7 @@ func (s *MessageService) CatchUp(opts domain.CatchUpOptions) (*domain.CatchUpSum
 			}
 		}
 		summary.ActiveChats = activeChats
+		s.flagUnusualActivity(summary, after, before)
 	}
 
 	if maxQuestions > 0 {
@@ -221,6 +1046,51 @@ func (s *MessageService) CatchUp(opts domain.CatchUpOptions) (*domain.CatchUpSum
 	return summary, nil
 }
 
+// Unusual activity detection for catch_up: a chat is flagged when its message count
+// in the timeframe is at least activitySpikeFactor times what its daily average over
+// the preceding baselineWindow predicts, and at least minSpikeMessages.
+const (
+	baselineWindow      = 28 * 24 * time.Hour
+	activitySpikeFactor = 3.0
+	minSpikeMessages    = 10
+)
+
+// flagUnusualActivity compares each active chat against its baseline daily average
+// and marks the ones with a spike.
+func (s *MessageService) flagUnusualActivity(summary *domain.CatchUpSummary, after, before string) {
+	start, err := time.Parse(time.RFC3339, after)
+	if err != nil {
+		return
+	}
+	end, err := time.Parse(time.RFC3339, before)
+	if err != nil {
+		return
+	}
+	// Short timeframes are treated as at least an hour so a few quick messages don't look like a spike
+	days := max(end.Sub(start).Hours(), 1) / 24
+
+	jids := make([]string, len(summary.ActiveChats))
+	for i, c := range summary.ActiveChats {
+		jids[i] = c.ChatJID
+	}
+	averages, err := s.store.ChatDailyAverages(jids, start.Add(-baselineWindow), start)
+	if err != nil {
+		return
+	}
+
+	for i := range summary.ActiveChats {
+		chat := &summary.ActiveChats[i]
+		avg := averages[chat.ChatJID]
+		chat.BaselineDailyAverage = &avg
+
+		expected := avg * days
+		if chat.MessageCount >= minSpikeMessages && float64(chat.MessageCount) >= activitySpikeFactor*expected {
+			chat.UnusuallyActive = true
+			summary.UnusuallyBusy = append(summary.UnusuallyBusy, chat.ChatName)
+		}
+	}
+}
+
 // generateCatchUpSummary creates a natural language summary.
 func (s *MessageService) generateCatchUpSummary(data *domain.CatchUpSummary) string {
 	if data.TotalMessages == 0 {
@@ -247,6 +1117,10 @@ func (s *MessageService) generateCatchUpSummary(data *domain.CatchUpSummary) str
 		summary += fmt.Sprintf(" %d chat(s) have unanswered questions.", len(data.NeedsAttention))
 	}
 
+	if len(data.UnusuallyBusy) > 0 {
+		summary += fmt.Sprintf(" Unusually active: %s.", strings.Join(data.UnusuallyBusy, ", "))
+	}
+
 	return summary
 }
 
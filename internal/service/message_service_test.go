@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/config"
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+	"github.com/eddmann/whatsapp-mcp/internal/store"
+)
+
+func TestCheckQuietHours(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	// 22:00-07:00 in Tokyo is 13:00-22:00 UTC
+	s := NewMessageService(nil, nil, &config.Config{
+		Location:   tokyo,
+		QuietHours: &config.QuietHours{Start: 22 * 60, End: 7 * 60},
+	})
+	utc := func(hour, min int) time.Time { return time.Date(2025, 3, 1, hour, min, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		force   bool
+		blocked bool
+	}{
+		{name: "just before start", now: utc(12, 59)},
+		{name: "at start", now: utc(13, 0), blocked: true},
+		{name: "local midnight", now: utc(15, 0), blocked: true},
+		{name: "just before end", now: utc(21, 59), blocked: true},
+		{name: "at end", now: utc(22, 0)},
+		{name: "forced inside window", now: utc(15, 0), force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkQuietHours(tt.now, tt.force)
+			if got := errors.Is(err, ErrQuietHours); got != tt.blocked {
+				t.Errorf("checkQuietHours = %v, want blocked %v", err, tt.blocked)
+			}
+		})
+	}
+
+	if err := NewMessageService(nil, nil, &config.Config{Location: tokyo}).checkQuietHours(utc(15, 0), false); err != nil {
+		t.Errorf("checkQuietHours without quiet hours = %v, want nil", err)
+	}
+}
+
+func TestSearchMessagesCapsContextRows(t *testing.T) {
+	db := newTestStore(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat(chat, "Test", at); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		if _, err := db.Messages.Exec(`INSERT INTO messages (id, chat_jid, sender, content, timestamp, is_from_me, media_type)
+			VALUES (?, ?, '447700900001', 'standup notes', ?, 0, '')`, fmt.Sprintf("m%03d", i), chat, store.FormatTime(at.Add(time.Duration(i)*time.Minute))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewMessageService(db, nil, &config.Config{MCP: config.MCPConfig{MaxPageSize: 200, DefaultSearchLimit: 100, MaxContextRows: 25}})
+	got, total, truncated, err := s.SearchMessages(domain.SearchMessagesOptions{Query: "standup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 200 {
+		t.Errorf("total = %d, want every match counted", total)
+	}
+	if len(got) != 25 || !truncated {
+		t.Errorf("returned %d rows, truncated %v; want 25 rows, truncated", len(got), truncated)
+	}
+}
+
+func TestCheckMediaAllowed(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	photo := write("photo.png", img.Bytes())
+	disguised := write("invoice.pdf", img.Bytes())
+	notes := write("notes.txt", []byte("meeting notes"))
+
+	tests := []struct {
+		name    string
+		allowed []string
+		path    string
+		blocked bool
+	}{
+		{name: "empty allowlist allows everything", path: photo},
+		{name: "allowed type", allowed: []string{"document"}, path: notes},
+		{name: "disallowed type", allowed: []string{"document"}, path: photo, blocked: true},
+		{name: "type sniffed from content", allowed: []string{"document"}, path: disguised, blocked: true},
+		{name: "one of several", allowed: []string{"image", "video"}, path: photo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMessageService(nil, nil, &config.Config{SendAllowedMedia: tt.allowed})
+			err := s.checkMediaAllowed(tt.path)
+			if got := errors.Is(err, ErrMediaTypeNotAllowed); got != tt.blocked {
+				t.Errorf("checkMediaAllowed = %v, want blocked %v", err, tt.blocked)
+			}
+		})
+	}
+
+	// SendMedia refuses before anything reaches the WhatsApp client
+	s := NewMessageService(nil, nil, &config.Config{SendAllowedMedia: []string{"document"}})
+	if _, err := s.SendMedia("447700900001", photo, "", domain.SendMessageOptions{}); !errors.Is(err, ErrMediaTypeNotAllowed) {
+		t.Errorf("SendMedia = %v, want ErrMediaTypeNotAllowed", err)
+	}
+}
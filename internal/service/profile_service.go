@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eddmann/whatsapp-mcp/internal/wa"
+)
+
+// WhatsApp's limits for the account's display name and about text, in characters.
+const (
+	maxProfileNameLength = 25
+	maxStatusLength      = 139
+)
+
+// ProfileService handles updates to the logged-in account's own profile.
+type ProfileService struct {
+	client *wa.Client
+}
+
+// NewProfileService creates a new ProfileService.
+func NewProfileService(client *wa.Client) *ProfileService {
+	return &ProfileService{client: client}
+}
+
+// SetProfileName sets the account's display (push) name and returns the applied value.
+func (s *ProfileService) SetProfileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("name cannot be empty")
+	}
+	if n := utf8.RuneCountInString(name); n > maxProfileNameLength {
+		return "", fmt.Errorf("name is %d characters, the maximum is %d", n, maxProfileNameLength)
+	}
+
+	if err := s.client.SetProfileName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// SetStatus sets the account's about/status text and returns the applied value.
+func (s *ProfileService) SetStatus(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("status cannot be empty")
+	}
+	if n := utf8.RuneCountInString(text); n > maxStatusLength {
+		return "", fmt.Errorf("status is %d characters, the maximum is %d", n, maxStatusLength)
+	}
+
+	if err := s.client.SetStatus(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
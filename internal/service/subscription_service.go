@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// maxSubscribedChats caps how many chats a single session can subscribe to.
+const maxSubscribedChats = 100
+
+// SubscriptionService tracks which MCP client sessions want new-message
+// notifications for which chats.
+type SubscriptionService struct {
+	mu       sync.RWMutex
+	sessions map[string]map[string]bool // session ID -> set of chat JIDs
+}
+
+// NewSubscriptionService creates a new SubscriptionService.
+func NewSubscriptionService() *SubscriptionService {
+	return &SubscriptionService{sessions: make(map[string]map[string]bool)}
+}
+
+// Subscribe adds chats to a session's subscriptions and returns the session's full list.
+func (s *SubscriptionService) Subscribe(sessionID string, chatJIDs []string) ([]string, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("no client session; notifications require a persistent MCP connection")
+	}
+	if len(chatJIDs) == 0 {
+		return nil, fmt.Errorf("at least one chat is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Build the union in a copy so a rejected request leaves the session untouched
+	existing := s.sessions[sessionID]
+	chats := make(map[string]bool, len(existing)+len(chatJIDs))
+	for jid := range existing {
+		chats[jid] = true
+	}
+	for _, jid := range chatJIDs {
+		chats[jid] = true
+	}
+	if len(chats) > maxSubscribedChats {
+		return nil, fmt.Errorf("cannot subscribe to more than %d chats", maxSubscribedChats)
+	}
+	s.sessions[sessionID] = chats
+
+	return sortedKeys(chats), nil
+}
+
+// Unsubscribe removes chats from a session's subscriptions, or all of them when
+// chatJIDs is empty, and returns the remaining list.
+func (s *SubscriptionService) Unsubscribe(sessionID string, chatJIDs []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(chatJIDs) == 0 {
+		delete(s.sessions, sessionID)
+		return []string{}
+	}
+
+	chats := s.sessions[sessionID]
+	for _, jid := range chatJIDs {
+		delete(chats, jid)
+	}
+	if len(chats) == 0 {
+		delete(s.sessions, sessionID)
+	}
+
+	return sortedKeys(chats)
+}
+
+// RemoveSession drops all subscriptions for a disconnected session.
+func (s *SubscriptionService) RemoveSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// Subscribers returns the session IDs subscribed to a chat.
+func (s *SubscriptionService) Subscribers(chatJID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	for id, chats := range s.sessions {
+		if chats[chatJID] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sortedKeys returns the keys of a set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+)
+
+func chatJIDs(from, n int) []string {
+	jids := make([]string, n)
+	for i := range jids {
+		jids[i] = fmt.Sprintf("4477009%05d@s.whatsapp.net", from+i)
+	}
+	return jids
+}
+
+func TestSubscribeCap(t *testing.T) {
+	tests := []struct {
+		name      string
+		initial   int
+		add       int
+		wantErr   bool
+		wantCount int
+	}{
+		{name: "within cap", initial: 10, add: 5, wantCount: 15},
+		{name: "exactly at cap", initial: maxSubscribedChats - 1, add: 1, wantCount: maxSubscribedChats},
+		{name: "over cap leaves session unchanged", initial: maxSubscribedChats - 1, add: 2, wantErr: true, wantCount: maxSubscribedChats - 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSubscriptionService()
+			if _, err := s.Subscribe("session", chatJIDs(0, tt.initial)); err != nil {
+				t.Fatalf("initial subscribe: %v", err)
+			}
+
+			_, err := s.Subscribe("session", chatJIDs(tt.initial, tt.add))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Subscribe error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(s.sessions["session"]); got != tt.wantCount {
+				t.Errorf("subscribed chats = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
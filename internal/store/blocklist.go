@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// ReplaceBlocklist replaces the cached blocklist with jids, keeping the time each
+// still-blocked JID was first recorded.
+func (d *DB) ReplaceBlocklist(jids []string) error {
+	tx, err := d.Messages.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	keep := make(map[string]bool, len(jids))
+	now := time.Now()
+	for _, jid := range jids {
+		keep[jid] = true
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO blocklist (jid, blocked_at) VALUES (?, ?)`, jid, now); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query(`SELECT jid FROM blocklist`)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			rows.Close()
+			return err
+		}
+		if !keep[jid] {
+			stale = append(stale, jid)
+		}
+	}
+	rows.Close()
+	for _, jid := range stale {
+		if _, err := tx.Exec(`DELETE FROM blocklist WHERE jid = ?`, jid); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetBlocked adds a JID to or removes it from the cached blocklist.
+func (d *DB) SetBlocked(jid string, blocked bool) error {
+	if !blocked {
+		_, err := d.Messages.Exec(`DELETE FROM blocklist WHERE jid = ?`, jid)
+		return err
+	}
+	_, err := d.Messages.Exec(`INSERT OR IGNORE INTO blocklist (jid, blocked_at) VALUES (?, ?)`, jid, time.Now())
+	return err
+}
+
+// ListBlocked returns the cached blocklist with names from contacts, most recently blocked first.
+func (d *DB) ListBlocked() ([]domain.BlockedContact, error) {
+	rows, err := d.Messages.Query(`
+		SELECT b.jid, COALESCE(NULLIF(c.full_name, ''), NULLIF(c.business_name, ''), NULLIF(c.push_name, '')), b.blocked_at
+		FROM blocklist b
+		LEFT JOIN contacts c ON c.jid = b.jid
+		ORDER BY b.blocked_at DESC, b.jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := []domain.BlockedContact{}
+	for rows.Next() {
+		var b domain.BlockedContact
+		var name sql.NullString
+		if err := rows.Scan(&b.JID, &name, &b.BlockedAt); err != nil {
+			return nil, err
+		}
+		b.Phone, _, _ = strings.Cut(b.JID, "@")
+		if name.Valid {
+			b.Name = &name.String
+		}
+		blocked = append(blocked, b)
+	}
+	return blocked, rows.Err()
+}
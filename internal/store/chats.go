@@ -0,0 +1,246 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// UpsertChat inserts a chat or updates its name and last activity time,
+// leaving other columns (such as the read marker) untouched.
+func (d *DB) UpsertChat(jid, name string, lastMessageTime time.Time) error {
+	_, err := d.Messages.Exec(`
+		INSERT INTO chats (jid, name, last_message_time) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			name = excluded.name,
+			last_message_time = excluded.last_message_time`,
+		jid, name, FormatTime(lastMessageTime),
+	)
+	return err
+}
+
+// ListUnknownSenderChats returns direct chats whose contact has no saved address
+// book name and who have sent at least one message, most recent first. Non-empty
+// after/before restrict it to chats they messaged within that range.
+func (d *DB) ListUnknownSenderChats(after, before string, limit int) ([]domain.UnknownSenderChat, error) {
+	args := excludeSystemArgs()
+	q := `SELECT c.jid, contacts.push_name, COALESCE(contacts.business_name, '') != '',
+			COUNT(*), EXISTS(SELECT 1 FROM messages WHERE chat_jid = c.jid AND is_from_me = 1),
+			(SELECT content FROM messages lm WHERE lm.chat_jid = c.jid AND lm.is_from_me = 0 AND ` + excludeSystemSQL("lm") + `
+				ORDER BY lm.timestamp DESC, lm.rowid DESC LIMIT 1),
+			CAST(MAX(m.timestamp) AS TEXT)
+		FROM chats c
+		JOIN messages m ON m.chat_jid = c.jid AND m.is_from_me = 0
+		LEFT JOIN contacts ON contacts.jid = c.jid
+		WHERE c.jid LIKE '%@s.whatsapp.net' AND COALESCE(contacts.full_name, '') = ''`
+	if after != "" {
+		q += " AND m.timestamp > " + timeArg
+		args = append(args, after)
+	}
+	if before != "" {
+		q += " AND m.timestamp < " + timeArg
+		args = append(args, before)
+	}
+	q += " GROUP BY c.jid ORDER BY MAX(m.timestamp) DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.Messages.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chats := []domain.UnknownSenderChat{}
+	for rows.Next() {
+		var chat domain.UnknownSenderChat
+		var pushName, lastMessage, ts sql.NullString
+		if err := rows.Scan(&chat.JID, &pushName, &chat.IsBusiness, &chat.MessageCount, &chat.HasReplied, &lastMessage, &ts); err != nil {
+			return nil, err
+		}
+		chat.Phone, _, _ = strings.Cut(chat.JID, "@")
+		if pushName.Valid && pushName.String != "" {
+			chat.PushName = &pushName.String
+		}
+		if lastMessage.Valid {
+			chat.LastMessage = &lastMessage.String
+		}
+		if ts.Valid {
+			t := parseDBTime(ts.String)
+			chat.LastMessageTime = &t
+		}
+		chats = append(chats, chat)
+	}
+
+	return chats, rows.Err()
+}
+
+// RenameChat updates a chat's stored name, and the cached group name if it is a group.
+func (d *DB) RenameChat(jid, name string) error {
+	if _, err := d.Messages.Exec(`UPDATE chats SET name = ? WHERE jid = ?`, name, jid); err != nil {
+		return err
+	}
+	_, err := d.Messages.Exec(`UPDATE groups SET name = ? WHERE jid = ?`, name, jid)
+	return err
+}
+
+// SetChatArchived records whether a chat is archived. Archiving also unpins it,
+// matching WhatsApp.
+func (d *DB) SetChatArchived(jid string, archived bool) error {
+	_, err := d.Messages.Exec(`UPDATE chats SET archived = ?, pinned = CASE WHEN ? THEN 0 ELSE pinned END WHERE jid = ?`,
+		archived, archived, jid)
+	return err
+}
+
+// SetChatPinned records whether a chat is pinned.
+func (d *DB) SetChatPinned(jid string, pinned bool) error {
+	_, err := d.Messages.Exec(`UPDATE chats SET pinned = ? WHERE jid = ?`, pinned, jid)
+	return err
+}
+
+// SetChatMutedUntil records when a chat's mute ends; domain.MutedForever mutes it
+// indefinitely and the zero time unmutes it.
+func (d *DB) SetChatMutedUntil(jid string, until time.Time) error {
+	var value any
+	if !until.IsZero() {
+		value = FormatTime(until)
+	}
+	_, err := d.Messages.Exec(`UPDATE chats SET muted_until = ? WHERE jid = ?`, value, jid)
+	return err
+}
+
+// MarkChatRead advances a chat's last-read marker to t. The marker never moves
+// backwards, so out-of-order receipts cannot un-read messages.
+func (d *DB) MarkChatRead(jid string, t time.Time) error {
+	_, err := d.Messages.Exec(`
+		UPDATE chats SET last_read_time = ?
+		WHERE jid = ? AND (last_read_time IS NULL OR datetime(last_read_time) < datetime(?))`,
+		FormatTime(t), jid, FormatTime(t),
+	)
+	return err
+}
+
+// MarkChatUnread moves a chat's last-read marker to just before its latest
+// incoming message, so at least that message counts as unread.
+func (d *DB) MarkChatUnread(jid string) error {
+	_, err := d.Messages.Exec(`
+		UPDATE chats SET last_read_time = (
+			SELECT strftime('%Y-%m-%dT%H:%M:%SZ', MAX(datetime(timestamp)), '-1 second') FROM messages
+			WHERE chat_jid = ? AND is_from_me = 0
+		)
+		WHERE jid = ?`, jid, jid)
+	return err
+}
+
+// GetChatReadTime returns a chat's last-read marker, or nil if none is known.
+func (d *DB) GetChatReadTime(jid string) (*time.Time, error) {
+	var ts sql.NullString
+	err := d.Messages.QueryRow(`SELECT CAST(last_read_time AS TEXT) FROM chats WHERE jid = ?`, jid).Scan(&ts)
+	if err == sql.ErrNoRows || (err == nil && !ts.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := parseDBTime(ts.String)
+	return &t, nil
+}
+
+// ListUnreadMessages returns incoming messages in a chat newer than readTime,
+// oldest first, along with the total number of such messages.
+func (d *DB) ListUnreadMessages(chatJID string, readTime time.Time, limit int) ([]domain.Message, int, error) {
+	where := `messages.chat_jid = ? AND messages.is_from_me = 0 AND messages.timestamp > ` + timeArg + ` AND ` + excludeSystemSQL("messages")
+	args := append([]any{chatJID, readTime.UTC().Format(time.RFC3339)}, excludeSystemArgs()...)
+
+	var total int
+	if err := d.Messages.QueryRow(`SELECT COUNT(*) FROM messages WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := d.Messages.Query(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE `+where+`
+		ORDER BY messages.timestamp ASC, messages.rowid ASC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	messages := []domain.Message{}
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, total, rows.Err()
+}
+
+// ListIncomingMessages returns incoming messages in a chat with timestamps after
+// after and no later than upTo, newest first, capped by limit.
+func (d *DB) ListIncomingMessages(chatJID string, after, upTo time.Time, limit int) ([]domain.Message, error) {
+	args := append([]any{chatJID, after.UTC().Format(time.RFC3339), upTo.UTC().Format(time.RFC3339)}, excludeSystemArgs()...)
+	rows, err := d.Messages.Query(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE messages.chat_jid = ? AND messages.is_from_me = 0
+			AND messages.timestamp > `+timeArg+` AND messages.timestamp <= `+timeArg+`
+			AND `+excludeSystemSQL("messages")+`
+		ORDER BY messages.timestamp DESC, messages.rowid DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []domain.Message{}
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// ListRecentChats returns the chats with the most recent activity, newest first,
+// naming each from the chat record or, failing that, the contact's cached names.
+// Broadcast lists and status updates are excluded.
+func (d *DB) ListRecentChats(directOnly bool, limit int) ([]domain.Chat, error) {
+	q := `SELECT chats.jid, COALESCE(NULLIF(chats.name, ''), contacts.full_name, contacts.business_name, contacts.push_name), chats.last_message_time
+		FROM chats LEFT JOIN contacts ON contacts.jid = chats.jid
+		WHERE chats.last_message_time IS NOT NULL AND chats.jid NOT LIKE '%@broadcast'`
+	if directOnly {
+		q += " AND chats.jid NOT LIKE '%@g.us'"
+	}
+	q += " ORDER BY chats.last_message_time DESC LIMIT ?"
+
+	rows, err := d.Messages.Query(q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chats := []domain.Chat{}
+	for rows.Next() {
+		var chat domain.Chat
+		var name, ts sql.NullString
+		if err := rows.Scan(&chat.JID, &name, &ts); err != nil {
+			return nil, err
+		}
+		if name.Valid {
+			chat.Name = &name.String
+		}
+		if ts.Valid {
+			t, _ := time.Parse(time.RFC3339, ts.String)
+			chat.LastMessageTime = &t
+		}
+		chat.IsGroup = strings.HasSuffix(chat.JID, "@g.us")
+		chats = append(chats, chat)
+	}
+
+	return chats, rows.Err()
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// UpsertContact inserts or updates a contact's cached names.
+// Empty names never overwrite previously known values.
+func (d *DB) UpsertContact(jid, phone, fullName, pushName, businessName string) error {
+	_, err := d.Messages.Exec(`
+		INSERT INTO contacts (jid, phone, full_name, push_name, business_name, updated_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			phone = excluded.phone,
+			full_name = COALESCE(excluded.full_name, contacts.full_name),
+			push_name = COALESCE(excluded.push_name, contacts.push_name),
+			business_name = COALESCE(excluded.business_name, contacts.business_name),
+			updated_at = excluded.updated_at`,
+		jid, phone, fullName, pushName, businessName, FormatTime(time.Now()),
+	)
+	return err
+}
+
+// GetContactName returns the best known name for a contact JID,
+// preferring full name, then business name, then push name.
+// Returns an empty string if the contact is unknown or has no name.
+func (d *DB) GetContactName(jid string) (string, error) {
+	var name sql.NullString
+	err := d.Messages.QueryRow(`
+		SELECT COALESCE(full_name, business_name, push_name)
+		FROM contacts WHERE jid = ?`, jid).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return name.String, nil
+}
+
+// SearchContacts returns individual contacts whose name or phone number contains
+// query (case-insensitive), named contacts first, plus the total number of matches.
+func (d *DB) SearchContacts(query string, limit int) ([]domain.Contact, int, error) {
+	pattern := "%" + strings.ToLower(query) + "%"
+	filter := `
+		FROM contacts
+		WHERE (jid LIKE '%@s.whatsapp.net' OR jid LIKE '%@lid')
+			AND (LOWER(full_name) LIKE ? OR LOWER(business_name) LIKE ? OR LOWER(push_name) LIKE ? OR phone LIKE ?)`
+	args := []any{pattern, pattern, pattern, pattern}
+
+	var total int
+	if err := d.Messages.QueryRow("SELECT COUNT(*)"+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := d.Messages.Query(`
+		SELECT jid, phone, COALESCE(full_name, business_name, push_name)`+filter+`
+		ORDER BY COALESCE(full_name, business_name, push_name) IS NULL, LOWER(COALESCE(full_name, business_name, push_name)), phone
+		LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	contacts := []domain.Contact{}
+	for rows.Next() {
+		var c domain.Contact
+		var phone, name sql.NullString
+		if err := rows.Scan(&c.JID, &phone, &name); err != nil {
+			return nil, 0, err
+		}
+		c.Phone = phone.String
+		if name.Valid {
+			c.Name = &name.String
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, total, rows.Err()
+}
+
+// ListUnnamedContacts returns JIDs of contacts without a full or business name.
+func (d *DB) ListUnnamedContacts() ([]string, error) {
+	rows, err := d.Messages.Query(`SELECT jid FROM contacts WHERE full_name IS NULL AND business_name IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+	return jids, rows.Err()
+}
+
+// GetBusinessInfo returns the cached business lookup for a contact JID,
+// or nil if it has never been checked.
+func (d *DB) GetBusinessInfo(jid string) (*domain.BusinessInfo, error) {
+	var isBusiness sql.NullBool
+	var businessName, verifiedName, issuer, checkedAt sql.NullString
+	err := d.Messages.QueryRow(`
+		SELECT is_business, business_name, verified_name, verified_issuer, business_checked_at
+		FROM contacts WHERE jid = ?`, jid).Scan(&isBusiness, &businessName, &verifiedName, &issuer, &checkedAt)
+	if err == sql.ErrNoRows || (err == nil && !checkedAt.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := &domain.BusinessInfo{JID: jid, Source: "cache"}
+	if isBusiness.Valid {
+		info.IsBusiness = &isBusiness.Bool
+	}
+	if businessName.Valid {
+		info.BusinessName = &businessName.String
+	}
+	if verifiedName.Valid {
+		info.VerifiedName = &verifiedName.String
+	}
+	if issuer.Valid {
+		info.VerifiedIssuer = &issuer.String
+	}
+	info.CheckedAt = parseDBTime(checkedAt.String)
+	return info, nil
+}
+
+// SaveBusinessInfo caches the result of a business lookup on the contact row.
+func (d *DB) SaveBusinessInfo(phone string, info domain.BusinessInfo) error {
+	_, err := d.Messages.Exec(`
+		INSERT INTO contacts (jid, phone, business_name, is_business, verified_name, verified_issuer, business_checked_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			business_name = COALESCE(excluded.business_name, contacts.business_name),
+			is_business = excluded.is_business,
+			verified_name = excluded.verified_name,
+			verified_issuer = excluded.verified_issuer,
+			business_checked_at = excluded.business_checked_at`,
+		info.JID, phone, info.BusinessName, info.IsBusiness, info.VerifiedName, info.VerifiedIssuer, FormatTime(info.CheckedAt), FormatTime(time.Now()),
+	)
+	return err
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+func TestContactTimestampsStoredAsRFC3339(t *testing.T) {
+	d := newTestDB(t)
+	const jid = "447700900001@s.whatsapp.net"
+	if err := d.UpsertContact(jid, "447700900001", "", "Sam", ""); err != nil {
+		t.Fatal(err)
+	}
+	checked := time.Date(2025, 3, 1, 12, 30, 0, 0, time.FixedZone("BST", 3600))
+	yes := true
+	if err := d.SaveBusinessInfo("447700900001", domain.BusinessInfo{JID: jid, IsBusiness: &yes, CheckedAt: checked}); err != nil {
+		t.Fatal(err)
+	}
+
+	var updated, checkedAt string
+	if err := d.Messages.QueryRow(`SELECT updated_at, business_checked_at FROM contacts WHERE jid = ?`, jid).Scan(&updated, &checkedAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse(time.RFC3339, updated); err != nil || updated != FormatTime(parseDBTime(updated)) {
+		t.Errorf("updated_at = %q, want UTC RFC3339", updated)
+	}
+	if checkedAt != "2025-03-01T11:30:00Z" {
+		t.Errorf("business_checked_at = %q, want 2025-03-01T11:30:00Z", checkedAt)
+	}
+
+	info, err := d.GetBusinessInfo(jid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || !info.CheckedAt.Equal(checked) {
+		t.Errorf("cached business info = %+v, want checked at %s", info, checked)
+	}
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// EditMessageContent replaces a message's content with its edited text, keeping the
+// previous content in message_edits. The messages_au trigger reindexes messages_fts.
+// Returns false if the message isn't stored.
+func (d *DB) EditMessageContent(chatJID, messageID, content string, editedAt time.Time) (bool, error) {
+	tx, err := d.Messages.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+	err = tx.QueryRow(`SELECT content FROM messages WHERE chat_jid = ? AND id = ?`, chatJID, messageID).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if previous.String == content {
+		return true, nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO message_edits (chat_jid, message_id, previous_content, edited_at) VALUES (?, ?, ?, ?)`,
+		chatJID, messageID, previous, FormatTime(editedAt)); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE messages SET content = ?, edited_at = ? WHERE chat_jid = ? AND id = ?`, content, FormatTime(editedAt), chatJID, messageID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
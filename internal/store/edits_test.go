@@ -0,0 +1,85 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+func TestEditedMessagesAreFlagged(t *testing.T) {
+	d := newTestDB(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	editedAt := at.Add(5 * time.Minute)
+	addMessages(t, d,
+		testMessage{id: "plain", chat: chat, sender: "447700900001", content: "see you at 6", at: at},
+		testMessage{id: "edited", chat: chat, sender: "447700900001", content: "see you at 7", at: at.Add(time.Minute)},
+	)
+	if found, err := d.EditMessageContent(chat, "edited", "see you at 8", editedAt); err != nil || !found {
+		t.Fatalf("EditMessageContent = %v, %v", found, err)
+	}
+
+	one := func(m *domain.Message, err error) ([]domain.Message, error) {
+		if m == nil {
+			return nil, err
+		}
+		return []domain.Message{*m}, err
+	}
+	reads := map[string]func() ([]domain.Message, error){
+		"ListMessages": func() ([]domain.Message, error) {
+			return d.ListMessages(domain.ListMessagesOptions{ChatJID: chat})
+		},
+		"GetMessage":       func() ([]domain.Message, error) { return one(d.GetMessage(chat, "edited")) },
+		"GetLatestMessage": func() ([]domain.Message, error) { return one(d.GetLatestMessage(chat)) },
+		"SearchMessages": func() ([]domain.Message, error) {
+			msgs, _, err := d.SearchMessages(domain.SearchMessagesOptions{Query: "see you"})
+			return msgs, err
+		},
+		"ListUnreadMessages": func() ([]domain.Message, error) {
+			msgs, _, err := d.ListUnreadMessages(chat, at.Add(-time.Hour), 10)
+			return msgs, err
+		},
+	}
+
+	for name, read := range reads {
+		t.Run(name, func(t *testing.T) {
+			msgs, err := read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(msgs) == 0 {
+				t.Fatal("no messages returned")
+			}
+			for _, m := range msgs {
+				switch m.ID {
+				case "edited":
+					if !m.IsEdited || m.EditedAt == nil || !m.EditedAt.Equal(editedAt) {
+						t.Errorf("edited message: is_edited = %v, edited_at = %v; want true, %v", m.IsEdited, m.EditedAt, editedAt)
+					}
+					if m.Content == nil || *m.Content != "see you at 8" {
+						t.Errorf("edited message content = %v, want the edited text", m.Content)
+					}
+				case "plain":
+					if m.IsEdited || m.EditedAt != nil {
+						t.Errorf("unedited message: is_edited = %v, edited_at = %v", m.IsEdited, m.EditedAt)
+					}
+				}
+			}
+		})
+	}
+
+	msg, err := d.GetMessage(chat, "edited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"is_edited":true`) || !strings.Contains(string(out), `"edited_at":"2025-03-01T12:05:00Z"`) {
+		t.Errorf("message JSON = %s, want is_edited and edited_at", out)
+	}
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"strings"
+)
+
+// sanitizeFTSQuery rewrites a search query into valid FTS5 syntax. Every word is
+// wrapped in double quotes so punctuation (apostrophes, colons, '$', '?') is
+// treated as text, while intentional syntax is kept: quoted phrases, OR/AND/NOT
+// between terms, prefix wildcards (vacat*), balanced parentheses, and -word
+// exclusions, which become NOT clauses after the positive terms.
+func sanitizeFTSQuery(query string) string {
+	var tokens, excluded []string
+	keepParens := balancedParens(query)
+
+	for len(query) > 0 {
+		query = strings.TrimLeft(query, " \t\n")
+		if query == "" {
+			break
+		}
+
+		switch c := query[0]; {
+		case c == '(' || c == ')':
+			if keepParens {
+				tokens = append(tokens, string(c))
+			}
+			query = query[1:]
+			continue
+		case c == '"':
+			end := strings.IndexByte(query[1:], '"')
+			var phrase string
+			if end < 0 {
+				phrase, query = query[1:], ""
+			} else {
+				phrase, query = query[1:end+1], query[end+2:]
+			}
+			tok := quoteFTS(phrase)
+			if strings.HasPrefix(query, "*") {
+				tok += "*"
+				query = query[1:]
+			}
+			tokens = append(tokens, tok)
+			continue
+		}
+
+		end := strings.IndexAny(query, " \t\n()\"")
+		if end < 0 {
+			end = len(query)
+		}
+		word := query[:end]
+		query = query[end:]
+
+		switch {
+		case word == "OR" || word == "AND" || word == "NOT":
+			tokens = append(tokens, word)
+		case len(word) > 1 && word[0] == '-':
+			excluded = append(excluded, ftsTerm(word[1:]))
+		default:
+			tokens = append(tokens, ftsTerm(word))
+		}
+	}
+
+	tokens = fixOperators(dropEmptyGroups(tokens))
+	if len(tokens) == 0 {
+		// FTS5 can't match on exclusions alone, so treat a lone "-word" as a word
+		return strings.Join(excluded, " ")
+	}
+
+	out := joinFTS(tokens)
+	if len(excluded) > 0 {
+		out = "(" + out + ")"
+		for _, e := range excluded {
+			out += " NOT " + e
+		}
+	}
+	return out
+}
+
+// ftsTerm quotes a single word, keeping a trailing '*' as a prefix wildcard.
+func ftsTerm(word string) string {
+	if stem, ok := strings.CutSuffix(word, "*"); ok && strings.TrimRight(stem, "*") != "" {
+		return quoteFTS(strings.TrimRight(stem, "*")) + "*"
+	}
+	return quoteFTS(word)
+}
+
+// quoteFTS wraps s in double quotes as an FTS5 string, escaping embedded quotes.
+func quoteFTS(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// fixOperators quotes boolean operators that have no term on one side (e.g. a
+// query starting with "AND" or ending in "OR"), so they are searched as words.
+func fixOperators(tokens []string) []string {
+	for i, t := range tokens {
+		if !isFTSOperator(t) {
+			continue
+		}
+		prevOK := i > 0 && !isFTSOperator(tokens[i-1]) && tokens[i-1] != "("
+		nextOK := i+1 < len(tokens) && !isFTSOperator(tokens[i+1]) && tokens[i+1] != ")"
+		if !prevOK || !nextOK {
+			tokens[i] = quoteFTS(t)
+		}
+	}
+	return tokens
+}
+
+// dropEmptyGroups removes parenthesised groups with nothing inside, such as "()"
+// or "(())", which FTS5 rejects as a syntax error.
+func dropEmptyGroups(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t == ")" && len(out) > 0 && out[len(out)-1] == "(" {
+			out = out[:len(out)-1]
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// joinFTS joins query tokens with spaces, adding an explicit AND where a term
+// meets a parenthesised group, since FTS5 only allows implicit AND between phrases.
+func joinFTS(tokens []string) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 {
+			prev := tokens[i-1]
+			if (prev == ")" && t != ")" && !isFTSOperator(t)) || (t == "(" && prev != "(" && !isFTSOperator(prev)) {
+				b.WriteString(" AND")
+			}
+			b.WriteByte(' ')
+		}
+		b.WriteString(t)
+	}
+	return b.String()
+}
+
+// isFTSOperator reports whether a query token is a boolean operator.
+func isFTSOperator(t string) bool {
+	return t == "OR" || t == "AND" || t == "NOT"
+}
+
+// balancedParens reports whether the parentheses outside quoted phrases in query
+// are balanced and never close before opening.
+func balancedParens(query string) bool {
+	depth, inQuote, found := 0, false, false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			depth++
+			found = true
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return found && depth == 0
+}
@@ -0,0 +1,60 @@
+package store
+
+import "testing"
+
+func TestSanitizeFTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "what's up?", want: `"what's" "up?"`},
+		{query: "cost: $5", want: `"cost:" "$5"`},
+		{query: "a OR b", want: `"a" OR "b"`},
+		{query: "a AND NOT b", want: `"a" "AND" NOT "b"`},
+		{query: "-foo", want: `"foo"`},
+		{query: "a -b", want: `("a") NOT "b"`},
+		{query: `"phrase"*`, want: `"phrase"*`},
+		{query: `"say ""hi"""`, want: `"say " "hi" ""`},
+		{query: `"unterminated phrase`, want: `"unterminated phrase"`},
+		{query: "vacat*", want: `"vacat"*`},
+		{query: "OR", want: `"OR"`},
+		{query: "a OR", want: `"a" "OR"`},
+		{query: "()", want: ""},
+		{query: "(())", want: ""},
+		{query: "(a OR b) c", want: `( "a" OR "b" ) AND "c"`},
+		{query: "(a OR b", want: `"a" OR "b"`},
+		{query: "a OR b)", want: `"a" OR "b"`},
+		{query: ")a(", want: `"a"`},
+		{query: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := sanitizeFTSQuery(tt.query); got != tt.want {
+				t.Errorf("sanitizeFTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSanitizeFTSQueryIsValid runs each sanitised query through FTS5 itself, so
+// it only checks syntax in builds with the sqlite_fts5 tag.
+func TestSanitizeFTSQueryIsValid(t *testing.T) {
+	d := newTestDB(t)
+	if !d.FTS {
+		t.Skip("SQLite built without FTS5")
+	}
+
+	for _, query := range []string{"what's up?", "cost: $5", "a OR b", "-foo", "a -b", `"phrase"*`, "()", "(a OR b) c", "(a OR b", "a OR b)", ")a(", "OR", "NOT a", "AND", "a AND NOT b"} {
+		t.Run(query, func(t *testing.T) {
+			fts := sanitizeFTSQuery(query)
+			if fts == "" {
+				return
+			}
+			var n int
+			if err := d.Messages.QueryRow(`SELECT COUNT(*) FROM messages_fts WHERE messages_fts MATCH ?`, fts).Scan(&n); err != nil {
+				t.Errorf("MATCH %q (from %q): %v", fts, query, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// UpsertGroup inserts or replaces cached metadata for a group.
+func (d *DB) UpsertGroup(g domain.Group) error {
+	_, err := d.Messages.Exec(`
+		INSERT INTO groups (jid, name, participant_count, is_admin, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			name = excluded.name,
+			participant_count = excluded.participant_count,
+			is_admin = excluded.is_admin,
+			updated_at = excluded.updated_at`,
+		g.JID, g.Name, g.ParticipantCount, g.IsAdmin, g.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// GetGroup returns cached metadata for a group, or nil if it is not cached.
+func (d *DB) GetGroup(jid string) (*domain.Group, error) {
+	var g domain.Group
+	var name sql.NullString
+	var updatedAt string
+	err := d.Messages.QueryRow(`
+		SELECT jid, name, participant_count, is_admin, updated_at
+		FROM groups WHERE jid = ?`, jid).Scan(&g.JID, &name, &g.ParticipantCount, &g.IsAdmin, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.Name = name.String
+	g.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &g, nil
+}
+
+// ListGroupChatJIDs returns the JIDs of all stored group chats, most recently active first.
+func (d *DB) ListGroupChatJIDs() ([]string, error) {
+	rows, err := d.Messages.Query(`SELECT jid FROM chats WHERE jid LIKE '%@g.us' ORDER BY last_message_time DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+	return jids, rows.Err()
+}
+
+// CountGroupChats returns the number of stored group chats.
+func (d *DB) CountGroupChats() (int, error) {
+	var n int
+	err := d.Messages.QueryRow(`SELECT COUNT(*) FROM chats WHERE jid LIKE '%@g.us'`).Scan(&n)
+	return n, err
+}
+
+// ListGroupChats returns a page of stored group chats, most recently active first,
+// as groups carrying only the JID and chat name.
+func (d *DB) ListGroupChats(limit, page int) ([]domain.Group, error) {
+	rows, err := d.Messages.Query(`
+		SELECT jid, name FROM chats WHERE jid LIKE '%@g.us'
+		ORDER BY last_message_time DESC LIMIT ? OFFSET ?`, limit, page*limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []domain.Group
+	for rows.Next() {
+		var g domain.Group
+		var name sql.NullString
+		if err := rows.Scan(&g.JID, &name); err != nil {
+			return nil, err
+		}
+		g.Name = name.String
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
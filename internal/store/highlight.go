@@ -0,0 +1,141 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// searchTerm is a single word or phrase extracted from a search query.
+type searchTerm struct {
+	runes  []rune
+	prefix bool
+}
+
+// parseSearchTerms extracts the positive terms from an FTS5-style query,
+// keeping quoted phrases together and dropping operators and exclusions.
+func parseSearchTerms(query string) []searchTerm {
+	var terms []searchTerm
+	add := func(s string, prefix bool) {
+		s = strings.TrimFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if s == "" {
+			return
+		}
+		terms = append(terms, searchTerm{runes: lowerRunes(s), prefix: prefix})
+	}
+
+	for len(query) > 0 {
+		query = strings.TrimLeft(query, " \t\n()")
+		if query == "" {
+			break
+		}
+
+		if query[0] == '"' {
+			end := strings.IndexByte(query[1:], '"')
+			if end < 0 {
+				add(query[1:], false)
+				break
+			}
+			phrase := query[1 : end+1]
+			query = query[end+2:]
+			prefix := strings.HasPrefix(query, "*")
+			add(phrase, prefix)
+			continue
+		}
+
+		end := strings.IndexAny(query, " \t\n()")
+		if end < 0 {
+			end = len(query)
+		}
+		tok := query[:end]
+		query = query[end:]
+
+		switch {
+		case tok == "OR" || tok == "AND" || tok == "NOT":
+			continue
+		case strings.HasPrefix(tok, "-"):
+			continue
+		}
+		if i := strings.IndexByte(tok, ':'); i >= 0 {
+			tok = tok[i+1:]
+		}
+		add(tok, strings.HasSuffix(tok, "*"))
+	}
+
+	return terms
+}
+
+// computeMatchRanges returns the character (rune) offsets of each search term
+// occurrence within content. Overlapping ranges are merged.
+func computeMatchRanges(content string, terms []searchTerm) []domain.MatchRange {
+	if content == "" || len(terms) == 0 {
+		return nil
+	}
+
+	text := lowerRunes(content)
+	var ranges []domain.MatchRange
+
+	for _, term := range terms {
+		n := len(term.runes)
+		for i := 0; i+n <= len(text); i++ {
+			if i > 0 && isWordRune(text[i-1]) {
+				continue
+			}
+			if !runesEqual(text[i:i+n], term.runes) {
+				continue
+			}
+			end := i + n
+			if term.prefix {
+				for end < len(text) && isWordRune(text[end]) {
+					end++
+				}
+			} else if end < len(text) && isWordRune(text[end]) {
+				continue
+			}
+			ranges = append(ranges, domain.MatchRange{Start: i, End: end})
+			i = end - 1
+		}
+	}
+
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(a, b int) bool { return ranges[a].Start < ranges[b].Start })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// lowerRunes lowercases s rune-by-rune so offsets stay aligned with the original.
+func lowerRunes(s string) []rune {
+	r := []rune(s)
+	for i := range r {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return r
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// mediaFilters returns the WHERE conditions and arguments for listing a chat's
+// media messages. Text messages store an empty media_type, so both NULL and the empty string
+// are left out.
+func mediaFilters(opts domain.ListMediaOptions) ([]string, []any) {
+	where := []string{"chat_jid = ?", "media_type IS NOT NULL", "media_type != ''", "COALESCE(is_deleted, 0) = 0"}
+	args := []any{opts.ChatJID}
+	if opts.MediaType != "" {
+		where = append(where, "media_type = ?")
+		args = append(args, opts.MediaType)
+	}
+	if opts.After != "" {
+		where = append(where, "timestamp > "+timeArg)
+		args = append(args, opts.After)
+	}
+	if opts.Before != "" {
+		where = append(where, "timestamp < "+timeArg)
+		args = append(args, opts.Before)
+	}
+	return where, args
+}
+
+// CountMedia returns how many media messages in a chat match the filters.
+func (d *DB) CountMedia(opts domain.ListMediaOptions) (int, error) {
+	where, args := mediaFilters(opts)
+	var count int
+	err := d.Messages.QueryRow("SELECT COUNT(*) FROM messages WHERE "+strings.Join(where, " AND "), args...).Scan(&count)
+	return count, err
+}
+
+// ListMedia lists a chat's media messages, newest first unless opts.Sort is
+// SortAsc. A Limit of 0 returns every match. Filename is the name recorded with
+// the message, before any local renaming on download.
+func (d *DB) ListMedia(opts domain.ListMediaOptions) ([]domain.MediaItem, error) {
+	where, args := mediaFilters(opts)
+	order := "DESC"
+	if opts.Sort == domain.SortAsc {
+		order = "ASC"
+	}
+	q := `SELECT id, chat_jid, sender, is_from_me, timestamp, media_type, COALESCE(filename, ''), COALESCE(mime_type, ''), COALESCE(content, ''), COALESCE(file_length, 0)
+		FROM messages WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY timestamp ` + order + `, rowid ` + order
+	if opts.Limit > 0 {
+		q += " LIMIT ? OFFSET ?"
+		args = append(args, opts.Limit, opts.Page*opts.Limit)
+	}
+
+	rows, err := d.Messages.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.MediaItem{}
+	for rows.Next() {
+		var item domain.MediaItem
+		var sender sql.NullString
+		var ts string
+		if err := rows.Scan(&item.MessageID, &item.ChatJID, &sender, &item.IsFromMe, &ts, &item.MediaType, &item.Filename, &item.MimeType, &item.Caption, &item.SizeBytes); err != nil {
+			return nil, err
+		}
+		item.Sender = sender.String
+		item.Timestamp = parseDBTime(ts)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// ListMentionsFeed returns messages from others that @mention the user or reply to
+// one of their messages, across all chats, newest first, with the total number of
+// matches. after and before are optional bounds.
+func (d *DB) ListMentionsFeed(after, before string, limit, page int) ([]domain.MentionFeedItem, int, error) {
+	where := []string{"m.is_from_me = 0", "(m.mentions_me = 1 OR m.reply_to_me = 1)", "COALESCE(m.is_deleted, 0) = 0"}
+	args := []any{}
+	if after != "" {
+		where = append(where, "m.timestamp > "+timeArg)
+		args = append(args, after)
+	}
+	if before != "" {
+		where = append(where, "m.timestamp < "+timeArg)
+		args = append(args, before)
+	}
+	filter := " WHERE " + strings.Join(where, " AND ")
+
+	var total int
+	if err := d.Messages.QueryRow("SELECT COUNT(*) FROM messages m"+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := d.Messages.Query(`
+		SELECT m.id, m.chat_jid, c.name, m.sender,
+			COALESCE(NULLIF(ct.full_name, ''), NULLIF(ct.business_name, ''), NULLIF(ct.push_name, '')),
+			m.content, m.media_type, m.timestamp, m.mentions_me, m.reply_to_me, m.reply_to_id
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		LEFT JOIN contacts ct ON ct.jid = m.sender || '@s.whatsapp.net'`+filter+`
+		ORDER BY m.timestamp DESC, m.rowid DESC
+		LIMIT ? OFFSET ?`, append(args, limit, page*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []domain.MentionFeedItem{}
+	for rows.Next() {
+		var item domain.MentionFeedItem
+		var chatName, senderName, content, media, replyTo sql.NullString
+		if err := rows.Scan(&item.ID, &item.ChatJID, &chatName, &item.Sender, &senderName, &content, &media, &item.Timestamp, &item.MentionsMe, &item.RepliesToMe, &replyTo); err != nil {
+			return nil, 0, err
+		}
+		if chatName.Valid {
+			item.ChatName = &chatName.String
+		}
+		if senderName.Valid {
+			item.SenderName = &senderName.String
+		}
+		item.Snippet = snippet(content.String, feedSnippetLength)
+		if media.Valid && media.String != "" {
+			item.MediaType = &media.String
+		}
+		if replyTo.Valid && replyTo.String != "" {
+			item.ReplyToID = &replyTo.String
+		}
+		item.IsGroup = strings.HasSuffix(item.ChatJID, "@g.us")
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
+
+// feedSnippetLength caps the message preview in the mentions feed, in characters.
+const feedSnippetLength = 200
+
+// snippet shortens s to at most n characters, marking any cut with an ellipsis.
+func snippet(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// GetProfilePicture returns the last downloaded profile picture for a JID at the
+// given resolution, or nil if none has been downloaded.
+func (d *DB) GetProfilePicture(jid string, preview bool) (*domain.ProfilePicture, error) {
+	pic := domain.ProfilePicture{JID: jid, HasPicture: true, Preview: preview}
+	var fetchedAt time.Time
+	err := d.Messages.QueryRow(`
+		SELECT picture_id, url, path, fetched_at FROM profile_pictures
+		WHERE jid = ? AND preview = ?`, jid, preview).Scan(&pic.PictureID, &pic.URL, &pic.Path, &fetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pic.FetchedAt = &fetchedAt
+	return &pic, nil
+}
+
+// SaveProfilePicture records a downloaded profile picture, replacing any earlier one.
+func (d *DB) SaveProfilePicture(pic domain.ProfilePicture) error {
+	_, err := d.Messages.Exec(`
+		INSERT OR REPLACE INTO profile_pictures (jid, preview, picture_id, url, path, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, pic.JID, pic.Preview, pic.PictureID, pic.URL, pic.Path, pic.FetchedAt)
+	return err
+}
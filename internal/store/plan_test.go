@@ -0,0 +1,128 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// queryPlan returns the EXPLAIN QUERY PLAN details for query, one per line.
+func queryPlan(t *testing.T, d *DB, query string, args ...any) string {
+	t.Helper()
+	rows, err := d.Messages.Query("EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	defer rows.Close()
+
+	var details []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatal(err)
+		}
+		details = append(details, detail)
+	}
+	return strings.Join(details, "\n")
+}
+
+func TestMessageQueriesUseIndexes(t *testing.T) {
+	d := newTestDB(t)
+	const after, before = "2025-03-01T00:00:00Z", "2025-03-02T00:00:00Z"
+
+	tests := []struct {
+		name  string
+		query func() (string, []any)
+		want  string
+	}{
+		{
+			name: "list messages in a timeframe",
+			query: func() (string, []any) {
+				return messagesQuery(domain.ListMessagesOptions{After: after, Before: before, IncludeSystem: true})
+			},
+			want: "USING INDEX idx_messages_timestamp (timestamp>? AND timestamp<?)",
+		},
+		{
+			name: "list a chat's messages in a timeframe",
+			query: func() (string, []any) {
+				return messagesQuery(domain.ListMessagesOptions{ChatJID: "447700900001@s.whatsapp.net", After: after, IncludeSystem: true})
+			},
+			want: "USING INDEX idx_messages_chat_timestamp (chat_jid=? AND timestamp>?)",
+		},
+		{
+			name: "list a sender's messages",
+			query: func() (string, []any) {
+				return messagesQuery(domain.ListMessagesOptions{Sender: "447700900001", IncludeSystem: true})
+			},
+			want: "USING INDEX idx_messages_sender (sender=?)",
+		},
+		{
+			name: "active chats",
+			query: func() (string, []any) {
+				return activeChatsQuery(after, before, false, false, 10)
+			},
+			want: "USING INDEX idx_messages_timestamp (timestamp>? AND timestamp<?)",
+		},
+		{
+			name: "active groups including muted",
+			query: func() (string, []any) {
+				return activeChatsQuery(after, before, true, true, 10)
+			},
+			want: "USING INDEX idx_messages_timestamp (timestamp>? AND timestamp<?)",
+		},
+		{
+			name: "catch-up message count",
+			query: func() (string, []any) {
+				return messagesBetweenQuery, []any{after, before}
+			},
+			want: "USING COVERING INDEX idx_messages_timestamp (timestamp>? AND timestamp<?)",
+		},
+		{
+			name: "substring search in a timeframe",
+			query: func() (string, []any) {
+				opts := domain.SearchMessagesOptions{Query: "lunch", After: after, Before: before, Limit: 20}
+				where, args := searchFilters(opts)
+				return searchLikeQuery(opts, where, args)
+			},
+			want: "USING INDEX idx_messages_timestamp (timestamp>? AND timestamp<?)",
+		},
+		{
+			name: "substring search in a chat",
+			query: func() (string, []any) {
+				opts := domain.SearchMessagesOptions{Query: "lunch", ChatJID: "447700900001@s.whatsapp.net", After: after, Limit: 20}
+				where, args := searchFilters(opts)
+				return searchLikeQuery(opts, where, args)
+			},
+			want: "USING INDEX idx_messages_chat_timestamp (chat_jid=? AND timestamp>?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.query()
+			plan := queryPlan(t, d, query, args...)
+			if !strings.Contains(plan, tt.want) {
+				t.Errorf("plan does not use %q:\n%s", tt.want, plan)
+			}
+		})
+	}
+}
+
+func TestFTSSearchUsesRowidJoin(t *testing.T) {
+	d := newTestDB(t)
+	if !d.FTS {
+		t.Skip("SQLite FTS5 is not available in this build")
+	}
+
+	opts := domain.SearchMessagesOptions{Query: "lunch", After: "2025-03-01T00:00:00Z", Limit: 20}
+	where, args := searchFilters(opts)
+	query, args := searchFTSQuery(opts, where, args)
+	plan := queryPlan(t, d, query, args...)
+	for _, want := range []string{"VIRTUAL TABLE INDEX", "m USING INTEGER PRIMARY KEY (rowid=?)"} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("plan does not use %q:\n%s", want, plan)
+		}
+	}
+}
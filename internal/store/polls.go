@@ -0,0 +1,129 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// SavePoll records a poll's question and options, keyed by its creation message.
+func (d *DB) SavePoll(p domain.Poll) error {
+	options, err := json.Marshal(p.Options)
+	if err != nil {
+		return err
+	}
+	_, err = d.Messages.Exec(`
+		INSERT OR REPLACE INTO polls (chat_jid, message_id, sender, question, options, allow_multiple, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		p.ChatJID, p.MessageID, p.Sender, p.Question, string(options), p.AllowMultiple, p.CreatedAt,
+	)
+	return err
+}
+
+// GetPoll returns the poll created by a message, or nil if it isn't stored.
+func (d *DB) GetPoll(chatJID, messageID string) (*domain.Poll, error) {
+	var p domain.Poll
+	var options string
+	err := d.Messages.QueryRow(`
+		SELECT chat_jid, message_id, sender, question, options, allow_multiple, created_at
+		FROM polls WHERE chat_jid = ? AND message_id = ?`,
+		chatJID, messageID,
+	).Scan(&p.ChatJID, &p.MessageID, &p.Sender, &p.Question, &options, &p.AllowMultiple, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(options), &p.Options); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ReplacePollVotes sets a voter's current selections in a poll, replacing any
+// earlier vote. An empty selection retracts the vote. Votes older than the
+// stored one (e.g. replayed by history sync) are ignored.
+func (d *DB) ReplacePollVotes(chatJID, pollMessageID, voter string, options []string, t time.Time) error {
+	tx, err := d.Messages.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var newer int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM poll_votes
+		WHERE chat_jid = ? AND poll_message_id = ? AND voter = ? AND datetime(voted_at) > datetime(?)`,
+		chatJID, pollMessageID, voter, t.UTC().Format(time.RFC3339),
+	).Scan(&newer); err != nil {
+		return err
+	}
+	if newer > 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM poll_votes WHERE chat_jid = ? AND poll_message_id = ? AND voter = ?`,
+		chatJID, pollMessageID, voter); err != nil {
+		return err
+	}
+	for _, option := range options {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO poll_votes (chat_jid, poll_message_id, voter, option_name, voted_at) VALUES (?, ?, ?, ?, ?)`,
+			chatJID, pollMessageID, voter, option, t); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPollResults tallies the votes for a poll, or returns nil if the poll isn't stored.
+func (d *DB) GetPollResults(chatJID, messageID string) (*domain.PollResults, error) {
+	poll, err := d.GetPoll(chatJID, messageID)
+	if err != nil || poll == nil {
+		return nil, err
+	}
+
+	results := &domain.PollResults{Poll: *poll, Results: make([]domain.PollOptionResult, len(poll.Options))}
+	index := make(map[string]int, len(poll.Options))
+	for i, option := range poll.Options {
+		results.Results[i] = domain.PollOptionResult{Option: option, Voters: []domain.PollVoter{}}
+		index[option] = i
+	}
+
+	rows, err := d.Messages.Query(`
+		SELECT v.option_name, v.voter, COALESCE(c.full_name, c.business_name, c.push_name)
+		FROM poll_votes v
+		LEFT JOIN contacts c ON c.jid = v.voter || '@s.whatsapp.net'
+		WHERE v.chat_jid = ? AND v.poll_message_id = ?
+		ORDER BY v.voted_at`, chatJID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	voters := map[string]bool{}
+	for rows.Next() {
+		var option string
+		var voter domain.PollVoter
+		var name sql.NullString
+		if err := rows.Scan(&option, &voter.Sender, &name); err != nil {
+			return nil, err
+		}
+		i, ok := index[option]
+		if !ok {
+			continue
+		}
+		if name.Valid && name.String != "" {
+			voter.Name = &name.String
+		}
+		results.Results[i].Votes++
+		results.Results[i].Voters = append(results.Results[i].Voters, voter)
+		voters[voter.Sender] = true
+	}
+	results.TotalVoters = len(voters)
+
+	return results, rows.Err()
+}
@@ -2,20 +2,19 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/eddmann/whatsapp-mcp/internal/domain"
 )
 
-// CountChats returns the total number of chats matching the query.
-func (d *DB) CountChats(query string) (int, error) {
+// CountChats returns the total number of chats matching the list filters.
+func (d *DB) CountChats(opts domain.ListChatsOptions) (int, error) {
 	q := "SELECT COUNT(*) FROM chats"
-	args := []any{}
-
-	if query != "" {
-		q += " WHERE (LOWER(name) LIKE LOWER(?) OR jid LIKE ?)"
-		args = append(args, "%"+query+"%", "%"+query+"%")
+	where, args := chatFilters(opts)
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
 	}
 
 	var count int
@@ -23,6 +22,37 @@ func (d *DB) CountChats(query string) (int, error) {
 	return count, err
 }
 
+// notMutedSQL returns a WHERE fragment matching chats that aren't currently muted.
+func notMutedSQL(alias string) string {
+	return fmt.Sprintf("(%[1]s.muted_until IS NULL OR datetime(%[1]s.muted_until) <= datetime('now'))", alias)
+}
+
+// chatFilters returns the WHERE conditions and arguments for the chat list filters.
+// Archived chats are left out unless requested.
+func chatFilters(opts domain.ListChatsOptions) ([]string, []any) {
+	where := []string{}
+	args := []any{}
+
+	if opts.Query != "" {
+		where = append(where, "(LOWER(chats.name) LIKE LOWER(?) OR chats.jid LIKE ?)")
+		args = append(args, "%"+opts.Query+"%", "%"+opts.Query+"%")
+	}
+	if opts.OnlyGroups {
+		where = append(where, "chats.jid LIKE '%@g.us'")
+	}
+	if opts.PinnedOnly {
+		where = append(where, "chats.pinned = 1")
+	}
+	if !opts.IncludeArchived {
+		where = append(where, "COALESCE(chats.archived, 0) = 0")
+	}
+	if opts.ExcludeMuted {
+		where = append(where, notMutedSQL("chats"))
+	}
+
+	return where, args
+}
+
 // ListChats returns chats with filtering and pagination.
 // Always sorted by last activity and includes last message preview.
 func (d *DB) ListChats(opts domain.ListChatsOptions) ([]domain.Chat, error) {
@@ -33,33 +63,34 @@ func (d *DB) ListChats(opts domain.ListChatsOptions) ([]domain.Chat, error) {
 		opts.Page = 0
 	}
 
+	// The preview is picked by rowid rather than matched on last_message_time, so
+	// messages sharing a timestamp can't duplicate a chat row
 	q := `SELECT
 		chats.jid,
 		chats.name,
 		chats.last_message_time,
 		m.content AS last_message,
 		m.sender AS last_sender,
-		m.is_from_me AS last_is_from_me
+		m.is_from_me AS last_is_from_me,
+		COALESCE(chats.archived, 0),
+		COALESCE(chats.pinned, 0),
+		CAST(chats.muted_until AS TEXT)
 	FROM chats
-	LEFT JOIN messages m ON chats.jid = m.chat_jid AND chats.last_message_time = m.timestamp`
-
-	where := []string{}
-	args := []any{}
-
-	if opts.Query != "" {
-		where = append(where, "(LOWER(chats.name) LIKE LOWER(?) OR chats.jid LIKE ?)")
-		args = append(args, "%"+opts.Query+"%", "%"+opts.Query+"%")
-	}
-
-	if opts.OnlyGroups {
-		where = append(where, "chats.jid LIKE '%@g.us'")
-	}
+	LEFT JOIN messages m ON m.rowid = (
+		SELECT rowid FROM messages WHERE chat_jid = chats.jid ORDER BY timestamp DESC, rowid DESC LIMIT 1
+	)`
 
+	where, args := chatFilters(opts)
 	if len(where) > 0 {
 		q += " WHERE " + strings.Join(where, " AND ")
 	}
 
-	q += " ORDER BY chats.last_message_time DESC LIMIT ? OFFSET ?"
+	if opts.Sort == domain.ChatSortName {
+		// Unnamed chats go last rather than sorting before "A"
+		q += " ORDER BY COALESCE(chats.name, '') = '', chats.name COLLATE NOCASE, chats.last_message_time DESC LIMIT ? OFFSET ?"
+	} else {
+		q += " ORDER BY chats.last_message_time DESC LIMIT ? OFFSET ?"
+	}
 	args = append(args, opts.Limit, opts.Page*opts.Limit)
 
 	rows, err := d.Messages.Query(q, args...)
@@ -74,10 +105,14 @@ func (d *DB) ListChats(opts domain.ListChatsOptions) ([]domain.Chat, error) {
 		var name, ts sql.NullString
 		var lastMsg, lastSender sql.NullString
 		var lastFromMe sql.NullBool
+		var mutedUntil sql.NullString
 
-		if err := rows.Scan(&chat.JID, &name, &ts, &lastMsg, &lastSender, &lastFromMe); err != nil {
+		if err := rows.Scan(&chat.JID, &name, &ts, &lastMsg, &lastSender, &lastFromMe, &chat.Archived, &chat.Pinned, &mutedUntil); err != nil {
 			return nil, err
 		}
+		if mutedUntil.Valid {
+			chat.SetMuted(parseDBTime(mutedUntil.String))
+		}
 
 		if lastMsg.Valid {
 			chat.LastMessage = &lastMsg.String
@@ -108,14 +143,16 @@ func (d *DB) ListChats(opts domain.ListChatsOptions) ([]domain.Chat, error) {
 
 // GetChat retrieves a single chat by JID.
 func (d *DB) GetChat(chatJID string, includeLast bool) (*domain.Chat, error) {
-	row := d.Messages.QueryRow(`SELECT c.jid, c.name, c.last_message_time FROM chats c WHERE c.jid = ?`, chatJID)
-	var jid string
-	var name, ts sql.NullString
-	if err := row.Scan(&jid, &name, &ts); err != nil {
+	row := d.Messages.QueryRow(`SELECT c.jid, c.name, c.last_message_time, COALESCE(c.archived, 0), COALESCE(c.pinned, 0), CAST(c.muted_until AS TEXT) FROM chats c WHERE c.jid = ?`, chatJID)
+	chat := &domain.Chat{}
+	var name, ts, mutedUntil sql.NullString
+	if err := row.Scan(&chat.JID, &name, &ts, &chat.Archived, &chat.Pinned, &mutedUntil); err != nil {
 		return nil, err
 	}
+	if mutedUntil.Valid {
+		chat.SetMuted(parseDBTime(mutedUntil.String))
+	}
 
-	chat := &domain.Chat{JID: jid}
 	if name.Valid {
 		chat.Name = &name.String
 	}
@@ -127,7 +164,7 @@ func (d *DB) GetChat(chatJID string, includeLast bool) (*domain.Chat, error) {
 	chat.IsGroup = strings.HasSuffix(chat.JID, "@g.us")
 
 	if includeLast {
-		r := d.Messages.QueryRow(`SELECT content, sender, is_from_me FROM messages WHERE chat_jid = ? ORDER BY timestamp DESC LIMIT 1`, chatJID)
+		r := d.Messages.QueryRow(`SELECT content, sender, is_from_me FROM messages WHERE chat_jid = ? ORDER BY timestamp DESC, rowid DESC LIMIT 1`, chatJID)
 		var content, sender sql.NullString
 		var isFromMe sql.NullBool
 		_ = r.Scan(&content, &sender, &isFromMe)
@@ -145,29 +182,141 @@ func (d *DB) GetChat(chatJID string, includeLast bool) (*domain.Chat, error) {
 	return chat, nil
 }
 
-// ListMessages lists messages with filters and pagination.
-func (d *DB) ListMessages(opts domain.ListMessagesOptions) ([]domain.Message, error) {
-	parts := []string{"SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type FROM messages JOIN chats ON messages.chat_jid = chats.jid"}
+// CountMessages returns the total number of messages matching the list filters.
+func (d *DB) CountMessages(opts domain.ListMessagesOptions) (int, error) {
+	q := "SELECT COUNT(*) FROM messages"
+	where, args := messageFilters(opts)
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var count int
+	err := d.Messages.QueryRow(q, args...).Scan(&count)
+	return count, err
+}
+
+// LastMessageTime returns the timestamp of the newest stored message, or the zero
+// time if there are none.
+func (d *DB) LastMessageTime() (time.Time, error) {
+	var last sql.NullString
+	if err := d.Messages.QueryRow("SELECT MAX(timestamp) FROM messages").Scan(&last); err != nil || !last.Valid {
+		return time.Time{}, err
+	}
+	return parseDBTime(last.String), nil
+}
+
+// messageFilters returns the WHERE conditions and arguments for the message list
+// filters. System messages are left out unless requested.
+func messageFilters(opts domain.ListMessagesOptions) ([]string, []any) {
 	where := []string{}
 	args := []any{}
 
 	if opts.After != "" {
-		where = append(where, "datetime(messages.timestamp) > datetime(?)")
+		where = append(where, "messages.timestamp > "+timeArg)
 		args = append(args, opts.After)
 	}
 	if opts.Before != "" {
-		where = append(where, "datetime(messages.timestamp) < datetime(?)")
+		where = append(where, "messages.timestamp < "+timeArg)
 		args = append(args, opts.Before)
 	}
 	if opts.ChatJID != "" {
 		where = append(where, "messages.chat_jid = ?")
 		args = append(args, opts.ChatJID)
 	}
+	if opts.Sender != "" {
+		where = append(where, "messages.sender = ?")
+		args = append(args, opts.Sender)
+	}
+	if opts.MediaType != "" {
+		where = append(where, "messages.media_type = ?")
+		args = append(args, opts.MediaType)
+	}
+	switch opts.Direction {
+	case domain.DirectionIncoming:
+		where = append(where, "messages.is_from_me = ?")
+		args = append(args, false)
+	case domain.DirectionOutgoing:
+		where = append(where, "messages.is_from_me = ?")
+		args = append(args, true)
+	}
+	if !opts.IncludeSystem {
+		where = append(where, excludeSystemSQL("messages"))
+		args = append(args, excludeSystemArgs()...)
+	}
+
+	return where, args
+}
+
+// messagesQuery builds the filtered and ordered message SELECT shared by
+// ListMessages and EachMessage, without any paging.
+func messagesQuery(opts domain.ListMessagesOptions) (string, []any) {
+	parts := []string{"SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at FROM messages JOIN chats ON messages.chat_jid = chats.jid"}
+	where, args := messageFilters(opts)
 
 	if len(where) > 0 {
 		parts = append(parts, "WHERE "+strings.Join(where, " AND "))
 	}
 
+	// Timestamps only have second resolution, so rowid breaks ties and keeps
+	// pages stable when several messages share a second
+	if opts.Sort == domain.SortAsc {
+		parts = append(parts, "ORDER BY messages.timestamp ASC, messages.rowid ASC")
+	} else {
+		parts = append(parts, "ORDER BY messages.timestamp DESC, messages.rowid DESC")
+	}
+
+	return strings.Join(parts, " "), args
+}
+
+// eachMessageBatch is how many rows EachMessage reads before attaching their reactions.
+const eachMessageBatch = 500
+
+// EachMessage calls fn for every message matching opts, in opts.Sort order, ignoring
+// Limit and Page. Rows come from a single cursor, so unlike paging with ListMessages
+// none are skipped or repeated when messages share a timestamp or arrive mid-read.
+// Iteration stops at the first error from fn, which is returned.
+func (d *DB) EachMessage(opts domain.ListMessagesOptions, fn func(domain.Message) error) error {
+	query, args := messagesQuery(opts)
+	rows, err := d.Messages.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]domain.Message, 0, eachMessageBatch)
+	flush := func() error {
+		if err := d.attachReactions(batch); err != nil {
+			return err
+		}
+		for _, m := range batch {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, msg)
+		if len(batch) == eachMessageBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// ListMessages lists messages with filters and pagination.
+func (d *DB) ListMessages(opts domain.ListMessagesOptions) ([]domain.Message, error) {
 	if opts.Limit <= 0 {
 		opts.Limit = 20
 	}
@@ -175,10 +324,11 @@ func (d *DB) ListMessages(opts domain.ListMessagesOptions) ([]domain.Message, er
 		opts.Page = 0
 	}
 
-	parts = append(parts, "ORDER BY messages.timestamp DESC", "LIMIT ? OFFSET ?")
+	query, args := messagesQuery(opts)
+	query += " LIMIT ? OFFSET ?"
 	args = append(args, opts.Limit, opts.Page*opts.Limit)
 
-	rows, err := d.Messages.Query(strings.Join(parts, " "), args...)
+	rows, err := d.Messages.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -193,85 +343,238 @@ func (d *DB) ListMessages(opts domain.ListMessagesOptions) ([]domain.Message, er
 		messages = append(messages, msg)
 	}
 
+	if err := d.attachReactions(messages); err != nil {
+		return nil, err
+	}
+
 	return messages, nil
 }
 
-// SearchMessages performs full-text search on message content.
-func (d *DB) SearchMessages(opts domain.SearchMessagesOptions) ([]domain.Message, error) {
-	if opts.Limit <= 0 {
-		opts.Limit = 20
+// GetLastMessageFromSender returns the most recent message a sender posted in a chat,
+// or nil if they have never posted there.
+func (d *DB) GetLastMessageFromSender(chatJID, sender string) (*domain.Message, error) {
+	row := d.Messages.QueryRow(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE messages.chat_jid = ? AND messages.sender = ?
+		ORDER BY messages.timestamp DESC, messages.rowid DESC LIMIT 1`, chatJID, sender)
+
+	msg, err := scanMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	if opts.Page < 0 {
-		opts.Page = 0
+	if err != nil {
+		return nil, err
 	}
+	return &msg, nil
+}
 
-	dateWhere := []string{}
-	dateArgs := []any{}
-	if opts.After != "" {
-		dateWhere = append(dateWhere, "datetime(m.timestamp) > datetime(?)")
-		dateArgs = append(dateArgs, opts.After)
+// ListChatSenders returns the distinct senders in a chat with message counts,
+// most active first. Names are resolved from the contacts table in the same query.
+func (d *DB) ListChatSenders(chatJID string) ([]domain.ChatSender, error) {
+	rows, err := d.Messages.Query(`
+		SELECT m.sender, COALESCE(c.full_name, c.business_name, c.push_name), COALESCE(MAX(m.is_from_me), 0), COUNT(*), MAX(m.timestamp)
+		FROM messages m
+		LEFT JOIN contacts c ON c.jid = m.sender || '@s.whatsapp.net'
+		WHERE m.chat_jid = ? AND m.sender != ''
+		GROUP BY m.sender
+		ORDER BY COUNT(*) DESC`, chatJID)
+	if err != nil {
+		return nil, err
 	}
-	if opts.Before != "" {
-		dateWhere = append(dateWhere, "datetime(m.timestamp) < datetime(?)")
-		dateArgs = append(dateArgs, opts.Before)
+	defer rows.Close()
+
+	senders := []domain.ChatSender{}
+	for rows.Next() {
+		var s domain.ChatSender
+		var name sql.NullString
+		var ts string
+		if err := rows.Scan(&s.Sender, &name, &s.IsFromMe, &s.MessageCount, &ts); err != nil {
+			return nil, err
+		}
+		if name.Valid && name.String != "" {
+			s.Name = &name.String
+		}
+		s.LastMessageTime = parseDBTime(ts)
+		senders = append(senders, s)
 	}
 
-	ftsQuery := `
-		SELECT m.timestamp, m.sender, c.name, m.content, m.is_from_me, m.chat_jid, m.id, m.media_type
-		FROM messages_fts f
-		JOIN messages m ON m.rowid = f.rowid
-		JOIN chats c ON m.chat_jid = c.jid
-		WHERE messages_fts MATCH ?`
+	return senders, rows.Err()
+}
 
-	ftsArgs := []any{opts.Query}
-	if len(dateWhere) > 0 {
-		ftsQuery += " AND " + strings.Join(dateWhere, " AND ")
-		ftsArgs = append(ftsArgs, dateArgs...)
+// FindGroupSenders returns distinct people who have posted in any group and whose
+// number or cached name (including push names) contains query, most recently
+// active first. It finds group members who have no saved contact or direct chat.
+// Senders in LID-addressed groups are stored by LID, so names are looked up under
+// both the phone-number and LID forms of the sender.
+func (d *DB) FindGroupSenders(query string, limit int) ([]domain.ChatSender, error) {
+	pattern := "%" + strings.ToLower(strings.TrimPrefix(strings.TrimSpace(query), "+")) + "%"
+	rows, err := d.Messages.Query(`
+		SELECT m.sender,
+			COALESCE(pn.full_name, lid.full_name, pn.business_name, lid.business_name, pn.push_name, lid.push_name),
+			COUNT(*), MAX(m.timestamp)
+		FROM messages m
+		LEFT JOIN contacts pn ON pn.jid = m.sender || '@s.whatsapp.net'
+		LEFT JOIN contacts lid ON lid.jid = m.sender || '@lid'
+		WHERE m.chat_jid LIKE '%@g.us' AND m.is_from_me = 0 AND m.sender != ''
+			AND (m.sender LIKE ?
+				OR LOWER(pn.full_name) LIKE ? OR LOWER(pn.business_name) LIKE ? OR LOWER(pn.push_name) LIKE ?
+				OR LOWER(lid.full_name) LIKE ? OR LOWER(lid.business_name) LIKE ? OR LOWER(lid.push_name) LIKE ?)
+		GROUP BY m.sender
+		ORDER BY MAX(m.timestamp) DESC
+		LIMIT ?`, pattern, pattern, pattern, pattern, pattern, pattern, pattern, limit)
+	if err != nil {
+		return nil, err
 	}
-	ftsQuery += " ORDER BY m.timestamp DESC LIMIT ? OFFSET ?"
-	ftsArgs = append(ftsArgs, opts.Limit, opts.Page*opts.Limit)
+	defer rows.Close()
 
-	rows, err := d.Messages.Query(ftsQuery, ftsArgs...)
+	senders := []domain.ChatSender{}
+	for rows.Next() {
+		var s domain.ChatSender
+		var name sql.NullString
+		var ts string
+		if err := rows.Scan(&s.Sender, &name, &s.MessageCount, &ts); err != nil {
+			return nil, err
+		}
+		if name.Valid && name.String != "" {
+			s.Name = &name.String
+		}
+		s.LastMessageTime = parseDBTime(ts)
+		senders = append(senders, s)
+	}
+
+	return senders, rows.Err()
+}
 
+// GetLatestMessage returns the most recent message in a chat, ignoring system
+// messages and reactions, or nil if none.
+func (d *DB) GetLatestMessage(chatJID string) (*domain.Message, error) {
+	args := append([]any{chatJID}, excludeSystemArgs()...)
+	row := d.Messages.QueryRow(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE messages.chat_jid = ? AND `+excludeSystemSQL("messages")+`
+		ORDER BY messages.timestamp DESC, messages.rowid DESC LIMIT 1`, args...)
+
+	msg, err := scanMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		likeQuery := `
-			SELECT m.timestamp, m.sender, c.name, m.content, m.is_from_me, m.chat_jid, m.id, m.media_type
-			FROM messages m JOIN chats c ON m.chat_jid = c.jid
-			WHERE LOWER(m.content) LIKE LOWER(?)`
+		return nil, err
+	}
+	return &msg, nil
+}
 
-		likeArgs := []any{"%" + opts.Query + "%"}
-		if len(dateWhere) > 0 {
-			likeQuery += " AND " + strings.Join(dateWhere, " AND ")
-			likeArgs = append(likeArgs, dateArgs...)
-		}
-		likeQuery += " ORDER BY m.timestamp DESC LIMIT ? OFFSET ?"
-		likeArgs = append(likeArgs, opts.Limit, opts.Page*opts.Limit)
+// GetLatestIncomingMessage returns the most recent message in a chat that was not
+// sent by the logged-in user, ignoring system messages and reactions, or nil if none.
+func (d *DB) GetLatestIncomingMessage(chatJID string) (*domain.Message, error) {
+	args := append([]any{chatJID}, excludeSystemArgs()...)
+	row := d.Messages.QueryRow(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE messages.chat_jid = ? AND messages.is_from_me = 0 AND `+excludeSystemSQL("messages")+`
+		ORDER BY messages.timestamp DESC, messages.rowid DESC LIMIT 1`, args...)
+
+	msg, err := scanMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
 
-		rows, err = d.Messages.Query(likeQuery, likeArgs...)
-		if err != nil {
-			return nil, err
-		}
+// GetMessage returns a single message by ID within a chat, or nil if it isn't stored.
+func (d *DB) GetMessage(chatJID, messageID string) (*domain.Message, error) {
+	row := d.Messages.QueryRow(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE messages.chat_jid = ? AND messages.id = ?`, chatJID, messageID)
+
+	msg, err := scanMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	defer rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// MarkMessageDeleted flags a message as deleted for everyone, clearing its content
+// and media download fields. The messages_au trigger drops the old content from
+// messages_fts. Returns false if the message isn't stored.
+func (d *DB) MarkMessageDeleted(chatJID, messageID string) (bool, error) {
+	res, err := d.Messages.Exec(`
+		UPDATE messages SET is_deleted = 1, content = NULL, url = '', media_key = NULL, file_sha256 = NULL, file_enc_sha256 = NULL
+		WHERE chat_jid = ? AND id = ?`, chatJID, messageID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SearchMessages performs full-text search on message content, returning each match
+// followed by its surrounding messages. When opts.MaxRows is set, expansion stops
+// once that many rows are collected and the bool result reports the truncation.
+func (d *DB) SearchMessages(opts domain.SearchMessagesOptions) ([]domain.Message, bool, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	if opts.Page < 0 {
+		opts.Page = 0
+	}
+
+	where, whereArgs := searchFilters(opts)
 
 	var messages []domain.Message
-	for rows.Next() {
-		msg, err := scanMessage(rows)
-		if err != nil {
-			return nil, err
+	var err error
+	if d.FTS {
+		messages, err = d.searchFTS(opts, where, whereArgs)
+		// Queries that aren't valid FTS5 syntax (e.g. unbalanced quotes) still get substring matching
+		if err != nil && isFTSSyntaxError(err) {
+			messages, err = d.searchLike(opts, where, whereArgs)
+		}
+	} else {
+		messages, err = d.searchLike(opts, where, whereArgs)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if opts.IncludeMatchRanges {
+		terms := parseSearchTerms(opts.Query)
+		for i := range messages {
+			if messages[i].Content != nil {
+				messages[i].MatchRanges = computeMatchRanges(*messages[i].Content, terms)
+			}
 		}
-		messages = append(messages, msg)
 	}
 
+	truncated := false
 	if len(messages) > 0 {
 		const contextSize = 2
+		contextFilter, contextFilterArgs := "", []any{}
+		if !opts.IncludeSystem {
+			contextFilter = " AND " + excludeSystemSQL("messages")
+			contextFilterArgs = excludeSystemArgs()
+		}
 		expanded := make([]domain.Message, 0, len(messages)*(1+2*contextSize))
+		full := func() bool { return opts.MaxRows > 0 && len(expanded) >= opts.MaxRows }
 		for _, base := range messages {
+			if full() {
+				truncated = true
+				break
+			}
 			expanded = append(expanded, base)
 
-			beforeRows, err := d.Messages.Query(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type FROM messages JOIN chats ON messages.chat_jid = chats.jid WHERE messages.chat_jid = ? AND datetime(messages.timestamp) < datetime(?) ORDER BY messages.timestamp DESC LIMIT ?`, base.ChatJID, base.Timestamp.Format(time.RFC3339), contextSize)
+			beforeArgs := append([]any{base.ChatJID, base.Timestamp.Format(time.RFC3339)}, contextFilterArgs...)
+			beforeRows, err := d.Messages.Query(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at FROM messages JOIN chats ON messages.chat_jid = chats.jid WHERE messages.chat_jid = ? AND messages.timestamp < `+timeArg+contextFilter+` ORDER BY messages.timestamp DESC, messages.rowid DESC LIMIT ?`, append(beforeArgs, contextSize)...)
 			if err == nil {
 				for beforeRows.Next() {
+					if full() {
+						truncated = true
+						break
+					}
 					msg, err := scanMessage(beforeRows)
 					if err == nil {
 						expanded = append(expanded, msg)
@@ -280,9 +583,14 @@ func (d *DB) SearchMessages(opts domain.SearchMessagesOptions) ([]domain.Message
 				beforeRows.Close()
 			}
 
-			afterRows, err := d.Messages.Query(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type FROM messages JOIN chats ON messages.chat_jid = chats.jid WHERE messages.chat_jid = ? AND datetime(messages.timestamp) > datetime(?) ORDER BY messages.timestamp ASC LIMIT ?`, base.ChatJID, base.Timestamp.Format(time.RFC3339), contextSize)
+			afterArgs := append([]any{base.ChatJID, base.Timestamp.Format(time.RFC3339)}, contextFilterArgs...)
+			afterRows, err := d.Messages.Query(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at FROM messages JOIN chats ON messages.chat_jid = chats.jid WHERE messages.chat_jid = ? AND messages.timestamp > `+timeArg+contextFilter+` ORDER BY messages.timestamp ASC, messages.rowid ASC LIMIT ?`, append(afterArgs, contextSize)...)
 			if err == nil {
 				for afterRows.Next() {
+					if full() {
+						truncated = true
+						break
+					}
 					msg, err := scanMessage(afterRows)
 					if err == nil {
 						expanded = append(expanded, msg)
@@ -294,7 +602,243 @@ func (d *DB) SearchMessages(opts domain.SearchMessagesOptions) ([]domain.Message
 		messages = expanded
 	}
 
-	return messages, nil
+	if err := d.attachReactions(messages); err != nil {
+		return nil, false, err
+	}
+
+	return messages, truncated, nil
+}
+
+// CountSearchMessages returns the total number of messages matching a search,
+// before context expansion, using the same matching as SearchMessages.
+func (d *DB) CountSearchMessages(opts domain.SearchMessagesOptions) (int, error) {
+	where, args := searchFilters(opts)
+	extra := ""
+	if len(where) > 0 {
+		extra = " AND " + strings.Join(where, " AND ")
+	}
+
+	var count int
+	if d.FTS {
+		err := d.Messages.QueryRow(`SELECT COUNT(*) FROM messages_fts f JOIN messages m ON m.rowid = f.rowid WHERE messages_fts MATCH ?`+extra,
+			append([]any{sanitizeFTSQuery(opts.Query)}, args...)...).Scan(&count)
+		if err == nil || !isFTSSyntaxError(err) {
+			return count, err
+		}
+	}
+	err := d.Messages.QueryRow(`SELECT COUNT(*) FROM messages m WHERE LOWER(m.content) LIKE LOWER(?)`+extra,
+		append([]any{"%" + opts.Query + "%"}, args...)...).Scan(&count)
+	return count, err
+}
+
+// searchFilters returns the WHERE conditions and arguments for the search
+// filters, against the messages table aliased as m.
+func searchFilters(opts domain.SearchMessagesOptions) ([]string, []any) {
+	where := []string{}
+	args := []any{}
+	if opts.After != "" {
+		where = append(where, "m.timestamp > "+timeArg)
+		args = append(args, opts.After)
+	}
+	if opts.Before != "" {
+		where = append(where, "m.timestamp < "+timeArg)
+		args = append(args, opts.Before)
+	}
+	if opts.ChatJID != "" {
+		where = append(where, "m.chat_jid = ?")
+		args = append(args, opts.ChatJID)
+	}
+	if !opts.IncludeSystem {
+		where = append(where, excludeSystemSQL("m"))
+		args = append(args, excludeSystemArgs()...)
+	}
+
+	return where, args
+}
+
+// searchFTS runs a search query as an FTS5 MATCH against messages_fts, ordered
+// newest first or, for SearchSortRelevance, by bm25 rank. Each match carries its
+// bm25 score.
+func (d *DB) searchFTS(opts domain.SearchMessagesOptions, where []string, whereArgs []any) ([]domain.Message, error) {
+	query, args := searchFTSQuery(opts, where, whereArgs)
+	rows, err := d.Messages.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []domain.Message
+	for rows.Next() {
+		var score float64
+		msg, err := scanMessage(scoredRow{rows, &score})
+		if err != nil {
+			return nil, err
+		}
+		msg.Score = &score
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// searchFTSQuery builds the searchFTS query for one page of matches.
+func searchFTSQuery(opts domain.SearchMessagesOptions, where []string, whereArgs []any) (string, []any) {
+	query := `
+		SELECT m.timestamp, m.sender, c.name, m.content, m.is_from_me, m.chat_jid, m.id, m.media_type, m.is_deleted, m.edited_at, bm25(messages_fts)
+		FROM messages_fts f
+		JOIN messages m ON m.rowid = f.rowid
+		JOIN chats c ON m.chat_jid = c.jid
+		WHERE messages_fts MATCH ?`
+
+	args := []any{sanitizeFTSQuery(opts.Query)}
+	if len(where) > 0 {
+		query += " AND " + strings.Join(where, " AND ")
+		args = append(args, whereArgs...)
+	}
+	if opts.Sort == domain.SearchSortRelevance {
+		query += " ORDER BY bm25(messages_fts), m.timestamp DESC, m.rowid DESC LIMIT ? OFFSET ?"
+	} else {
+		query += " ORDER BY m.timestamp DESC, m.rowid DESC LIMIT ? OFFSET ?"
+	}
+	return query, append(args, opts.Limit, opts.Page*opts.Limit)
+}
+
+// scoredRow scans the scanMessage columns followed by a search score column.
+type scoredRow struct {
+	rows  *sql.Rows
+	score *float64
+}
+
+func (r scoredRow) Scan(dest ...any) error {
+	return r.rows.Scan(append(dest, r.score)...)
+}
+
+// searchLike runs a search query as a case-insensitive substring match, used when
+// FTS5 is unavailable. Substring matches have no rank, so results are always newest first.
+func (d *DB) searchLike(opts domain.SearchMessagesOptions, where []string, whereArgs []any) ([]domain.Message, error) {
+	query, args := searchLikeQuery(opts, where, whereArgs)
+	return d.queryMessages(query, args...)
+}
+
+// searchLikeQuery builds the searchLike query for one page of matches.
+func searchLikeQuery(opts domain.SearchMessagesOptions, where []string, whereArgs []any) (string, []any) {
+	query := `
+		SELECT m.timestamp, m.sender, c.name, m.content, m.is_from_me, m.chat_jid, m.id, m.media_type, m.is_deleted, m.edited_at
+		FROM messages m JOIN chats c ON m.chat_jid = c.jid
+		WHERE LOWER(m.content) LIKE LOWER(?)`
+
+	args := []any{"%" + opts.Query + "%"}
+	if len(where) > 0 {
+		query += " AND " + strings.Join(where, " AND ")
+		args = append(args, whereArgs...)
+	}
+	query += " ORDER BY m.timestamp DESC, m.rowid DESC LIMIT ? OFFSET ?"
+	return query, append(args, opts.Limit, opts.Page*opts.Limit)
+}
+
+// isFTSSyntaxError reports whether err comes from an invalid FTS5 MATCH expression.
+// SQLite reports these while stepping through results, not when preparing the query.
+func isFTSSyntaxError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "fts5:") || msg == "unterminated string"
+}
+
+// queryMessages runs a query selecting the scanMessage columns and collects the rows.
+func (d *DB) queryMessages(query string, args ...any) ([]domain.Message, error) {
+	rows, err := d.Messages.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []domain.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// ActivityBuckets returns message counts grouped into 15-minute UTC buckets,
+// keyed by bucket start. The quarter-hour granularity lets callers re-bucket
+// into any timezone (including :30 and :45 offsets) without per-row scans.
+func (d *DB) ActivityBuckets(chatJID, after, before string) (map[time.Time]int, error) {
+	where := []string{excludeSystemSQL("messages")}
+	args := excludeSystemArgs()
+	if chatJID != "" {
+		where = append(where, "messages.chat_jid = ?")
+		args = append(args, chatJID)
+	}
+	if after != "" {
+		where = append(where, "messages.timestamp > "+timeArg)
+		args = append(args, after)
+	}
+	if before != "" {
+		where = append(where, "messages.timestamp < "+timeArg)
+		args = append(args, before)
+	}
+
+	rows, err := d.Messages.Query(`
+		SELECT strftime('%Y-%m-%dT%H:', messages.timestamp) || printf('%02d', (CAST(strftime('%M', messages.timestamp) AS INTEGER) / 15) * 15) AS bucket, COUNT(*)
+		FROM messages
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY bucket`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make(map[time.Time]int)
+	for rows.Next() {
+		var bucket sql.NullString
+		var count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		t, err := time.Parse("2006-01-02T15:04", bucket.String)
+		if err != nil {
+			continue
+		}
+		buckets[t] += count
+	}
+
+	return buckets, rows.Err()
+}
+
+// excludeSystemSQL returns a WHERE fragment that filters out system/protocol
+// messages and reactions for the given messages table alias.
+// Bind it with excludeSystemArgs.
+func excludeSystemSQL(alias string) string {
+	return fmt.Sprintf("(%[1]s.content IS NULL OR (%[1]s.content != ? AND %[1]s.content NOT LIKE ?))", alias)
+}
+
+// excludeSystemArgs returns the arguments for excludeSystemSQL.
+func excludeSystemArgs() []any {
+	return []any{domain.SystemMessageContent, domain.ReactionContentPrefix + "%"}
+}
+
+// FormatTime renders t in the canonical stored form, UTC RFC3339, so stored
+// times order correctly as text as well as through datetime().
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// timeArg is a placeholder that converts a time argument to the canonical stored
+// form in SQL. Comparing the bare column against it, rather than wrapping both
+// sides in datetime(), lets range filters use the timestamp indexes.
+const timeArg = "strftime('%Y-%m-%dT%H:%M:%SZ', ?)"
+
+// parseDBTime parses a timestamp read from an aggregate column, where the driver
+// returns the raw stored text ("2006-01-02 15:04:05-07:00") rather than RFC3339.
+func parseDBTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
 }
 
 // scanMessage is a helper to scan a message from a row.
@@ -303,9 +847,10 @@ func scanMessage(scanner interface {
 }) (domain.Message, error) {
 	var msg domain.Message
 	var ts string
-	var chatName, content, media sql.NullString
+	var chatName, content, media, editedAt sql.NullString
+	var deleted sql.NullBool
 
-	if err := scanner.Scan(&ts, &msg.Sender, &chatName, &content, &msg.IsFromMe, &msg.ChatJID, &msg.ID, &media); err != nil {
+	if err := scanner.Scan(&ts, &msg.Sender, &chatName, &content, &msg.IsFromMe, &msg.ChatJID, &msg.ID, &media, &deleted, &editedAt); err != nil {
 		return msg, err
 	}
 
@@ -319,34 +864,55 @@ func scanMessage(scanner interface {
 	if media.Valid {
 		msg.MediaType = &media.String
 	}
+	msg.IsDeleted = deleted.Bool
+	if editedAt.Valid {
+		t := parseDBTime(editedAt.String)
+		msg.IsEdited = true
+		msg.EditedAt = &t
+	}
+	msg.IsGroup = strings.HasSuffix(msg.ChatJID, "@g.us")
 
 	return msg, nil
 }
 
-// GetActiveChats returns chats with activity in the specified time range.
-func (d *DB) GetActiveChats(after, before string, onlyGroups bool, limit int) ([]domain.ActiveChatInfo, error) {
-	query := `
-		SELECT
-			c.jid,
-			c.name,
-			COUNT(m.id) as msg_count,
-			MAX(m.timestamp) as last_time,
-			c.last_message_time
-		FROM chats c
-		JOIN messages m ON c.jid = m.chat_jid
-		WHERE datetime(m.timestamp) > datetime(?) AND datetime(m.timestamp) < datetime(?)
-	`
-
-	args := []any{after, before}
+// ChatDailyAverages returns the average number of messages per day in each of the
+// given chats over [since, until). Chats without messages in the window are omitted.
+func (d *DB) ChatDailyAverages(chatJIDs []string, since, until time.Time) (map[string]float64, error) {
+	averages := map[string]float64{}
+	days := until.Sub(since).Hours() / 24
+	if len(chatJIDs) == 0 || days <= 0 {
+		return averages, nil
+	}
 
-	// Apply groups-only filter
-	if onlyGroups {
-		query += " AND c.jid LIKE '%@g.us'"
+	args := []any{since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339)}
+	for _, jid := range chatJIDs {
+		args = append(args, jid)
 	}
+	rows, err := d.Messages.Query(`
+		SELECT chat_jid, COUNT(*) FROM messages
+		WHERE timestamp >= `+timeArg+` AND timestamp < `+timeArg+`
+			AND chat_jid IN (?`+strings.Repeat(", ?", len(chatJIDs)-1)+`)
+		GROUP BY chat_jid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	query += " GROUP BY c.jid, c.name ORDER BY last_time DESC LIMIT ?"
-	args = append(args, limit)
+	for rows.Next() {
+		var jid string
+		var count int
+		if err := rows.Scan(&jid, &count); err != nil {
+			return nil, err
+		}
+		averages[jid] = float64(count) / days
+	}
+	return averages, rows.Err()
+}
 
+// GetActiveChats returns chats with activity in the specified time range.
+// Currently muted chats are left out unless includeMuted is set.
+func (d *DB) GetActiveChats(after, before string, onlyGroups, includeMuted bool, limit int) ([]domain.ActiveChatInfo, error) {
+	query, args := activeChatsQuery(after, before, onlyGroups, includeMuted, limit)
 	rows, err := d.Messages.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -378,7 +944,7 @@ func (d *DB) GetActiveChats(after, before string, onlyGroups bool, limit int) ([
 			SELECT content, is_from_me
 			FROM messages
 			WHERE chat_jid = ?
-			ORDER BY timestamp DESC LIMIT 1
+			ORDER BY timestamp DESC, rowid DESC LIMIT 1
 		`, chat.ChatJID).Scan(&content, &isFromMe)
 
 		if content.Valid {
@@ -392,16 +958,57 @@ func (d *DB) GetActiveChats(after, before string, onlyGroups bool, limit int) ([
 	return chats, nil
 }
 
+// messagesBetweenQuery counts messages strictly between two times, including
+// system messages and reactions.
+const messagesBetweenQuery = `SELECT COUNT(*) FROM messages WHERE timestamp > ` + timeArg + ` AND timestamp < ` + timeArg
+
+// CountMessagesBetween returns how many messages were stored strictly between
+// after and before.
+func (d *DB) CountMessagesBetween(after, before string) (int, error) {
+	var n int
+	err := d.Messages.QueryRow(messagesBetweenQuery, after, before).Scan(&n)
+	return n, err
+}
+
+// activeChatsQuery builds the GetActiveChats query: chats with messages in the
+// time range, most recently active first.
+func activeChatsQuery(after, before string, onlyGroups, includeMuted bool, limit int) (string, []any) {
+	query := `
+		SELECT
+			c.jid,
+			c.name,
+			COUNT(m.id) as msg_count,
+			MAX(m.timestamp) as last_time,
+			c.last_message_time
+		FROM chats c
+		JOIN messages m ON c.jid = m.chat_jid
+		WHERE m.timestamp > ` + timeArg + ` AND m.timestamp < ` + timeArg + `
+	`
+
+	args := []any{after, before}
+
+	// Apply groups-only filter
+	if onlyGroups {
+		query += " AND c.jid LIKE '%@g.us'"
+	}
+	if !includeMuted {
+		query += " AND " + notMutedSQL("c")
+	}
+
+	query += " GROUP BY c.jid, c.name ORDER BY last_time DESC LIMIT ?"
+	return query, append(args, limit)
+}
+
 // GetQuestionsForMe finds messages ending with '?' where is_from_me = false.
 func (d *DB) GetQuestionsForMe(after, before string, limit int) ([]domain.Message, error) {
 	query := `
-		SELECT m.timestamp, m.sender, c.name, m.content, m.is_from_me, m.chat_jid, m.id, m.media_type
+		SELECT m.timestamp, m.sender, c.name, m.content, m.is_from_me, m.chat_jid, m.id, m.media_type, m.is_deleted, m.edited_at
 		FROM messages m
 		JOIN chats c ON m.chat_jid = c.jid
-		WHERE datetime(m.timestamp) > datetime(?) AND datetime(m.timestamp) < datetime(?)
+		WHERE m.timestamp > ` + timeArg + ` AND m.timestamp < ` + timeArg + `
 		AND m.is_from_me = 0
 		AND m.content LIKE '%?'
-		ORDER BY m.timestamp DESC
+		ORDER BY m.timestamp DESC, m.rowid DESC
 		LIMIT ?
 	`
 
@@ -431,7 +1038,7 @@ func (d *DB) GetMediaSummary(after, before string) (*domain.MediaSummary, error)
 			media_type,
 			COUNT(*) as count
 		FROM messages
-		WHERE datetime(timestamp) > datetime(?) AND datetime(timestamp) < datetime(?)
+		WHERE timestamp > ` + timeArg + ` AND timestamp < ` + timeArg + `
 		AND media_type IS NOT NULL
 		GROUP BY media_type
 	`
@@ -466,7 +1073,7 @@ func (d *DB) GetMediaSummary(after, before string) (*domain.MediaSummary, error)
 		SELECT DISTINCT c.name
 		FROM messages m
 		JOIN chats c ON m.chat_jid = c.jid
-		WHERE datetime(m.timestamp) > datetime(?) AND datetime(m.timestamp) < datetime(?)
+		WHERE m.timestamp > ` + timeArg + ` AND m.timestamp < ` + timeArg + `
 		AND m.media_type IS NOT NULL
 		LIMIT 10
 	`
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+func TestFindGroupSendersNames(t *testing.T) {
+	d := newTestDB(t)
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	const group = "120363000000000001@g.us"
+	addMessages(t, d,
+		testMessage{id: "m1", chat: group, sender: "447700900001", content: "hi", at: at},
+		testMessage{id: "m2", chat: group, sender: "98765432109876", content: "hello", at: at.Add(time.Minute)},
+	)
+	if err := d.UpsertContact("447700900001@s.whatsapp.net", "447700900001", "Alice Phone", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpsertContact("98765432109876@lid", "", "", "Bob Lid", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query      string
+		wantSender string
+		wantName   string
+	}{
+		{query: "alice", wantSender: "447700900001", wantName: "Alice Phone"},
+		{query: "bob", wantSender: "98765432109876", wantName: "Bob Lid"},
+		{query: "+4477", wantSender: "447700900001", wantName: "Alice Phone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			senders, err := d.FindGroupSenders(tt.query, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(senders) != 1 {
+				t.Fatalf("got %d senders, want 1", len(senders))
+			}
+			if senders[0].Sender != tt.wantSender {
+				t.Errorf("sender = %q, want %q", senders[0].Sender, tt.wantSender)
+			}
+			if senders[0].Name == nil || *senders[0].Name != tt.wantName {
+				t.Errorf("name = %v, want %q", senders[0].Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestListMessagesPagesSameSecond(t *testing.T) {
+	d := newTestDB(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var msgs []testMessage
+	for i := 0; i < 25; i++ {
+		msgs = append(msgs, testMessage{id: fmt.Sprintf("m%02d", i), chat: chat, sender: "447700900001", content: "same second", at: at})
+	}
+	addMessages(t, d, msgs...)
+
+	for _, sort := range []string{domain.SortAsc, domain.SortDesc} {
+		t.Run(sort, func(t *testing.T) {
+			seen := map[string]bool{}
+			for page := 0; page < 5; page++ {
+				got, err := d.ListMessages(domain.ListMessagesOptions{ChatJID: chat, Sort: sort, Limit: 5, Page: page})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, m := range got {
+					if seen[m.ID] {
+						t.Errorf("message %s returned on more than one page", m.ID)
+					}
+					seen[m.ID] = true
+				}
+			}
+			if len(seen) != len(msgs) {
+				t.Errorf("paged through %d messages, want %d", len(seen), len(msgs))
+			}
+		})
+	}
+}
+
+func TestListChatsSameSecond(t *testing.T) {
+	d := newTestDB(t)
+	const (
+		chat  = "447700900001@s.whatsapp.net"
+		other = "447700900002@s.whatsapp.net"
+	)
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	addMessages(t, d,
+		testMessage{id: "m1", chat: chat, sender: "447700900001", content: "first", at: at},
+		testMessage{id: "m2", chat: chat, sender: "447700900001", content: "second", at: at},
+		testMessage{id: "o1", chat: other, sender: "447700900002", content: "other", at: at.Add(-time.Minute)},
+	)
+
+	for _, sort := range []string{domain.ChatSortRecent, domain.ChatSortName} {
+		t.Run(sort, func(t *testing.T) {
+			chats, err := d.ListChats(domain.ListChatsOptions{Sort: sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chats) != 2 {
+				t.Fatalf("listed %d chats, want one row per chat", len(chats))
+			}
+			for _, c := range chats {
+				if c.JID != chat {
+					continue
+				}
+				if c.LastMessage == nil || *c.LastMessage != "second" {
+					t.Errorf("last message = %v, want the later of the same-second messages", c.LastMessage)
+				}
+			}
+		})
+	}
+
+	got, err := d.GetChat(chat, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastMessage == nil || *got.LastMessage != "second" {
+		t.Errorf("GetChat last message = %v, want second", got.LastMessage)
+	}
+}
+
+func TestSameSecondTieBreaks(t *testing.T) {
+	d := newTestDB(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var msgs []testMessage
+	for i := 0; i < 25; i++ {
+		msgs = append(msgs, testMessage{id: fmt.Sprintf("m%02d", i), chat: chat, sender: "447700900001", content: "ping", at: at})
+	}
+	addMessages(t, d, msgs...)
+
+	t.Run("latest message", func(t *testing.T) {
+		for name, get := range map[string]func(string) (*domain.Message, error){
+			"GetLatestMessage":         d.GetLatestMessage,
+			"GetLatestIncomingMessage": d.GetLatestIncomingMessage,
+		} {
+			got, err := get(chat)
+			if err != nil || got == nil {
+				t.Fatalf("%s = %v, %v", name, got, err)
+			}
+			if got.ID != "m24" {
+				t.Errorf("%s = %s, want the last stored message m24", name, got.ID)
+			}
+		}
+	})
+
+	t.Run("search pages", func(t *testing.T) {
+		seen := map[string]bool{}
+		for page := 0; page < 5; page++ {
+			got, err := d.searchLike(domain.SearchMessagesOptions{Query: "ping", Limit: 5, Page: page}, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range got {
+				if seen[m.ID] {
+					t.Errorf("message %s returned on more than one page", m.ID)
+				}
+				seen[m.ID] = true
+			}
+		}
+		if len(seen) != len(msgs) {
+			t.Errorf("paged through %d messages, want %d", len(seen), len(msgs))
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// UpsertReaction records a sender's reaction to a message, replacing their previous
+// one. An empty emoji removes the reaction. Reactions older than the stored one for
+// the same sender (e.g. replayed by history sync) are ignored.
+func (d *DB) UpsertReaction(chatJID, targetMessageID, sender, emoji string, t time.Time) error {
+	if emoji == "" {
+		_, err := d.Messages.Exec(`
+			DELETE FROM reactions
+			WHERE chat_jid = ? AND target_message_id = ? AND sender = ? AND datetime(timestamp) <= datetime(?)`,
+			chatJID, targetMessageID, sender, t.UTC().Format(time.RFC3339),
+		)
+		return err
+	}
+
+	_, err := d.Messages.Exec(`
+		INSERT INTO reactions (chat_jid, target_message_id, sender, emoji, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chat_jid, target_message_id, sender) DO UPDATE SET
+			emoji = excluded.emoji,
+			timestamp = excluded.timestamp
+		WHERE datetime(excluded.timestamp) >= datetime(reactions.timestamp)`,
+		chatJID, targetMessageID, sender, emoji, t,
+	)
+	return err
+}
+
+// GetMessageReactions returns the individual reactions to a message, oldest first,
+// with reactor names resolved from contacts.
+func (d *DB) GetMessageReactions(chatJID, messageID string) ([]domain.Reaction, error) {
+	rows, err := d.Messages.Query(`
+		SELECT r.emoji, r.sender, COALESCE(c.full_name, c.business_name, c.push_name), r.timestamp
+		FROM reactions r
+		LEFT JOIN contacts c ON c.jid = r.sender || '@s.whatsapp.net'
+		WHERE r.chat_jid = ? AND r.target_message_id = ?
+		ORDER BY r.timestamp`, chatJID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := []domain.Reaction{}
+	for rows.Next() {
+		var r domain.Reaction
+		var name sql.NullString
+		if err := rows.Scan(&r.Emoji, &r.Sender, &name, &r.Timestamp); err != nil {
+			return nil, err
+		}
+		if name.Valid && name.String != "" {
+			r.SenderName = &name.String
+		}
+		reactions = append(reactions, r)
+	}
+	return reactions, rows.Err()
+}
+
+// attachReactions sets each message's aggregated emoji counts from the reactions table.
+func (d *DB) attachReactions(messages []domain.Message) error {
+	byChat := map[string][]int{}
+	for i, m := range messages {
+		byChat[m.ChatJID] = append(byChat[m.ChatJID], i)
+	}
+
+	for chatJID, idx := range byChat {
+		args := []any{chatJID}
+		for _, i := range idx {
+			args = append(args, messages[i].ID)
+		}
+
+		rows, err := d.Messages.Query(`
+			SELECT target_message_id, emoji, COUNT(*) FROM reactions
+			WHERE chat_jid = ? AND target_message_id IN (?`+strings.Repeat(", ?", len(idx)-1)+`)
+			GROUP BY target_message_id, emoji`, args...)
+		if err != nil {
+			return err
+		}
+
+		counts := map[string]map[string]int{}
+		for rows.Next() {
+			var id, emoji string
+			var n int
+			if err := rows.Scan(&id, &emoji, &n); err != nil {
+				rows.Close()
+				return err
+			}
+			if counts[id] == nil {
+				counts[id] = map[string]int{}
+			}
+			counts[id][emoji] = n
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, i := range idx {
+			messages[i].Reactions = counts[messages[i].ID]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package store
+
+// pruneBatchSize is how far a chat may exceed its cap before pruning runs,
+// so busy chats are trimmed in batches rather than on every insert.
+const pruneBatchSize = 50
+
+// PruneChatMessages deletes the oldest messages in a chat beyond maxMessages.
+// It only prunes once the chat exceeds the cap by pruneBatchSize. Deletes go
+// through the messages_ad trigger, keeping messages_fts in sync.
+func (d *DB) PruneChatMessages(chatJID string, maxMessages int) (int64, error) {
+	if maxMessages <= 0 {
+		return 0, nil
+	}
+
+	var count int
+	if err := d.Messages.QueryRow("SELECT COUNT(*) FROM messages WHERE chat_jid = ?", chatJID).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count < maxMessages+pruneBatchSize {
+		return 0, nil
+	}
+
+	res, err := d.Messages.Exec(`DELETE FROM messages WHERE rowid IN (
+		SELECT rowid FROM messages WHERE chat_jid = ?
+		ORDER BY timestamp DESC, rowid DESC LIMIT -1 OFFSET ?
+	)`, chatJID, maxMessages)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := d.Messages.Exec(`DELETE FROM reactions WHERE chat_jid = ?
+		AND target_message_id NOT IN (SELECT id FROM messages WHERE chat_jid = ?)`, chatJID, chatJID); err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
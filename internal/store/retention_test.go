@@ -0,0 +1,72 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+func TestPruneChatMessages(t *testing.T) {
+	const chat, other = "447700900001@s.whatsapp.net", "447700900002@s.whatsapp.net"
+	const maxMessages = 10
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		stored     int
+		wantPruned int64
+		wantKept   int
+	}{
+		{name: "under cap", stored: maxMessages, wantPruned: 0, wantKept: maxMessages},
+		{name: "over cap within batch", stored: maxMessages + pruneBatchSize - 1, wantPruned: 0, wantKept: maxMessages + pruneBatchSize - 1},
+		{name: "over cap by a batch", stored: maxMessages + pruneBatchSize, wantPruned: pruneBatchSize, wantKept: maxMessages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDB(t)
+			var msgs []testMessage
+			for i := 0; i < tt.stored; i++ {
+				msgs = append(msgs, testMessage{id: fmt.Sprintf("m%03d", i), chat: chat, sender: "447700900001", content: fmt.Sprintf("word%03d", i), at: start.Add(time.Duration(i) * time.Minute)})
+			}
+			msgs = append(msgs, testMessage{id: "x", chat: other, sender: "447700900002", content: "other chat", at: start})
+			addMessages(t, d, msgs...)
+
+			pruned, err := d.PruneChatMessages(chat, maxMessages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pruned != tt.wantPruned {
+				t.Errorf("pruned = %d, want %d", pruned, tt.wantPruned)
+			}
+
+			var kept int
+			var oldest string
+			if err := d.Messages.QueryRow(`SELECT COUNT(*), MIN(id) FROM messages WHERE chat_jid = ?`, chat).Scan(&kept, &oldest); err != nil {
+				t.Fatal(err)
+			}
+			if kept != tt.wantKept {
+				t.Errorf("kept = %d, want %d", kept, tt.wantKept)
+			}
+			if want := fmt.Sprintf("m%03d", tt.stored-tt.wantKept); oldest != want {
+				t.Errorf("oldest kept = %s, want %s (the newest messages should survive)", oldest, want)
+			}
+			if n, _ := d.CountMessages(domain.ListMessagesOptions{ChatJID: other}); n != 1 {
+				t.Errorf("other chat has %d messages, want 1", n)
+			}
+
+			// Pruned messages must leave the full-text index too
+			if d.FTS && tt.wantPruned > 0 {
+				var hits int
+				if err := d.Messages.QueryRow(`SELECT COUNT(*) FROM messages_fts WHERE messages_fts MATCH 'word000'`).Scan(&hits); err != nil {
+					t.Fatal(err)
+				}
+				if hits != 0 {
+					t.Errorf("pruned message still matches in messages_fts")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+func TestSearchMessagesWithoutFTS(t *testing.T) {
+	d := newTestDB(t)
+	// Exercise the LIKE fallback even when this build has FTS5
+	d.FTS = false
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	// One message per chat, so results carry no surrounding context
+	addMessages(t, d,
+		testMessage{id: "m1", chat: "447700900001@s.whatsapp.net", sender: "447700900001", content: "Lunch on Friday?", at: at},
+		testMessage{id: "m2", chat: "447700900002@s.whatsapp.net", sender: "447700900002", content: "friday works", at: at.Add(time.Minute)},
+		testMessage{id: "m3", chat: "447700900003@s.whatsapp.net", sender: "447700900003", content: "see you at the café", at: at.Add(2 * time.Minute)},
+		testMessage{id: "m4", chat: "447700900004@s.whatsapp.net", sender: "447700900004", content: `he said "maybe`, at: at.Add(3 * time.Minute)},
+	)
+
+	tests := []struct {
+		name string
+		opts domain.SearchMessagesOptions
+		want []string
+	}{
+		{name: "case insensitive", opts: domain.SearchMessagesOptions{Query: "FRIDAY"}, want: []string{"m2", "m1"}},
+		{name: "substring", opts: domain.SearchMessagesOptions{Query: "unch"}, want: []string{"m1"}},
+		{name: "non-ASCII", opts: domain.SearchMessagesOptions{Query: "café"}, want: []string{"m3"}},
+		{name: "FTS syntax taken literally", opts: domain.SearchMessagesOptions{Query: `"maybe`}, want: []string{"m4"}},
+		{name: "chat filter", opts: domain.SearchMessagesOptions{Query: "friday", ChatJID: "447700900001@s.whatsapp.net"}, want: []string{"m1"}},
+		{name: "limit", opts: domain.SearchMessagesOptions{Query: "friday", Limit: 1}, want: []string{"m2"}},
+		{name: "no match", opts: domain.SearchMessagesOptions{Query: "weekend"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated, err := d.SearchMessages(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if truncated {
+				t.Error("results reported as truncated")
+			}
+			var ids []string
+			for _, m := range got {
+				ids = append(ids, m.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("matched %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRequireFTS(t *testing.T) {
+	if newTestDB(t).FTS {
+		t.Skip("SQLite FTS5 is available in this build")
+	}
+	if d, err := Open(t.TempDir(), true); err == nil {
+		d.Close()
+		t.Error("Open with requireFTS succeeded without FTS5")
+	}
+}
+
+func TestSearchMessagesMaxRows(t *testing.T) {
+	d := newTestDB(t)
+	const chat = "447700900001@s.whatsapp.net"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Every third message matches, so each match has two context messages either side
+	var msgs []testMessage
+	for i := 0; i < 92; i++ {
+		content := "filler"
+		if i%3 == 2 {
+			content = "deadline moved"
+		}
+		msgs = append(msgs, testMessage{id: fmt.Sprintf("m%02d", i), chat: chat, sender: "447700900001", content: content, at: at.Add(time.Duration(i) * time.Minute)})
+	}
+	addMessages(t, d, msgs...)
+
+	tests := []struct {
+		name          string
+		limit         int
+		maxRows       int
+		wantRows      int
+		wantTruncated bool
+	}{
+		{name: "uncapped", limit: 30, wantRows: 30 * 5},
+		{name: "cap above total", limit: 30, maxRows: 1000, wantRows: 30 * 5},
+		{name: "cap exactly total", limit: 4, maxRows: 20, wantRows: 20},
+		{name: "cap inside context", limit: 30, maxRows: 12, wantRows: 12, wantTruncated: true},
+		{name: "cap on a match boundary", limit: 30, maxRows: 10, wantRows: 10, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated, err := d.SearchMessages(domain.SearchMessagesOptions{Query: "deadline", Limit: tt.limit, MaxRows: tt.maxRows})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantRows {
+				t.Errorf("returned %d rows, want %d", len(got), tt.wantRows)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// SetMessageStarred records whether a message is starred, reporting whether the
+// message is stored.
+func (d *DB) SetMessageStarred(chatJID, messageID string, starred bool) (bool, error) {
+	res, err := d.Messages.Exec(`UPDATE messages SET is_starred = ? WHERE chat_jid = ? AND id = ?`, starred, chatJID, messageID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListStarredMessages lists starred messages across all chats, newest first,
+// along with the total number starred.
+func (d *DB) ListStarredMessages(limit, page int) ([]domain.Message, int, error) {
+	var total int
+	if err := d.Messages.QueryRow(`SELECT COUNT(*) FROM messages WHERE is_starred = 1`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	messages, err := d.queryMessages(`SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.is_deleted, messages.edited_at
+		FROM messages JOIN chats ON messages.chat_jid = chats.jid
+		WHERE messages.is_starred = 1
+		ORDER BY messages.timestamp DESC, messages.rowid DESC
+		LIMIT ? OFFSET ?`, limit, page*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := d.attachReactions(messages); err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
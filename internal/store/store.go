@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -11,9 +12,15 @@ import (
 
 type DB struct {
 	Messages *sql.DB
+
+	// FTS reports whether the messages_fts full-text index is available.
+	FTS bool
 }
 
-func Open(dbDir string) (*DB, error) {
+// Open opens (and migrates) the messages database in dbDir. When requireFTS is
+// false, a build without SQLite FTS5 still opens, with FTS left false so
+// searches use LIKE matching instead.
+func Open(dbDir string, requireFTS bool) (*DB, error) {
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create db dir: %w", err)
 	}
@@ -29,7 +36,20 @@ func Open(dbDir string) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{Messages: mdb}, nil
+	fts := true
+	if err := migrateFTS(mdb); err != nil {
+		if requireFTS || !errors.Is(err, errFTSUnavailable) {
+			_ = mdb.Close()
+			return nil, err
+		}
+		if err := dropFTSTriggers(mdb); err != nil {
+			_ = mdb.Close()
+			return nil, err
+		}
+		fts = false
+	}
+
+	return &DB{Messages: mdb, FTS: fts}, nil
 }
 
 func (d *DB) Close() error {
@@ -68,11 +88,153 @@ func migrate(db *sql.DB) error {
             FOREIGN KEY (chat_jid) REFERENCES chats(jid)
         );
 
+        CREATE INDEX IF NOT EXISTS idx_messages_chat_timestamp ON messages(chat_jid, timestamp);
+        CREATE INDEX IF NOT EXISTS idx_messages_timestamp ON messages(timestamp);
+        CREATE INDEX IF NOT EXISTS idx_messages_sender ON messages(sender);
+        CREATE INDEX IF NOT EXISTS idx_messages_media_type ON messages(media_type);
+
+        CREATE TABLE IF NOT EXISTS contacts (
+            jid TEXT PRIMARY KEY,
+            phone TEXT,
+            full_name TEXT,
+            push_name TEXT,
+            business_name TEXT,
+            updated_at TIMESTAMP
+        );
+
+        CREATE TABLE IF NOT EXISTS groups (
+            jid TEXT PRIMARY KEY,
+            name TEXT,
+            participant_count INTEGER,
+            is_admin BOOLEAN,
+            updated_at TIMESTAMP
+        );
+
+        CREATE TABLE IF NOT EXISTS reactions (
+            chat_jid TEXT,
+            target_message_id TEXT,
+            sender TEXT,
+            emoji TEXT,
+            timestamp TIMESTAMP,
+            PRIMARY KEY (chat_jid, target_message_id, sender)
+        );
+
+        CREATE TABLE IF NOT EXISTS message_edits (
+            chat_jid TEXT,
+            message_id TEXT,
+            previous_content TEXT,
+            edited_at TIMESTAMP
+        );
+
+        CREATE INDEX IF NOT EXISTS idx_message_edits_message ON message_edits(chat_jid, message_id);
+
+        CREATE TABLE IF NOT EXISTS polls (
+            chat_jid TEXT,
+            message_id TEXT,
+            sender TEXT,
+            question TEXT,
+            options TEXT,
+            allow_multiple BOOLEAN,
+            created_at TIMESTAMP,
+            PRIMARY KEY (chat_jid, message_id)
+        );
+
+        CREATE TABLE IF NOT EXISTS poll_votes (
+            chat_jid TEXT,
+            poll_message_id TEXT,
+            voter TEXT,
+            option_name TEXT,
+            voted_at TIMESTAMP,
+            PRIMARY KEY (chat_jid, poll_message_id, voter, option_name)
+        );
+
+        CREATE TABLE IF NOT EXISTS profile_pictures (
+            jid TEXT,
+            preview BOOLEAN,
+            picture_id TEXT,
+            url TEXT,
+            path TEXT,
+            fetched_at TIMESTAMP,
+            PRIMARY KEY (jid, preview)
+        );
+
+        CREATE TABLE IF NOT EXISTS blocklist (
+            jid TEXT PRIMARY KEY,
+            blocked_at TIMESTAMP
+        );
     `)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-	// Enforce FTS5 availability and initialize virtual table and triggers
+	if err := migrateContacts(db); err != nil {
+		return fmt.Errorf("failed to migrate contacts: %w", err)
+	}
+	for _, col := range []struct{ table, name, def string }{
+		{"chats", "last_read_time", "TIMESTAMP"},
+		{"chats", "archived", "BOOLEAN DEFAULT 0"},
+		{"chats", "pinned", "BOOLEAN DEFAULT 0"},
+		{"chats", "muted_until", "TIMESTAMP"},
+		{"messages", "is_deleted", "BOOLEAN DEFAULT 0"},
+		{"messages", "edited_at", "TIMESTAMP"},
+		{"messages", "mime_type", "TEXT"},
+		{"messages", "reply_to_id", "TEXT"},
+		{"messages", "reply_to_me", "BOOLEAN DEFAULT 0"},
+		{"messages", "mentions_me", "BOOLEAN DEFAULT 0"},
+		{"messages", "is_starred", "BOOLEAN DEFAULT 0"},
+		{"contacts", "is_business", "BOOLEAN"},
+		{"contacts", "verified_name", "TEXT"},
+		{"contacts", "verified_issuer", "TEXT"},
+		{"contacts", "business_checked_at", "TIMESTAMP"},
+	} {
+		if err := ensureColumn(db, col.table, col.name, col.def); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", col.table, col.name, err)
+		}
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_starred ON messages(timestamp) WHERE is_starred = 1`); err != nil {
+		return fmt.Errorf("failed to create starred index: %w", err)
+	}
+	// Messages edited before edited_at existed take the time of their latest recorded edit
+	if _, err := db.Exec(`
+        UPDATE messages SET edited_at = (
+            SELECT MAX(e.edited_at) FROM message_edits e WHERE e.chat_jid = messages.chat_jid AND e.message_id = messages.id
+        )
+        WHERE edited_at IS NULL AND (chat_jid, id) IN (SELECT chat_jid, message_id FROM message_edits)`); err != nil {
+		return fmt.Errorf("failed to backfill messages.edited_at: %w", err)
+	}
+	if err := normalizeTimes(db); err != nil {
+		return fmt.Errorf("failed to normalize timestamps: %w", err)
+	}
+	return nil
+}
+
+// normalizeTimes rewrites message and chat times written by earlier versions
+// (the driver's "2006-01-02 15:04:05-07:00" form, in local time) to UTC RFC3339,
+// the form FormatTime writes, so they sort and compare consistently. Values
+// SQLite can't parse are left as they are.
+func normalizeTimes(db *sql.DB) error {
+	for _, col := range []struct{ table, name string }{
+		{"messages", "timestamp"},
+		{"messages", "edited_at"},
+		{"message_edits", "edited_at"},
+		{"chats", "last_message_time"},
+		{"chats", "last_read_time"},
+		{"chats", "muted_until"},
+	} {
+		canonical := fmt.Sprintf("strftime('%%Y-%%m-%%dT%%H:%%M:%%SZ', %s)", col.name)
+		if _, err := db.Exec(fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NOT NULL AND %s != %s",
+			col.table, col.name, canonical, canonical, col.name, canonical)); err != nil {
+			return fmt.Errorf("%s.%s: %w", col.table, col.name, err)
+		}
+	}
+	return nil
+}
+
+// errFTSUnavailable reports that SQLite was built without the FTS5 module.
+var errFTSUnavailable = errors.New("SQLite FTS5 is not available in the current build. Rebuild with CGO enabled and the go-sqlite3 'sqlite_fts5' build tag, e.g.: GO111MODULE=on CGO_ENABLED=1 go build -tags 'sqlite_fts5'. Under macOS, ensure Xcode CLT is installed. Set REQUIRE_FTS=false to start without full-text search.")
+
+// migrateFTS initializes the FTS5 virtual table and its sync triggers.
+// Returns errFTSUnavailable when FTS5 isn't compiled in.
+func migrateFTS(db *sql.DB) error {
 	if _, err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
         content,
         content='messages',
@@ -80,7 +242,7 @@ func migrate(db *sql.DB) error {
     );`); err != nil {
 		// Common error messages when FTS5 isn't compiled in: "no such module: fts5" or mentions of "fts5"
 		if strings.Contains(strings.ToLower(err.Error()), "fts5") || strings.Contains(strings.ToLower(err.Error()), "no such module") {
-			return fmt.Errorf("SQLite FTS5 is not available in the current build. Rebuild with CGO enabled and the go-sqlite3 'sqlite_fts5' build tag, e.g.: GO111MODULE=on CGO_ENABLED=1 go build -tags 'sqlite_fts5'. Under macOS, ensure Xcode CLT is installed.")
+			return errFTSUnavailable
 		}
 		return err
 	}
@@ -90,13 +252,18 @@ func migrate(db *sql.DB) error {
     END;`); err != nil {
 		return err
 	}
+	// External-content FTS5 deletes must supply the old content, otherwise the
+	// terms stay in the index. Recreate triggers created without it.
+	if _, err := db.Exec(`DROP TRIGGER IF EXISTS messages_ad; DROP TRIGGER IF EXISTS messages_au;`); err != nil {
+		return err
+	}
 	if _, err := db.Exec(`CREATE TRIGGER IF NOT EXISTS messages_ad AFTER DELETE ON messages BEGIN
-        INSERT INTO messages_fts(messages_fts, rowid) VALUES('delete', old.rowid);
+        INSERT INTO messages_fts(messages_fts, rowid, content) VALUES('delete', old.rowid, old.content);
     END;`); err != nil {
 		return err
 	}
 	if _, err := db.Exec(`CREATE TRIGGER IF NOT EXISTS messages_au AFTER UPDATE ON messages BEGIN
-        INSERT INTO messages_fts(messages_fts, rowid) VALUES('delete', old.rowid);
+        INSERT INTO messages_fts(messages_fts, rowid, content) VALUES('delete', old.rowid, old.content);
         INSERT INTO messages_fts(rowid, content)
         VALUES (new.rowid, new.content);
     END;`); err != nil {
@@ -111,3 +278,82 @@ func migrate(db *sql.DB) error {
 	_, _ = db.Exec(`INSERT INTO messages_fts(messages_fts) VALUES('rebuild')`)
 	return nil
 }
+
+// dropFTSTriggers removes the FTS sync triggers left by a previous FTS-enabled
+// build; they would otherwise make every message insert fail. The index is
+// rebuilt from the messages table once FTS5 is available again.
+func dropFTSTriggers(db *sql.DB) error {
+	_, err := db.Exec(`DROP TRIGGER IF EXISTS messages_ai; DROP TRIGGER IF EXISTS messages_ad; DROP TRIGGER IF EXISTS messages_au;`)
+	return err
+}
+
+// contactsMigratedVersion is the user_version recorded once migrateContacts has run.
+const contactsMigratedVersion = 1
+
+// migrateContacts moves per-sender name rows that were previously stored in the
+// chats table (no messages, no activity) into the contacts table, leaving chats
+// for actual conversations only. It runs once per database, tracked through
+// PRAGMA user_version, so chats created later without messages are left alone.
+func migrateContacts(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version >= contactsMigratedVersion {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+        INSERT OR IGNORE INTO contacts (jid, phone, full_name, updated_at)
+        SELECT jid, substr(jid, 1, instr(jid, '@') - 1), NULLIF(name, substr(jid, 1, instr(jid, '@') - 1)), CURRENT_TIMESTAMP
+        FROM chats
+        WHERE jid LIKE '%@s.whatsapp.net'
+            AND last_message_time IS NULL
+            AND NOT EXISTS (SELECT 1 FROM messages m WHERE m.chat_jid = chats.jid);
+
+        DELETE FROM chats
+        WHERE jid LIKE '%@s.whatsapp.net'
+            AND last_message_time IS NULL
+            AND NOT EXISTS (SELECT 1 FROM messages m WHERE m.chat_jid = chats.jid);
+    `); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, contactsMigratedVersion)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ensureColumn adds a column to an existing table if it is not already present,
+// so databases created by earlier versions pick up new columns.
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// testMessage is a row inserted directly into messages by tests.
+type testMessage struct {
+	id, chat, sender, content, mediaType string
+	at                                   time.Time
+	fromMe                               bool
+}
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	d, err := Open(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// addMessages stores messages and their chats, keeping each chat's latest time.
+func addMessages(t *testing.T, d *DB, msgs ...testMessage) {
+	t.Helper()
+	for _, m := range msgs {
+		if err := d.UpsertChat(m.chat, m.chat, m.at); err != nil {
+			t.Fatalf("upsert chat: %v", err)
+		}
+		if _, err := d.Messages.Exec(`INSERT INTO messages (id, chat_jid, sender, content, timestamp, is_from_me, media_type)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`, m.id, m.chat, m.sender, m.content, FormatTime(m.at), m.fromMe, m.mediaType); err != nil {
+			t.Fatalf("insert message %s: %v", m.id, err)
+		}
+	}
+}
+
+func TestMigrateContactsRunsOnce(t *testing.T) {
+	dir := t.TempDir()
+	d, err := Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Messages.Exec(`PRAGMA user_version = 0`); err != nil {
+		t.Fatal(err)
+	}
+	// Legacy per-sender name row, a group and a broadcast list with no messages
+	for _, jid := range []string{"447700900001@s.whatsapp.net", "120363000000000001@g.us", "status@broadcast"} {
+		if _, err := d.Messages.Exec(`INSERT INTO chats (jid, name) VALUES (?, 'Name')`, jid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Close()
+
+	d, err = Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Messages.Exec(`INSERT INTO chats (jid, name) VALUES ('447700900002@s.whatsapp.net', 'Later')`); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	d, err = Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	tests := []struct {
+		jid  string
+		want bool
+	}{
+		{jid: "447700900001@s.whatsapp.net", want: false},
+		{jid: "120363000000000001@g.us", want: true},
+		{jid: "status@broadcast", want: true},
+		{jid: "447700900002@s.whatsapp.net", want: true},
+	}
+	for _, tt := range tests {
+		var n int
+		if err := d.Messages.QueryRow(`SELECT COUNT(*) FROM chats WHERE jid = ?`, tt.jid).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if got := n == 1; got != tt.want {
+			t.Errorf("chat %s kept = %v, want %v", tt.jid, got, tt.want)
+		}
+	}
+
+	var name string
+	if err := d.Messages.QueryRow(`SELECT full_name FROM contacts WHERE jid = '447700900001@s.whatsapp.net'`).Scan(&name); err != nil || name != "Name" {
+		t.Errorf("migrated contact name = %q, %v", name, err)
+	}
+}
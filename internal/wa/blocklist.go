@@ -0,0 +1,79 @@
+package wa
+
+import (
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// SetBlocked blocks or unblocks an individual contact and refreshes the cached
+// blocklist from WhatsApp's response.
+func (c *Client) SetBlocked(userJID string, block bool) error {
+	jid, err := types.ParseJID(userJID)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+	if err := c.requireLoggedIn(); err != nil {
+		return err
+	}
+
+	action := events.BlocklistChangeActionUnblock
+	if block {
+		action = events.BlocklistChangeActionBlock
+	}
+	list, err := c.WA.UpdateBlocklist(jid.ToNonAD(), action)
+	if err != nil {
+		return err
+	}
+
+	if list != nil {
+		c.storeBlocklist(list)
+	} else if err := c.Store.SetBlocked(jid.ToNonAD().String(), block); err != nil {
+		c.Logger.Warn("failed to store blocklist change", "jid", userJID, "err", err)
+	}
+	return nil
+}
+
+// RefreshBlocklist fetches the full blocklist from WhatsApp into the cache.
+func (c *Client) RefreshBlocklist() error {
+	if err := c.requireLoggedIn(); err != nil {
+		return err
+	}
+	list, err := c.WA.GetBlocklist()
+	if err != nil {
+		return err
+	}
+	c.storeBlocklist(list)
+	return nil
+}
+
+// storeBlocklist replaces the cached blocklist.
+func (c *Client) storeBlocklist(list *types.Blocklist) {
+	jids := make([]string, len(list.JIDs))
+	for i, jid := range list.JIDs {
+		jids[i] = jid.ToNonAD().String()
+	}
+	if err := c.Store.ReplaceBlocklist(jids); err != nil {
+		c.Logger.Warn("failed to store blocklist", "err", err)
+	}
+}
+
+// handleBlocklist applies blocklist changes made on other devices. A "modify"
+// action carries no changes and means the whole list must be fetched again.
+func (c *Client) handleBlocklist(evt *events.Blocklist) {
+	if evt.Action == events.BlocklistActionModify {
+		go func() {
+			if err := c.RefreshBlocklist(); err != nil {
+				c.Logger.Warn("failed to refresh blocklist", "err", err)
+			}
+		}()
+		return
+	}
+	for _, change := range evt.Changes {
+		jid := change.JID.ToNonAD().String()
+		if err := c.Store.SetBlocked(jid, change.Action == events.BlocklistChangeActionBlock); err != nil {
+			c.Logger.Warn("failed to store blocklist change", "jid", jid, "err", err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// appStateTimeout bounds sending a chat state change and resyncing afterwards.
+const appStateTimeout = 30 * time.Second
+
+// SetChatArchived archives or unarchives a chat on all linked devices, then
+// records the new state locally. Archiving also unpins the chat.
+func (c *Client) SetChatArchived(chatJID string, archived bool) error {
+	jid, err := c.chatForAppState(chatJID)
+	if err != nil {
+		return err
+	}
+
+	// WhatsApp anchors archive changes to the chat's latest message
+	var lastTime time.Time
+	var lastKey *waCommon.MessageKey
+	if last, err := c.Store.GetLatestMessage(chatJID); err != nil {
+		c.Logger.Debug("failed to get latest message for archive", "jid", chatJID, "err", err)
+	} else if last != nil {
+		lastTime = last.Timestamp
+		lastKey = c.archiveKey(jid, last)
+	}
+
+	if err := c.sendAppState(appstate.BuildArchive(jid, archived, lastTime, lastKey)); err != nil {
+		return err
+	}
+	if err := c.Store.SetChatArchived(chatJID, archived); err != nil {
+		c.Logger.Warn("failed to store archived state", "jid", chatJID, "err", err)
+	}
+	return nil
+}
+
+// archiveKey builds the message key of a chat's latest message for an archive
+// change. Incoming group messages carry their sender as the participant.
+func (c *Client) archiveKey(chat types.JID, last *domain.Message) *waCommon.MessageKey {
+	key := &waCommon.MessageKey{
+		RemoteJID: protoString(chat.String()),
+		FromMe:    protoBool(last.IsFromMe),
+		ID:        protoString(last.ID),
+	}
+	if chat.Server == types.GroupServer && !last.IsFromMe && last.Sender != "" {
+		key.Participant = protoString(c.participantJID(last.Sender).String())
+	}
+	return key
+}
+
+// SetChatPinned pins or unpins a chat on all linked devices, then records the
+// new state locally.
+func (c *Client) SetChatPinned(chatJID string, pinned bool) error {
+	jid, err := c.chatForAppState(chatJID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.sendAppState(appstate.BuildPin(jid, pinned)); err != nil {
+		return err
+	}
+	if err := c.Store.SetChatPinned(chatJID, pinned); err != nil {
+		c.Logger.Warn("failed to store pinned state", "jid", chatJID, "err", err)
+	}
+	return nil
+}
+
+// SetChatMuted mutes a chat for duration (0 mutes it indefinitely), or unmutes it,
+// on all linked devices, then records the new state locally.
+func (c *Client) SetChatMuted(chatJID string, mute bool, duration time.Duration) error {
+	jid, err := c.chatForAppState(chatJID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.sendAppState(appstate.BuildMute(jid, mute, duration)); err != nil {
+		return err
+	}
+
+	var until time.Time
+	if mute {
+		until = domain.MutedForever
+		if duration > 0 {
+			until = time.Now().Add(duration)
+		}
+	}
+	if err := c.Store.SetChatMutedUntil(chatJID, until); err != nil {
+		c.Logger.Warn("failed to store muted state", "jid", chatJID, "err", err)
+	}
+	return nil
+}
+
+// chatForAppState parses a chat JID and checks the client can send app state changes.
+func (c *Client) chatForAppState(chatJID string) (types.JID, error) {
+	jid, err := types.ParseJID(chatJID)
+	if err != nil {
+		return types.EmptyJID, fmt.Errorf("invalid chat JID: %w", err)
+	}
+	if err := c.requireLoggedIn(); err != nil {
+		return types.EmptyJID, err
+	}
+	return jid, nil
+}
+
+// sendAppState sends an app state patch and waits for the collection to resync,
+// so the change is applied before returning and back-to-back changes don't race.
+func (c *Client) sendAppState(patch appstate.PatchInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), appStateTimeout)
+	defer cancel()
+
+	if err := c.WA.SendAppState(ctx, patch); err != nil {
+		return err
+	}
+	if err := c.WA.FetchAppState(ctx, patch.Type, false, false); err != nil {
+		c.Logger.Warn("failed to resync app state", "patch", patch.Type, "err", err)
+	}
+	return nil
+}
+
+// handleArchive applies archive changes synced from other devices.
+func (c *Client) handleArchive(evt *events.Archive) {
+	if err := c.Store.SetChatArchived(evt.JID.String(), evt.Action.GetArchived()); err != nil {
+		c.Logger.Warn("failed to store archived state", "jid", evt.JID, "err", err)
+	}
+}
+
+// handleMute applies mute changes synced from other devices.
+func (c *Client) handleMute(evt *events.Mute) {
+	var until time.Time
+	if evt.Action.GetMuted() {
+		until = domain.MutedForever
+		if end := evt.Action.GetMuteEndTimestamp(); end > 0 {
+			until = time.UnixMilli(end)
+		}
+	}
+	if err := c.Store.SetChatMutedUntil(evt.JID.String(), until); err != nil {
+		c.Logger.Warn("failed to store muted state", "jid", evt.JID, "err", err)
+	}
+}
+
+// handlePin applies pin changes synced from other devices.
+func (c *Client) handlePin(evt *events.Pin) {
+	if err := c.Store.SetChatPinned(evt.JID.String(), evt.Action.GetPinned()); err != nil {
+		c.Logger.Warn("failed to store pinned state", "jid", evt.JID, "err", err)
+	}
+}
@@ -0,0 +1,45 @@
+package wa
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+func TestArchiveKeyParticipant(t *testing.T) {
+	const (
+		lid   = "99887766554433"
+		phone = "447700900002"
+	)
+	group := types.NewJID("120363000000000001", types.GroupServer)
+
+	tests := []struct {
+		name            string
+		chat            types.JID
+		last            domain.Message
+		wantParticipant string
+	}{
+		{"LID sender", group, domain.Message{ID: "a", Sender: lid}, lid + "@lid"},
+		{"phone number sender", group, domain.Message{ID: "b", Sender: phone}, phone + "@s.whatsapp.net"},
+		{"own message", group, domain.Message{ID: "c", Sender: phone, IsFromMe: true}, ""},
+		{"direct chat", types.NewJID(phone, types.DefaultUserServer), domain.Message{ID: "d", Sender: phone}, ""},
+	}
+
+	c := newTestClient(t)
+	setOwnNumber(c, "447700900000")
+	c.WA.Store.LIDs = lidMappings{pn: map[string]string{lid: phone}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := c.archiveKey(tt.chat, &tt.last)
+			if key.GetRemoteJID() != tt.chat.String() || key.GetID() != tt.last.ID || key.GetFromMe() != tt.last.IsFromMe {
+				t.Errorf("key = %v, want chat %s, id %s, from me %v", key, tt.chat, tt.last.ID, tt.last.IsFromMe)
+			}
+			if got := key.GetParticipant(); got != tt.wantParticipant {
+				t.Errorf("participant = %q, want %q", got, tt.wantParticipant)
+			}
+		})
+	}
+}
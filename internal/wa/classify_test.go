@@ -0,0 +1,53 @@
+package wa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func TestClassify(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	pdf := []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	mp3 := []byte("ID3\x04\x00\x00\x00\x00\x00\x00")
+	wav := []byte("RIFF\x24\x00\x00\x00WAVEfmt \x10\x00\x00\x00")
+	mp4 := []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2avc1mp41")
+	m4a := []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00M4A mp42isom\x00\x00\x00\x00")
+
+	tests := []struct {
+		name     string
+		file     string
+		content  []byte
+		wantType whatsmeow.MediaType
+		wantMime string
+	}{
+		{name: "JPEG bytes in .dat", file: "photo.dat", content: jpeg, wantType: whatsmeow.MediaImage, wantMime: "image/jpeg"},
+		{name: "PNG named .jpg", file: "photo.jpg", content: png, wantType: whatsmeow.MediaImage, wantMime: "image/png"},
+		{name: "PDF bytes in .bin", file: "report.bin", content: pdf, wantType: whatsmeow.MediaDocument, wantMime: "application/pdf"},
+		{name: ".ogg stays PTT whatever its contents", file: "voice.ogg", content: jpeg, wantType: whatsmeow.MediaAudio, wantMime: "audio/ogg; codecs=opus"},
+		{name: "MP3 is audio", file: "song.mp3", content: mp3, wantType: whatsmeow.MediaAudio, wantMime: "audio/mpeg"},
+		{name: "WAV is audio", file: "memo.wav", content: wav, wantType: whatsmeow.MediaAudio, wantMime: "audio/wave"},
+		{name: "M4A is audio, not video", file: "memo.m4a", content: m4a, wantType: whatsmeow.MediaAudio, wantMime: "audio/mp4"},
+		{name: "MP4 is video", file: "clip.mp4", content: mp4, wantType: whatsmeow.MediaVideo, wantMime: "video/mp4"},
+		{name: "unknown bytes fall back to extension", file: "clip.mov", content: []byte{0, 1, 2, 3}, wantType: whatsmeow.MediaVideo, wantMime: "video/quicktime"},
+		{name: "text is a document", file: "notes.txt", content: []byte("hello"), wantType: whatsmeow.MediaDocument, wantMime: "application/octet-stream"},
+		{name: "empty file falls back to extension", file: "empty.png", content: nil, wantType: whatsmeow.MediaImage, wantMime: "image/png"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			gotType, gotMime := classify(path)
+			if gotType != tt.wantType || gotMime != tt.wantMime {
+				t.Errorf("classify(%s) = %s, %s, want %s, %s", tt.file, gotType, gotMime, tt.wantType, tt.wantMime)
+			}
+		})
+	}
+}
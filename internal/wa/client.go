@@ -7,6 +7,9 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog"
 	"go.mau.fi/whatsmeow"
@@ -14,6 +17,7 @@ import (
 	waLog "go.mau.fi/whatsmeow/util/log"
 
 	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/webhook"
 )
 
 // Client wraps a WhatsApp client with store integration and logging.
@@ -22,13 +26,59 @@ type Client struct {
 	Store   *store.DB
 	Logger  *slog.Logger
 	BaseDir string
+
+	// MaxMessagesPerChat caps stored messages per chat; 0 disables pruning.
+	MaxMessagesPerChat int
+	// HistoryMaxAge makes history sync skip messages older than this; 0 keeps everything.
+	HistoryMaxAge time.Duration
+
+	// Webhook receives lifecycle and message events; nil disables notifications.
+	Webhook *webhook.Notifier
+	// WebhookRedactContent omits content previews from message events.
+	WebhookRedactContent bool
+
+	// OnMessage, if set, is called after each incoming message is persisted.
+	OnMessage func(IncomingMessage)
+
+	historySynced   chan struct{} // Closed once the first history sync batch is persisted
+	historyOnce     sync.Once
+	historyBatches  atomic.Int32
+	historyProgress atomic.Uint32
+	historyComplete atomic.Bool // Set once a history sync batch reports 100% progress
+
+	chatNamesBackfilled atomic.Int64 // Unix nanoseconds of the last completed backfillChatNames run
+
+	lastKeepalive atomic.Int64 // Unix nanoseconds of the last keepalive that found the connection up
+
+	reconnectMu   sync.Mutex
+	reconnect     ReconnectState
+	reconnectStop chan struct{} // Closed to abandon the running reconnect loop
+	connOverride  connection    // Replaces WA for connecting and reconnecting; nil uses WA
+
+	presenceSubscribed sync.Map // Contact JIDs already subscribed to for typing indicators
+}
+
+// IncomingMessage describes a persisted incoming message for OnMessage hooks.
+type IncomingMessage struct {
+	ID        string
+	ChatJID   string
+	ChatName  string
+	Sender    string
+	Content   string
+	MediaType string
+	Timestamp time.Time
 }
 
 // New creates a new WhatsApp client with the given store and configuration.
-func New(db *store.DB, baseDir string, logLevel string, appLogger *slog.Logger) (*Client, error) {
+// Downloaded media is written under baseDir, while the encrypted session database
+// lives in sessionDir (defaulting to baseDir).
+func New(db *store.DB, baseDir, sessionDir string, logLevel string, appLogger *slog.Logger) (*Client, error) {
 	if baseDir == "" {
 		baseDir = "store"
 	}
+	if sessionDir == "" {
+		sessionDir = baseDir
+	}
 	if logLevel == "" {
 		logLevel = "INFO"
 	}
@@ -71,8 +121,11 @@ func New(db *store.DB, baseDir string, logLevel string, appLogger *slog.Logger)
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create store dir: %w", err)
 	}
+	if err := os.MkdirAll(sessionDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create session dir: %w", err)
+	}
 
-	waDBURI := fmt.Sprintf("file:%s/whatsapp.db?_foreign_keys=on", baseDir)
+	waDBURI := fmt.Sprintf("file:%s/whatsapp.db?_foreign_keys=on", sessionDir)
 	container, err := sqlstore.New(context.Background(), "sqlite3", waDBURI, dbLog)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open wa session db: %w", err)
@@ -92,7 +145,10 @@ func New(db *store.DB, baseDir string, logLevel string, appLogger *slog.Logger)
 		return nil, fmt.Errorf("failed to create client")
 	}
 
-	c := &Client{WA: client, Store: db, Logger: appLogger, BaseDir: baseDir}
+	// Unexpected disconnects are retried by reconnectLoop with capped backoff instead
+	client.EnableAutoReconnect = false
+
+	c := &Client{WA: client, Store: db, Logger: appLogger, BaseDir: baseDir, historySynced: make(chan struct{})}
 	c.registerHandlers()
 
 	return c, nil
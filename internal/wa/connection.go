@@ -2,48 +2,323 @@ package wa
 
 import (
 	"context"
+	"fmt"
+	"math/rand/v2"
 	"os"
+	"time"
 
 	"github.com/mdp/qrterminal"
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/eddmann/whatsapp-mcp/internal/webhook"
 )
 
 // registerHandlers registers event handlers for WhatsApp events.
 func (c *Client) registerHandlers() {
-	c.WA.AddEventHandler(func(evt interface{}) {
-		switch v := evt.(type) {
-		case *events.Message:
-			c.handleMessage(v)
-		case *events.HistorySync:
-			c.handleHistorySync(v)
-		case *events.Connected:
-			c.Logger.Info("connected")
-			// After connecting, backfill chat names from contacts/groups
-			go c.backfillChatNames()
-		case *events.LoggedOut:
-			c.Logger.Warn("logged out")
-		}
-	})
-}
-
-// ConnectWithQR connects to WhatsApp, displaying a QR code if needed.
-func (c *Client) ConnectWithQR(ctx context.Context) error {
-	if c.WA.Store.ID == nil {
-		qrChan, _ := c.WA.GetQRChannel(ctx)
-		if err := c.WA.Connect(); err != nil {
-			return err
+	c.WA.AddEventHandler(c.handleEvent)
+}
+
+// handleEvent dispatches a whatsmeow event to its handler.
+func (c *Client) handleEvent(evt interface{}) {
+	switch v := evt.(type) {
+	case *events.Message:
+		c.handleMessage(v)
+	case *events.HistorySync:
+		c.handleHistorySync(v)
+	case *events.Receipt:
+		c.handleReceipt(v)
+	case *events.MarkChatAsRead:
+		c.handleMarkChatAsRead(v)
+	case *events.Archive:
+		c.handleArchive(v)
+	case *events.Pin:
+		c.handlePin(v)
+	case *events.Mute:
+		c.handleMute(v)
+	case *events.Star:
+		c.handleStar(v)
+	case *events.Blocklist:
+		c.handleBlocklist(v)
+	case *events.Connected:
+		c.Logger.Info("connected")
+		c.reconnectMu.Lock()
+		c.reconnect.LoggedOut = false
+		c.reconnectMu.Unlock()
+		// After connecting, backfill chat and contact names from contacts/groups
+		go c.backfillChatNames()
+		go c.backfillContacts()
+		go func() {
+			if err := c.RefreshBlocklist(); err != nil {
+				c.Logger.Warn("failed to refresh blocklist", "err", err)
+			}
+		}()
+		c.Webhook.Notify(webhook.EventConnected, c.accountData())
+	case *events.Disconnected:
+		c.Logger.Warn("disconnected")
+		c.Webhook.Notify(webhook.EventDisconnected, nil)
+		c.startReconnect(false)
+	case *events.KeepAliveTimeout:
+		// The websocket still looks open, so the reconnect has to tear it down
+		if time.Since(v.LastSuccess) > whatsmeow.KeepAliveMaxFailTime {
+			c.Logger.Warn("keepalive pings failing", "errors", v.ErrorCount, "last_success", v.LastSuccess)
+			c.startReconnect(true)
 		}
+	case *events.LoggedOut:
+		c.Logger.Warn("logged out")
+		c.stopReconnect()
+		c.Webhook.Notify(webhook.EventLoggedOut, map[string]any{"reason": v.Reason.String()})
+	}
+}
+
+// accountData returns the logged-in account's JID for webhook payloads.
+func (c *Client) accountData() map[string]any {
+	if c.WA.Store.ID == nil {
+		return nil
+	}
+	return map[string]any{"jid": c.WA.Store.ID.ToNonAD().String()}
+}
+
+// Connect connects to WhatsApp. Without a paired session it starts pairing: a
+// QR code is drawn on stderr, or, when pairPhone is set, an 8-digit pairing code
+// for that number is printed instead, to enter under Linked devices > Link with
+// phone number.
+func (c *Client) Connect(ctx context.Context, pairPhone string) error {
+	if c.WA.Store.ID != nil {
+		return c.WA.Connect()
+	}
 
-		for evt := range qrChan {
-			if evt.Event == "code" {
+	qrChan, _ := c.WA.GetQRChannel(ctx)
+	if err := c.WA.Connect(); err != nil {
+		return err
+	}
+
+	codeRequested := false
+	for evt := range qrChan {
+		switch evt.Event {
+		case "code":
+			if pairPhone == "" {
 				qrterminal.GenerateHalfBlock(evt.Code, qrterminal.L, os.Stderr)
-			} else if evt.Event == "success" {
-				break
+				continue
+			}
+			// The pairing code is requested once the socket is ready, signalled by the first QR
+			if codeRequested {
+				continue
+			}
+			codeRequested = true
+			code, err := c.WA.PairPhone(ctx, pairPhone, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
+			if err != nil {
+				return fmt.Errorf("failed to request pairing code for +%s: %w", pairPhone, err)
 			}
+			fmt.Fprintf(os.Stderr, "WhatsApp pairing code for +%s: %s\nOn your phone open Linked devices > Link a device > Link with phone number instead, and enter the code.\n", pairPhone, code)
+		case "success":
+			return nil
+		case "err-scanned-without-multidevice":
+			c.Logger.Warn("QR code was scanned by a phone without multi-device enabled; still waiting for pairing")
+		case "timeout":
+			return fmt.Errorf("pairing timed out")
+		case "error":
+			return fmt.Errorf("pairing failed: %w", evt.Error)
+		default:
+			return fmt.Errorf("pairing failed: %s", evt.Event)
 		}
+	}
 
-		return nil
+	return nil
+}
+
+// reconnectTimeout bounds how long Reconnect waits for the new connection to log in.
+const reconnectTimeout = 30 * time.Second
+
+// Reconnect drops the current connection and connects again with the stored
+// session, waiting until the client is logged in or reconnectTimeout passes.
+// It refuses to run without a paired session, since connecting would only
+// start QR pairing on the server's terminal.
+func (c *Client) Reconnect() error {
+	if c.WA.Store.ID == nil {
+		return fmt.Errorf("no paired session; restart the server and pair with the QR code or PAIR_PHONE code to log in")
+	}
+
+	c.Logger.Info("reconnecting")
+	conn := c.conn()
+	conn.Disconnect()
+	if err := conn.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if !conn.WaitForConnection(reconnectTimeout) {
+		return fmt.Errorf("connection not established within %s", reconnectTimeout)
+	}
+	return nil
+}
+
+// RunKeepalive checks the connection every interval until ctx is done. Some NATs
+// silently drop idle websockets, so if the connection is found down while a session
+// is paired, it reconnects rather than waiting for whatsmeow to notice.
+func (c *Client) RunKeepalive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.conn().IsConnected() {
+			c.lastKeepalive.Store(time.Now().UnixNano())
+			continue
+		}
+		if c.WA.Store.ID == nil || c.ReconnectState().Active {
+			continue
+		}
+		c.Logger.Warn("keepalive found connection down")
+		if err := c.Reconnect(); err != nil {
+			c.Logger.Warn("keepalive reconnect failed", "err", err)
+			continue
+		}
+		c.lastKeepalive.Store(time.Now().UnixNano())
+	}
+}
+
+// LastKeepalive returns when the keepalive last found the connection up, or the
+// zero time if it hasn't yet.
+func (c *Client) LastKeepalive() time.Time {
+	n := c.lastKeepalive.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Backoff bounds for reconnectLoop: the delay doubles from reconnectBaseDelay on
+// each failed attempt up to reconnectMaxDelay.
+const (
+	reconnectBaseDelay = 2 * time.Second
+	reconnectMaxDelay  = 5 * time.Minute
+)
+
+// ReconnectState describes automatic reconnection after an unexpected disconnect.
+type ReconnectState struct {
+	Active      bool      // A reconnect loop is running
+	Attempts    int       // Attempts made by the current or most recent loop
+	NextAttempt time.Time // When the next attempt is due; zero when not active
+	LastError   string    // Why the most recent attempt failed, if it did
+	LoggedOut   bool      // The session was logged out, so no reconnect is tried
+}
+
+// ReconnectState returns a snapshot of the automatic reconnect state.
+func (c *Client) ReconnectState() ReconnectState {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	return c.reconnect
+}
+
+// connection is the part of the whatsmeow client that Reconnect drives.
+type connection interface {
+	IsConnected() bool
+	Connect() error
+	Disconnect()
+	WaitForConnection(timeout time.Duration) bool
+}
+
+// conn returns the connection to reconnect: connOverride when set, otherwise WA.
+func (c *Client) conn() connection {
+	if c.connOverride != nil {
+		return c.connOverride
+	}
+	return c.WA
+}
+
+// startReconnect starts reconnectLoop unless one is already running, there is no
+// paired session, or the session was logged out. With force, every attempt tears
+// the connection down and reconnects even if the websocket still reports open.
+func (c *Client) startReconnect(force bool) {
+	if c.WA.Store.ID == nil {
+		return
 	}
 
-	return c.WA.Connect()
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	if c.reconnect.Active || c.reconnect.LoggedOut {
+		return
+	}
+	stop := make(chan struct{})
+	c.reconnect = ReconnectState{Active: true}
+	c.reconnectStop = stop
+	go c.reconnectLoop(stop, force)
+}
+
+// stopReconnect records that the session was logged out and abandons any running
+// reconnect loop, since the stored credentials are no longer valid.
+func (c *Client) stopReconnect() {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	c.reconnect.LoggedOut = true
+	if c.reconnect.Active {
+		close(c.reconnectStop)
+		c.reconnect.Active = false
+		c.reconnect.NextAttempt = time.Time{}
+	}
+}
+
+// reconnectLoop calls Reconnect with capped exponential backoff and jitter until
+// the connection is back or stop is closed. Unless force is set, an attempt is
+// skipped when the connection is already up again.
+func (c *Client) reconnectLoop(stop chan struct{}, force bool) {
+	for attempt := 1; ; attempt++ {
+		delay := reconnectDelay(attempt)
+		c.reconnectMu.Lock()
+		c.reconnect.NextAttempt = time.Now().Add(delay)
+		c.reconnectMu.Unlock()
+
+		c.Logger.Info("reconnect scheduled", "attempt", attempt, "delay", delay.Round(time.Millisecond))
+		select {
+		case <-stop:
+			c.Logger.Info("reconnect abandoned: logged out")
+			return
+		case <-time.After(delay):
+		}
+
+		c.reconnectMu.Lock()
+		c.reconnect.Attempts = attempt
+		c.reconnectMu.Unlock()
+
+		// The reconnect tool or whatsmeow itself may have restored the connection meanwhile
+		var err error
+		if force || !c.conn().IsConnected() {
+			c.Logger.Info("reconnect attempt", "attempt", attempt)
+			err = c.Reconnect()
+		}
+
+		c.reconnectMu.Lock()
+		select {
+		case <-stop:
+			c.reconnectMu.Unlock()
+			return
+		default:
+		}
+		if err == nil {
+			c.reconnect.Active = false
+			c.reconnect.NextAttempt = time.Time{}
+			c.reconnect.LastError = ""
+			c.reconnectMu.Unlock()
+			c.Logger.Info("reconnected", "attempts", attempt)
+			return
+		}
+		c.reconnect.LastError = err.Error()
+		c.reconnectMu.Unlock()
+		c.Logger.Warn("reconnect attempt failed", "attempt", attempt, "err", err)
+	}
+}
+
+// reconnectDelay returns the wait before the given attempt (counting from 1): the
+// capped exponential delay, with the upper half randomised so that many clients
+// dropped at once don't retry in lockstep.
+func reconnectDelay(attempt int) time.Duration {
+	d := reconnectMaxDelay
+	if attempt <= 20 {
+		d = min(reconnectBaseDelay<<(attempt-1), reconnectMaxDelay)
+	}
+	return d/2 + rand.N(d/2+1)
 }
@@ -0,0 +1,100 @@
+package wa
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// fakeConn is a connection that records calls and comes up on Connect.
+type fakeConn struct {
+	mu          sync.Mutex
+	connected   bool
+	connects    int
+	disconnects int
+}
+
+func (f *fakeConn) IsConnected() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeConn) Connect() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connects++
+	f.connected = true
+	return nil
+}
+
+func (f *fakeConn) Disconnect() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disconnects++
+	f.connected = false
+}
+
+func (f *fakeConn) WaitForConnection(time.Duration) bool { return f.IsConnected() }
+
+func (f *fakeConn) calls() (connects, disconnects int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connects, f.disconnects
+}
+
+func TestReconnectAfterEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		evt           any
+		connected     bool
+		wantReconnect bool
+	}{
+		{name: "remote drop", evt: &events.Disconnected{}, wantReconnect: true},
+		{name: "drop already recovered", evt: &events.Disconnected{}, connected: true},
+		{
+			name:          "keepalive timeout on an open socket",
+			evt:           &events.KeepAliveTimeout{ErrorCount: 5, LastSuccess: time.Now().Add(-time.Hour)},
+			connected:     true,
+			wantReconnect: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient(t)
+			setOwnNumber(c, "447700900000")
+			conn := &fakeConn{connected: tt.connected}
+			c.connOverride = conn
+
+			c.handleEvent(tt.evt)
+			if !c.ReconnectState().Active {
+				t.Fatal("no reconnect started")
+			}
+			deadline := time.Now().Add(10 * time.Second)
+			for c.ReconnectState().Active {
+				if time.Now().After(deadline) {
+					t.Fatal("reconnect loop still running")
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			connects, disconnects := conn.calls()
+			if got := connects > 0; got != tt.wantReconnect {
+				t.Errorf("Connect called %d times, want reconnect %v", connects, tt.wantReconnect)
+			}
+			if tt.wantReconnect && disconnects == 0 {
+				t.Error("Disconnect not called before reconnecting")
+			}
+			if !conn.IsConnected() {
+				t.Error("connection left down")
+			}
+			if state := c.ReconnectState(); state.LastError != "" {
+				t.Errorf("last error = %q", state.LastError)
+			}
+		})
+	}
+}
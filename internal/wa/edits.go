@@ -0,0 +1,83 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// editWindow is how long after sending WhatsApp accepts edits to a message.
+const editWindow = 15 * time.Minute
+
+// EditMessage replaces the text of a message the logged-in account sent, and
+// records the edit locally. Only the account's own messages, sent within the
+// last 15 minutes, can be edited.
+func (c *Client) EditMessage(chatJID, messageID, newText string) (*SendMessageResult, error) {
+	if !c.WA.IsConnected() {
+		return &SendMessageResult{Success: false, Message: "not connected"}, fmt.Errorf("not connected")
+	}
+
+	chat, err := types.ParseJID(chatJID)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: "invalid chat JID"}, err
+	}
+
+	msg, err := c.Store.GetMessage(chatJID, messageID)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: err.Error()}, err
+	}
+	if msg == nil {
+		return &SendMessageResult{Success: false, Message: "message not found"}, fmt.Errorf("message %s not found in chat %s", messageID, chatJID)
+	}
+	if !msg.IsFromMe {
+		return &SendMessageResult{Success: false, Message: "not your message"}, fmt.Errorf("only messages you sent can be edited")
+	}
+	if msg.IsDeleted {
+		return &SendMessageResult{Success: false, Message: "message deleted"}, fmt.Errorf("message %s was deleted", messageID)
+	}
+	if time.Since(msg.Timestamp) > editWindow {
+		return &SendMessageResult{Success: false, Message: "edit window expired"}, fmt.Errorf("messages can only be edited within %s of sending", editWindow)
+	}
+
+	edit := c.WA.BuildEdit(chat, messageID, &waE2E.Message{Conversation: protoString(newText)})
+	resp, err := c.WA.SendMessage(context.Background(), chat, edit)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: err.Error()}, err
+	}
+
+	c.applyEdit(chatJID, messageID, newText, resp.Timestamp)
+
+	return &SendMessageResult{
+		Success:   true,
+		Message:   fmt.Sprintf("edited %s in %s", messageID, chatJID),
+		MessageID: messageID,
+		ChatJID:   chat.String(),
+		Timestamp: resp.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// applyEdit stores a message's edited text, keeping the previous content as history.
+func (c *Client) applyEdit(chatJID, messageID, content string, editedAt time.Time) {
+	if messageID == "" || content == "" {
+		return
+	}
+	found, err := c.Store.EditMessageContent(chatJID, messageID, content, editedAt)
+	if err != nil {
+		c.Logger.Warn("failed to store message edit", "id", messageID, "chat_jid", chatJID, "err", err)
+		return
+	}
+	if !found {
+		c.Logger.Debug("edited message not stored", "id", messageID, "chat_jid", chatJID)
+	}
+}
+
+// editTime returns when an edit protocol message was made, falling back to fallback.
+func editTime(pm *waE2E.ProtocolMessage, fallback time.Time) time.Time {
+	if ms := pm.GetTimestampMS(); ms > 0 {
+		return time.UnixMilli(ms)
+	}
+	return fallback
+}
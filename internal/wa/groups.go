@@ -0,0 +1,467 @@
+package wa
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// GetGroupMetadata returns group metadata from the local cache when it is newer
+// than maxAge, otherwise fetches it via GetGroupInfo and refreshes the cache.
+func (c *Client) GetGroupMetadata(groupJID string, maxAge time.Duration) (*domain.Group, error) {
+	cached, err := c.Store.GetGroup(groupJID)
+	if err != nil {
+		c.Logger.Warn("group cache lookup failed", "jid", groupJID, "err", err)
+	}
+	if cached != nil && time.Since(cached.UpdatedAt) < maxAge {
+		return cached, nil
+	}
+
+	return c.RefreshGroupMetadata(groupJID)
+}
+
+// RefreshGroupMetadata fetches group info from WhatsApp and updates the local cache.
+func (c *Client) RefreshGroupMetadata(groupJID string) (*domain.Group, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group JID: %w", err)
+	}
+	if jid.Server != types.GroupServer {
+		return nil, fmt.Errorf("%s is not a group", groupJID)
+	}
+	if !c.WA.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	info, err := c.WA.GetGroupInfo(jid)
+	if err != nil {
+		return nil, err
+	}
+
+	g := domain.Group{
+		JID:              groupJID,
+		Name:             info.Name,
+		ParticipantCount: len(info.Participants),
+		UpdatedAt:        time.Now(),
+	}
+	for _, p := range info.Participants {
+		if c.isOwnJID(p.JID) || c.isOwnJID(p.PhoneNumber) || c.isOwnJID(p.LID) {
+			g.IsAdmin = p.IsAdmin || p.IsSuperAdmin
+			break
+		}
+	}
+
+	if err := c.Store.UpsertGroup(g); err != nil {
+		c.Logger.Warn("failed to cache group metadata", "jid", groupJID, "err", err)
+	}
+
+	return &g, nil
+}
+
+// Invite link states reported by GetGroupDetails.
+const (
+	InviteLinkAvailable   = "available"
+	InviteLinkAdminOnly   = "admin_only"
+	InviteLinkUnavailable = "unavailable"
+)
+
+// GetGroupDetails fetches a group's live metadata from WhatsApp, naming each
+// participant from contacts where possible. The invite link is only readable by
+// admins; for others InviteLinkState is InviteLinkAdminOnly. Also refreshes the
+// cached group metadata.
+func (c *Client) GetGroupDetails(groupJID string) (*domain.GroupDetails, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group JID: %w", err)
+	}
+	if jid.Server != types.GroupServer {
+		return nil, fmt.Errorf("%s is not a group", groupJID)
+	}
+	if !c.WA.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	info, err := c.WA.GetGroupInfo(jid)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &domain.GroupDetails{
+		JID:                  groupJID,
+		Name:                 info.Name,
+		Description:          info.Topic,
+		AnnounceOnly:         info.IsAnnounce,
+		Locked:               info.IsLocked,
+		JoinApprovalRequired: info.IsJoinApprovalRequired,
+		ParticipantCount:     len(info.Participants),
+		Participants:         make([]domain.GroupParticipant, 0, len(info.Participants)),
+	}
+	if !info.GroupCreated.IsZero() {
+		created := info.GroupCreated
+		details.CreatedAt = &created
+	}
+	if owner := info.OwnerPN; !owner.IsEmpty() {
+		details.Owner = owner.ToNonAD().String()
+	} else if !info.OwnerJID.IsEmpty() {
+		details.Owner = info.OwnerJID.ToNonAD().String()
+	}
+
+	for _, p := range info.Participants {
+		// Prefer the phone number JID: contacts are keyed by it, and it's what a DM needs
+		pj := p.JID
+		if !p.PhoneNumber.IsEmpty() {
+			pj = p.PhoneNumber
+		}
+		participant := domain.GroupParticipant{
+			JID:          pj.ToNonAD().String(),
+			IsAdmin:      p.IsAdmin || p.IsSuperAdmin,
+			IsSuperAdmin: p.IsSuperAdmin,
+			IsMe:         c.isOwnJID(p.JID) || c.isOwnJID(p.PhoneNumber) || c.isOwnJID(p.LID),
+		}
+		if name := c.resolvePreferredName(pj.ToNonAD()); name != "" && name != pj.User {
+			participant.Name = &name
+		}
+		if participant.IsMe {
+			details.IsAdmin = participant.IsAdmin
+		}
+		details.Participants = append(details.Participants, participant)
+	}
+
+	details.InviteLinkState = InviteLinkAdminOnly
+	if details.IsAdmin {
+		if link, err := c.WA.GetGroupInviteLink(jid, false); err == nil && link != "" {
+			details.InviteLinkState = InviteLinkAvailable
+			details.InviteLink = link
+		} else {
+			details.InviteLinkState = InviteLinkUnavailable
+			if err != nil {
+				c.Logger.Debug("failed to get group invite link", "jid", groupJID, "err", err)
+			}
+		}
+	}
+
+	if err := c.Store.UpsertGroup(domain.Group{
+		JID:              groupJID,
+		Name:             info.Name,
+		ParticipantCount: len(info.Participants),
+		IsAdmin:          details.IsAdmin,
+		UpdatedAt:        time.Now(),
+	}); err != nil {
+		c.Logger.Warn("failed to cache group metadata", "jid", groupJID, "err", err)
+	}
+
+	return details, nil
+}
+
+// ErrNotGroupAdmin is returned when a group change needs admin rights the user lacks.
+var ErrNotGroupAdmin = errors.New("you must be a group admin to do this")
+
+// Group invite errors, re-exported so callers can match them without importing whatsmeow.
+var (
+	ErrInviteLinkInvalid = whatsmeow.ErrInviteLinkInvalid
+	ErrInviteLinkRevoked = whatsmeow.ErrInviteLinkRevoked
+)
+
+// GetGroupInviteLink returns a group's invite URL. With reset, the current link is
+// revoked and a new one generated; that requires being a group admin, which is
+// checked against fresh metadata first so non-admins get ErrNotGroupAdmin.
+func (c *Client) GetGroupInviteLink(groupJID string, reset bool) (string, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return "", fmt.Errorf("invalid group JID: %w", err)
+	}
+	if jid.Server != types.GroupServer {
+		return "", fmt.Errorf("%s is not a group", groupJID)
+	}
+	if !c.WA.IsConnected() {
+		return "", fmt.Errorf("not connected")
+	}
+
+	if reset {
+		g, err := c.RefreshGroupMetadata(groupJID)
+		if err != nil {
+			return "", err
+		}
+		if !g.IsAdmin {
+			return "", ErrNotGroupAdmin
+		}
+	}
+
+	link, err := c.WA.GetGroupInviteLink(jid, reset)
+	if errors.Is(err, whatsmeow.ErrGroupInviteLinkUnauthorized) {
+		return "", ErrNotGroupAdmin
+	}
+	return link, err
+}
+
+// SetGroupSubject renames a group and updates the stored chat and group names.
+// Returns ErrNotGroupAdmin if the group only lets admins edit its info.
+func (c *Client) SetGroupSubject(groupJID, subject string) error {
+	jid, err := c.groupForUpdate(groupJID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.WA.SetGroupName(jid, subject); err != nil {
+		return groupUpdateError(err)
+	}
+	if err := c.Store.RenameChat(groupJID, subject); err != nil {
+		c.Logger.Warn("failed to store new group subject", "jid", groupJID, "err", err)
+	}
+	return nil
+}
+
+// SetGroupDescription replaces a group's description; an empty description
+// clears it. Returns ErrNotGroupAdmin if the group only lets admins edit its info.
+func (c *Client) SetGroupDescription(groupJID, description string) error {
+	jid, err := c.groupForUpdate(groupJID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.WA.SetGroupTopic(jid, "", "", description); err != nil {
+		return groupUpdateError(err)
+	}
+	if _, err := c.RefreshGroupMetadata(groupJID); err != nil {
+		c.Logger.Debug("failed to refresh group metadata", "jid", groupJID, "err", err)
+	}
+	return nil
+}
+
+// groupForUpdate parses a group JID and checks the client can send changes.
+func (c *Client) groupForUpdate(groupJID string) (types.JID, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return types.EmptyJID, fmt.Errorf("invalid group JID: %w", err)
+	}
+	if jid.Server != types.GroupServer {
+		return types.EmptyJID, fmt.Errorf("%s is not a group", groupJID)
+	}
+	if !c.WA.IsConnected() {
+		return types.EmptyJID, fmt.Errorf("not connected")
+	}
+	return jid, nil
+}
+
+// groupUpdateError maps WhatsApp's permission errors for group changes.
+func groupUpdateError(err error) error {
+	switch {
+	case errors.Is(err, whatsmeow.ErrIQNotAuthorized):
+		return ErrNotGroupAdmin
+	case errors.Is(err, whatsmeow.ErrIQForbidden):
+		return fmt.Errorf("you are not a member of this group: %w", err)
+	}
+	return err
+}
+
+// JoinGroupWithLink joins a group using an invite link or bare invite code and
+// records it in the chats table. If the group requires admin approval, a join
+// request is sent instead and PendingApproval is set on the result.
+func (c *Client) JoinGroupWithLink(code string) (*domain.GroupJoinResult, error) {
+	if !c.WA.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	// Look the group up first: it validates the code and gives us the name
+	info, err := c.WA.GetGroupInfoFromLink(code)
+	if err != nil {
+		return nil, err
+	}
+
+	jid, err := c.WA.JoinGroupWithLink(code)
+	if err != nil {
+		return nil, err
+	}
+	if jid.IsEmpty() {
+		jid = info.JID
+	}
+
+	result := &domain.GroupJoinResult{
+		JID:             jid.String(),
+		Name:            info.Name,
+		PendingApproval: info.IsJoinApprovalRequired,
+	}
+	if result.PendingApproval {
+		return result, nil
+	}
+
+	if err := c.Store.UpsertChat(result.JID, info.Name, time.Now()); err != nil {
+		c.Logger.Warn("failed to store joined group", "jid", result.JID, "err", err)
+	}
+	if _, err := c.RefreshGroupMetadata(result.JID); err != nil {
+		c.Logger.Debug("failed to refresh joined group metadata", "jid", result.JID, "err", err)
+	}
+
+	return result, nil
+}
+
+// ParticipantAction is a change to apply to group participants.
+type ParticipantAction = whatsmeow.ParticipantChange
+
+// Participant actions accepted by UpdateGroupParticipants.
+const (
+	ParticipantAdd     = whatsmeow.ParticipantChangeAdd
+	ParticipantRemove  = whatsmeow.ParticipantChangeRemove
+	ParticipantPromote = whatsmeow.ParticipantChangePromote
+	ParticipantDemote  = whatsmeow.ParticipantChangeDemote
+)
+
+// participantErrors explains the error codes WhatsApp returns per participant.
+var participantErrors = map[int]string{
+	401: "not authorized; you must be a group admin",
+	403: "blocked by their privacy settings; send them the group invite link instead",
+	404: "not on WhatsApp or not a member of this group",
+	406: "not allowed for this participant",
+	408: "they recently left the group and can't be re-added yet",
+	409: "already a member of the group",
+	500: "the group is full",
+}
+
+// UpdateGroupParticipants applies action to each user JID in a group and reports
+// the outcome per participant, in input order. The cached group metadata is
+// refreshed afterwards.
+func (c *Client) UpdateGroupParticipants(groupJID string, userJIDs []string, action ParticipantAction) ([]domain.ParticipantChangeResult, error) {
+	users := make([]types.JID, len(userJIDs))
+	for i, u := range userJIDs {
+		parsed, err := types.ParseJID(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid participant JID %q: %w", u, err)
+		}
+		users[i] = parsed
+	}
+
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group JID: %w", err)
+	}
+	if jid.Server != types.GroupServer {
+		return nil, fmt.Errorf("%s is not a group", groupJID)
+	}
+	if !c.WA.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	changed, err := c.WA.UpdateGroupParticipants(jid, users, action)
+	if err != nil {
+		return nil, err
+	}
+
+	// The response may address participants by LID, so match on either identity
+	byUser := make(map[string]types.GroupParticipant, len(changed))
+	for _, p := range changed {
+		byUser[p.JID.User] = p
+		if !p.PhoneNumber.IsEmpty() {
+			byUser[p.PhoneNumber.User] = p
+		}
+	}
+
+	results := make([]domain.ParticipantChangeResult, len(users))
+	for i, u := range users {
+		results[i] = domain.ParticipantChangeResult{Participant: u.User, JID: u.String()}
+		p, ok := byUser[u.User]
+		switch {
+		case !ok:
+			results[i].Error = "no result returned for this participant"
+		case p.Error != 0:
+			results[i].Error = participantErrors[p.Error]
+			if results[i].Error == "" {
+				results[i].Error = fmt.Sprintf("failed with error code %d", p.Error)
+			}
+		default:
+			results[i].Success = true
+		}
+	}
+
+	if _, err := c.RefreshGroupMetadata(groupJID); err != nil {
+		c.Logger.Warn("failed to refresh group metadata", "jid", groupJID, "err", err)
+	}
+
+	return results, nil
+}
+
+// ErrNoGroupPicture is returned when a group has no profile picture set.
+var ErrNoGroupPicture = whatsmeow.ErrProfilePictureNotSet
+
+// pictureDownloadTimeout bounds the HTTP download of a profile picture.
+const pictureDownloadTimeout = 30 * time.Second
+
+// DownloadGroupPicture fetches a group's full-resolution profile photo and saves it
+// under the group's media folder, returning the picture ID and absolute file path.
+// Returns ErrNoGroupPicture if the group has no photo.
+func (c *Client) DownloadGroupPicture(groupJID string) (pictureID, path string, err error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid group JID: %w", err)
+	}
+	if jid.Server != types.GroupServer {
+		return "", "", fmt.Errorf("%s is not a group", groupJID)
+	}
+	if !c.WA.IsConnected() {
+		return "", "", fmt.Errorf("not connected")
+	}
+
+	info, err := c.WA.GetProfilePictureInfo(jid, &whatsmeow.GetProfilePictureParams{})
+	if err != nil {
+		return "", "", err
+	}
+	if info == nil || info.URL == "" {
+		return "", "", ErrNoGroupPicture
+	}
+
+	path, err = c.savePicture(info.URL, groupJID, fmt.Sprintf("group_picture_%s.jpg", info.ID), "group_picture.jpg")
+	if err != nil {
+		return "", "", err
+	}
+	return info.ID, path, nil
+}
+
+// savePicture downloads a profile picture URL into the chat's media folder and
+// returns the absolute path of the saved file.
+func (c *Client) savePicture(url, chatJID, filename, fallback string) (string, error) {
+	httpClient := &http.Client{Timeout: pictureDownloadTimeout}
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download picture: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download picture: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download picture: %w", err)
+	}
+
+	outDir := filepath.Join(c.BaseDir, strings.ReplaceAll(chatJID, ":", "_"))
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", err
+	}
+	out := filepath.Join(outDir, sanitizeFilename(filename, fallback))
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return "", err
+	}
+
+	abs, _ := filepath.Abs(out)
+	return abs, nil
+}
+
+// isOwnJID reports whether jid refers to the logged-in account (phone number or LID).
+func (c *Client) isOwnJID(jid types.JID) bool {
+	if jid.IsEmpty() || c.WA == nil || c.WA.Store == nil {
+		return false
+	}
+	if c.WA.Store.ID != nil && jid.User == c.WA.Store.ID.User && jid.Server == c.WA.Store.ID.Server {
+		return true
+	}
+	return !c.WA.Store.LID.IsEmpty() && jid.User == c.WA.Store.LID.User && jid.Server == c.WA.Store.LID.Server
+}
@@ -1,13 +1,19 @@
 package wa
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"mime"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"go.mau.fi/whatsmeow"
 	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
 )
 
 // extractTextContent extracts text content from a WhatsApp message.
@@ -16,13 +22,19 @@ func extractTextContent(m *waE2E.Message) string {
 		return ""
 	}
 
-	// Basic text messages
-	if t := m.GetConversation(); t != "" {
-		return t
+	// Basic text messages. Some messages carry both fields, so keep whichever
+	// holds more text rather than letting one shadow the other. A link preview
+	// only stands in when the sender wrote nothing.
+	text := m.GetConversation()
+	et := m.GetExtendedTextMessage()
+	if ext := et.GetText(); len(ext) > len(text) {
+		text = ext
 	}
-
-	if et := m.GetExtendedTextMessage(); et != nil {
-		return et.GetText()
+	if text == "" && et != nil {
+		text = linkPreviewText(et)
+	}
+	if text != "" {
+		return text
 	}
 
 	// Location messages
@@ -32,11 +44,7 @@ func extractTextContent(m *waE2E.Message) string {
 
 	// Contact messages
 	if contact := m.GetContactMessage(); contact != nil {
-		name := contact.GetDisplayName()
-		if name == "" {
-			name = "Contact"
-		}
-		return fmt.Sprintf("👤 %s", name)
+		return contactText(contact)
 	}
 
 	// Sticker messages
@@ -50,18 +58,13 @@ func extractTextContent(m *waE2E.Message) string {
 	}
 
 	// Poll messages
-	if poll := m.GetPollCreationMessage(); poll != nil {
+	if poll := pollCreation(m); poll != nil {
 		return fmt.Sprintf("📊 Poll: %s", poll.GetName())
 	}
 
-	// Reaction messages
-	if reaction := m.GetReactionMessage(); reaction != nil {
-		return fmt.Sprintf("😊 Reaction: %s", reaction.GetText())
-	}
-
 	// System messages and other types
 	if m.GetProtocolMessage() != nil {
-		return "🔧 System Message"
+		return domain.SystemMessageContent
 	}
 
 	return ""
@@ -82,10 +85,7 @@ func extractMediaInfo(m *waE2E.Message) (mediaType, filename, url string, mediaK
 		return "audio", fmt.Sprintf("audio_%s.ogg", time.Now().Format("20060102_150405")), aud.GetURL(), aud.GetMediaKey(), aud.GetFileSHA256(), aud.GetFileEncSHA256(), aud.GetFileLength()
 	}
 	if doc := m.GetDocumentMessage(); doc != nil {
-		name := doc.GetFileName()
-		if name == "" {
-			name = fmt.Sprintf("document_%s", time.Now().Format("20060102_150405"))
-		}
+		name := withMimeExtension(sanitizeFilename(doc.GetFileName(), fmt.Sprintf("document_%s", time.Now().Format("20060102_150405"))), doc.GetMimetype())
 		return "document", name, doc.GetURL(), doc.GetMediaKey(), doc.GetFileSHA256(), doc.GetFileEncSHA256(), doc.GetFileLength()
 	}
 	if sticker := m.GetStickerMessage(); sticker != nil {
@@ -94,6 +94,75 @@ func extractMediaInfo(m *waE2E.Message) (mediaType, filename, url string, mediaK
 	return "", "", "", nil, nil, nil, 0
 }
 
+// mediaMimeType returns the MIME type the sender declared for a message's media.
+func mediaMimeType(m *waE2E.Message) string {
+	switch {
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetMimetype()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetMimetype()
+	case m.GetAudioMessage() != nil:
+		return m.GetAudioMessage().GetMimetype()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetMimetype()
+	case m.GetStickerMessage() != nil:
+		return m.GetStickerMessage().GetMimetype()
+	}
+	return ""
+}
+
+// mimeExtensions maps common WhatsApp document MIME types to file extensions,
+// ahead of the system MIME table, which varies between machines.
+var mimeExtensions = map[string]string{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+
+	"application/pdf":               ".pdf",
+	"application/msword":            ".doc",
+	"application/vnd.ms-excel":      ".xls",
+	"application/vnd.ms-powerpoint": ".ppt",
+	"application/zip":               ".zip",
+	"application/json":              ".json",
+	"text/plain":                    ".txt",
+	"text/csv":                      ".csv",
+	"text/vcard":                    ".vcf",
+	"image/jpeg":                    ".jpg",
+	"image/png":                     ".png",
+	"image/webp":                    ".webp",
+	"image/gif":                     ".gif",
+	"video/mp4":                     ".mp4",
+	"video/quicktime":               ".mov",
+	"video/3gpp":                    ".3gp",
+	"audio/ogg":                     ".ogg",
+	"audio/mpeg":                    ".mp3",
+	"audio/mp4":                     ".m4a",
+	"audio/aac":                     ".aac",
+}
+
+// mimeExtension returns the file extension for a MIME type (parameters such as
+// "; codecs=opus" are ignored), or "" if it is unknown.
+func mimeExtension(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	base = strings.ToLower(strings.TrimSpace(base))
+	if ext, ok := mimeExtensions[base]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(base); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// withMimeExtension appends the extension for mimeType to a filename that has
+// none, so the file can be opened once downloaded.
+func withMimeExtension(name, mimeType string) string {
+	if filepath.Ext(name) != "" {
+		return name
+	}
+	return name + mimeExtension(mimeType)
+}
+
 // classifyMedia classifies a file by extension for WhatsApp media types.
 func classifyMedia(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -109,6 +178,90 @@ func classifyMedia(path string) string {
 	}
 }
 
+// linkPreviewText returns the most descriptive preview field of an extended text
+// message that has no text of its own (e.g. a shared link or bare quote).
+func linkPreviewText(et *waE2E.ExtendedTextMessage) string {
+	for _, s := range []string{et.GetTitle(), et.GetMatchedText(), et.GetDescription()} {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// maxFilenameLength caps sanitized filenames, in bytes, to stay under filesystem limits.
+const maxFilenameLength = 128
+
+// sanitizeFilename makes an untrusted (sender-supplied) filename safe to write
+// inside a media directory: directory components are stripped, unsafe and control
+// characters are replaced, leading dots are removed (so ".." and hidden files
+// cannot result) and the name is truncated while keeping its extension.
+// fallback is used when nothing usable remains.
+func sanitizeFilename(name, fallback string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return -1
+		case strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	name = strings.TrimRight(name, ". ")
+
+	if name == "" {
+		name = strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r == ':' {
+				return '_'
+			}
+			return r
+		}, fallback)
+	}
+
+	if len(name) > maxFilenameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > maxFilenameLength/4 {
+			ext = ""
+		}
+		stem := name[:len(name)-len(ext)]
+		cut := maxFilenameLength - len(ext)
+		for cut > 0 && !utf8.RuneStart(stem[cut]) {
+			cut--
+		}
+		name = stem[:cut] + ext
+	}
+
+	return name
+}
+
+// suffixFilename inserts "_suffix" before a filename's extension, shortening the
+// rest of the name if needed to stay within maxFilenameLength.
+func suffixFilename(name, suffix string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if room := maxFilenameLength - len(ext) - len(suffix) - 1; len(stem) > room {
+		cut := max(room, 0)
+		for cut > 0 && !utf8.RuneStart(stem[cut]) {
+			cut--
+		}
+		stem = stem[:cut]
+	}
+	return stem + "_" + suffix + ext
+}
+
+// sameContent reports whether data hashes to the given SHA-256, i.e. an existing
+// file is the same media being downloaded again.
+func sameContent(data, sha []byte) bool {
+	sum := sha256.Sum256(data)
+	return bytes.Equal(sum[:], sha)
+}
+
 // isOgg checks if a file is an Ogg file.
 func isOgg(path string) bool {
 	return strings.ToLower(filepath.Ext(path)) == ".ogg"
@@ -158,3 +311,29 @@ func (d *downloadable) GetFileLength() uint64             { return d.FileLength
 func (d *downloadable) GetFileSHA256() []byte             { return d.FileSHA256 }
 func (d *downloadable) GetFileEncSHA256() []byte          { return d.FileEncSHA256 }
 func (d *downloadable) GetMediaType() whatsmeow.MediaType { return d.MediaType }
+
+// messageContextInfo returns the reply/mention context attached to a message, or
+// nil if it has none.
+func messageContextInfo(m *waE2E.Message) *waE2E.ContextInfo {
+	switch {
+	case m == nil:
+		return nil
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetContextInfo()
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetContextInfo()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetContextInfo()
+	case m.GetAudioMessage() != nil:
+		return m.GetAudioMessage().GetContextInfo()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetContextInfo()
+	case m.GetStickerMessage() != nil:
+		return m.GetStickerMessage().GetContextInfo()
+	case m.GetContactMessage() != nil:
+		return m.GetContactMessage().GetContextInfo()
+	case m.GetLocationMessage() != nil:
+		return m.GetLocationMessage().GetContextInfo()
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package wa
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+func TestExtractTextContent(t *testing.T) {
+	link := func(text, title, matched, description string) *waE2E.ExtendedTextMessage {
+		opt := func(s string) *string {
+			if s == "" {
+				return nil
+			}
+			return protoString(s)
+		}
+		return &waE2E.ExtendedTextMessage{Text: opt(text), Title: opt(title), MatchedText: opt(matched), Description: opt(description)}
+	}
+	longTitle := "An extremely long link preview title that is much longer than the message"
+
+	tests := []struct {
+		name         string
+		conversation string
+		extended     *waE2E.ExtendedTextMessage
+		want         string
+	}{
+		{name: "conversation only", conversation: "hello", want: "hello"},
+		{name: "extended text only", extended: link("hello there", "", "", ""), want: "hello there"},
+		{name: "both texts keep the longer", conversation: "hi", extended: link("hi, see you at 6", "", "", ""), want: "hi, see you at 6"},
+		{name: "conversation beats a longer preview", conversation: "look", extended: link("", longTitle, "", ""), want: "look"},
+		{name: "extended text beats a longer preview", extended: link("look", longTitle, "", ""), want: "look"},
+		{name: "preview title without text", extended: link("", longTitle, "https://example.com", "desc"), want: longTitle},
+		{name: "preview matched text without title", extended: link("", "", "https://example.com", "desc"), want: "https://example.com"},
+		{name: "preview description only", extended: link("", "  ", "", "desc"), want: "desc"},
+		{name: "empty extended text", extended: link("", "", "", ""), want: ""},
+		{name: "nothing", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &waE2E.Message{ExtendedTextMessage: tt.extended}
+			if tt.conversation != "" {
+				m.Conversation = protoString(tt.conversation)
+			}
+			if got := extractTextContent(m); got != tt.want {
+				t.Errorf("extractTextContent = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMimeExtension(t *testing.T) {
+	tests := []struct {
+		mime string
+		want string
+	}{
+		{mime: "application/pdf", want: ".pdf"},
+		{mime: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", want: ".docx"},
+		{mime: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", want: ".xlsx"},
+		{mime: "application/vnd.openxmlformats-officedocument.presentationml.presentation", want: ".pptx"},
+		{mime: "application/msword", want: ".doc"},
+		{mime: "application/vnd.ms-excel", want: ".xls"},
+		{mime: "application/vnd.ms-powerpoint", want: ".ppt"},
+		{mime: "application/zip", want: ".zip"},
+		{mime: "application/json", want: ".json"},
+		{mime: "text/plain", want: ".txt"},
+		{mime: "text/plain; charset=utf-8", want: ".txt"},
+		{mime: "text/csv", want: ".csv"},
+		{mime: "text/vcard", want: ".vcf"},
+		{mime: "image/jpeg", want: ".jpg"},
+		{mime: "image/png", want: ".png"},
+		{mime: "image/webp", want: ".webp"},
+		{mime: "image/gif", want: ".gif"},
+		{mime: "video/mp4", want: ".mp4"},
+		{mime: "video/quicktime", want: ".mov"},
+		{mime: "video/3gpp", want: ".3gp"},
+		{mime: "audio/ogg; codecs=opus", want: ".ogg"},
+		{mime: "audio/mpeg", want: ".mp3"},
+		{mime: "audio/mp4", want: ".m4a"},
+		{mime: "audio/aac", want: ".aac"},
+		{mime: " Application/PDF ", want: ".pdf"},
+		{mime: "application/x-made-up-type", want: ""},
+		{mime: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mime, func(t *testing.T) {
+			if got := mimeExtension(tt.mime); got != tt.want {
+				t.Errorf("mimeExtension(%q) = %q, want %q", tt.mime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMimeExtension(t *testing.T) {
+	tests := []struct {
+		name, mime string
+		want       string
+	}{
+		{name: "report", mime: "application/pdf", want: "report.pdf"},
+		{name: "report.pdf", mime: "application/pdf", want: "report.pdf"},
+		{name: "scan.jpeg", mime: "application/pdf", want: "scan.jpeg"},
+		{name: "voice", mime: "audio/ogg; codecs=opus", want: "voice.ogg"},
+		{name: "blob", mime: "application/x-made-up-type", want: "blob"},
+		{name: "blob", mime: "", want: "blob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.mime, func(t *testing.T) {
+			if got := withMimeExtension(tt.name, tt.mime); got != tt.want {
+				t.Errorf("withMimeExtension(%q, %q) = %q, want %q", tt.name, tt.mime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "report.pdf", want: "report.pdf"},
+		{name: "unix traversal", in: "../../etc/passwd", want: "passwd"},
+		{name: "windows traversal", in: `..\..\Windows\win.ini`, want: "win.ini"},
+		{name: "absolute path", in: "/etc/shadow", want: "shadow"},
+		{name: "dot dot only", in: "..", want: "fallback.bin"},
+		{name: "trailing separator", in: "../../", want: "fallback.bin"},
+		{name: "hidden file", in: ".bashrc", want: "bashrc"},
+		{name: "reserved characters", in: `a<b>c:d"e|f?g*h.txt`, want: "a_b_c_d_e_f_g_h.txt"},
+		{name: "control characters", in: "re\x00po\nrt.pdf", want: "report.pdf"},
+		{name: "trailing dots and spaces", in: "report.pdf. . ", want: "report.pdf"},
+		{name: "empty", in: "", want: "fallback.bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeFilename(tt.in, "fallback.bin")
+			if got != tt.want {
+				t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			// Joined onto a media directory, the result must stay inside it
+			dir := filepath.Join("store", "media")
+			if out := filepath.Join(dir, got); filepath.Dir(out) != dir {
+				t.Errorf("sanitizeFilename(%q) = %q escapes %s", tt.in, got, dir)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameFallback(t *testing.T) {
+	// Message IDs used as fallbacks are sender-controlled too
+	if got := sanitizeFilename("", "document_../../x"); strings.ContainsAny(got, `/\`) {
+		t.Errorf("fallback produced %q, containing a path separator", got)
+	}
+}
+
+func TestSuffixFilename(t *testing.T) {
+	long := strings.Repeat("a", maxFilenameLength)
+	// 3-byte runes put the cut for a 2-byte suffix in the middle of one
+	multibyte := strings.Repeat("€", maxFilenameLength/3)
+
+	tests := []struct {
+		name, file, suffix string
+		want               string
+	}{
+		{name: "before extension", file: "photo.jpg", suffix: "2", want: "photo_2.jpg"},
+		{name: "no extension", file: "notes", suffix: "3", want: "notes_3"},
+		{name: "only last extension", file: "archive.tar.gz", suffix: "2", want: "archive.tar_2.gz"},
+		{name: "duplicate of a suffixed name", file: "photo_2.jpg", suffix: "2", want: "photo_2_2.jpg"},
+		{name: "long name shortened", file: long + ".pdf", suffix: "2", want: long[:maxFilenameLength-6] + "_2.pdf"},
+		{name: "cut at a rune boundary", file: multibyte + ".txt", suffix: "10", want: strings.Repeat("€", 40) + "_10.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suffixFilename(tt.file, tt.suffix)
+			if got != tt.want {
+				t.Errorf("suffixFilename(%q, %q) = %q, want %q", tt.file, tt.suffix, got, tt.want)
+			}
+			if len(got) > maxFilenameLength {
+				t.Errorf("result is %d bytes, over %d", len(got), maxFilenameLength)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("result %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+func TestSuffixFilenameDuplicates(t *testing.T) {
+	// Successive suffixes for the same name must all differ
+	seen := map[string]bool{}
+	for _, file := range []string{"report.pdf", strings.Repeat("x", 200) + ".pdf", "../../etc/passwd"} {
+		name := sanitizeFilename(file, "document")
+		for i := 2; i <= 20; i++ {
+			got := suffixFilename(name, strconv.Itoa(i))
+			if seen[got] {
+				t.Fatalf("suffixFilename(%q, %d) = %q, already produced", name, i, got)
+			}
+			if strings.ContainsAny(got, "/\\") {
+				t.Fatalf("suffixFilename(%q, %d) = %q contains a path separator", name, i, got)
+			}
+			seen[got] = true
+		}
+	}
+}
@@ -2,16 +2,24 @@ package wa
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"go.mau.fi/whatsmeow"
 	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
 	"github.com/eddmann/whatsapp-mcp/internal/media"
 )
 
@@ -32,9 +40,18 @@ type DownloadMediaResult struct {
 	Path      string
 }
 
+// SendOptions contains optional parameters for sending a message.
+type SendOptions struct {
+	ReplyToMessageID string   // Message ID to quote in a threaded reply
+	QuotedSender     string   // Quoted author's JID, used if ReplyToMessageID isn't stored
+	QuotedText       string   // Quoted text, used if ReplyToMessageID isn't stored
+	Mentions         []string // User JIDs to @mention (group chats only)
+	Filename         string   // Display filename for documents, overriding the path's base name
+}
+
 // SendText sends a text message to a JID or phone number string (without +) or group JID.
-// If replyToMessageID is provided, sends as a quoted reply.
-func (c *Client) SendText(recipient, text, replyToMessageID string) (*SendMessageResult, error) {
+// If opts.ReplyToMessageID is provided, sends as a quoted reply; opts.Mentions tags group participants.
+func (c *Client) SendText(recipient, text string, opts SendOptions) (*SendMessageResult, error) {
 	if !c.WA.IsConnected() {
 		return &SendMessageResult{Success: false, Message: "not connected"}, fmt.Errorf("not connected")
 	}
@@ -44,17 +61,18 @@ func (c *Client) SendText(recipient, text, replyToMessageID string) (*SendMessag
 		return &SendMessageResult{Success: false, Message: "invalid recipient"}, err
 	}
 
-	msg := &waE2E.Message{}
+	text = withMentionTokens(text, opts.Mentions)
+	ctxInfo, err := c.buildContextInfo(jid.String(), opts)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: "failed to build quote"}, err
+	}
 
-	if replyToMessageID != "" {
-		quotedMsg, err := c.buildQuotedMessage(replyToMessageID, jid.String())
-		if err != nil {
-			return &SendMessageResult{Success: false, Message: "failed to build quote"}, err
-		}
+	msg := &waE2E.Message{}
 
+	if ctxInfo != nil {
 		msg.ExtendedTextMessage = &waE2E.ExtendedTextMessage{
 			Text:        protoString(text),
-			ContextInfo: quotedMsg,
+			ContextInfo: ctxInfo,
 		}
 	} else {
 		msg.Conversation = protoString(text)
@@ -64,6 +82,8 @@ func (c *Client) SendText(recipient, text, replyToMessageID string) (*SendMessag
 	if err != nil {
 		return &SendMessageResult{Success: false, Message: err.Error()}, err
 	}
+	c.storeSent(jid, resp.ID, msg, resp.Timestamp)
+	c.markChatRead(jid.String(), resp.Timestamp)
 
 	return &SendMessageResult{
 		Success:   true,
@@ -75,8 +95,8 @@ func (c *Client) SendText(recipient, text, replyToMessageID string) (*SendMessag
 }
 
 // SendMedia sends an image/video/document/audio with optional caption; audio is PTT if .ogg.
-// If replyToMessageID is provided, sends as a quoted reply.
-func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*SendMessageResult, error) {
+// If opts.ReplyToMessageID is provided, sends as a quoted reply; opts.Mentions tags group participants.
+func (c *Client) SendMedia(recipient, path, caption string, opts SendOptions) (*SendMessageResult, error) {
 	if !c.WA.IsConnected() {
 		return &SendMessageResult{Success: false, Message: "not connected"}, fmt.Errorf("not connected")
 	}
@@ -92,20 +112,20 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 	}
 
 	mediaType, mime := classify(path)
+	if opts.Filename != "" && mediaType != whatsmeow.MediaDocument {
+		return &SendMessageResult{Success: false, Message: "filename only applies to documents"}, fmt.Errorf("filename can only be set for documents, not %s", mediaType)
+	}
 	up, err := c.WA.Upload(context.Background(), b, mediaType)
 	if err != nil {
 		return &SendMessageResult{Success: false, Message: "upload failed"}, err
 	}
 
 	m := &waE2E.Message{}
-	base := filepath.Base(path)
 
-	var quotedCtx *waE2E.ContextInfo
-	if replyToMessageID != "" {
-		quotedCtx, err = c.buildQuotedMessage(replyToMessageID, jid.String())
-		if err != nil {
-			return &SendMessageResult{Success: false, Message: "failed to build quote"}, err
-		}
+	caption = withMentionTokens(caption, opts.Mentions)
+	quotedCtx, err := c.buildContextInfo(jid.String(), opts)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: "failed to build quote"}, err
 	}
 
 	switch mediaType {
@@ -121,6 +141,7 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 			FileLength:    &up.FileLength,
 			ContextInfo:   quotedCtx,
 		}
+		c.addImagePreview(m.ImageMessage, path)
 	case whatsmeow.MediaVideo:
 		m.VideoMessage = &waE2E.VideoMessage{
 			Caption:       protoString(caption),
@@ -133,18 +154,11 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 			FileLength:    &up.FileLength,
 			ContextInfo:   quotedCtx,
 		}
+		c.addVideoPreview(m.VideoMessage, path)
 	case whatsmeow.MediaDocument:
-		m.DocumentMessage = &waE2E.DocumentMessage{
-			Title:         protoString(base),
-			Caption:       protoString(caption),
-			Mimetype:      protoString(mime),
-			URL:           &up.URL,
-			DirectPath:    &up.DirectPath,
-			MediaKey:      up.MediaKey,
-			FileEncSHA256: up.FileEncSHA256,
-			FileSHA256:    up.FileSHA256,
-			FileLength:    &up.FileLength,
-			ContextInfo:   quotedCtx,
+		m.DocumentMessage = documentMessage(path, opts.Filename, mime, caption, up, quotedCtx)
+		if mime == "application/pdf" {
+			c.addPDFPreview(m.DocumentMessage, path)
 		}
 	case whatsmeow.MediaAudio:
 		if !isOgg(path) {
@@ -164,7 +178,11 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 				return &SendMessageResult{Success: false, Message: "upload converted"}, err
 			}
 
-			dur, waveform, _ := media.AnalyzeOggOpus(b2)
+			dur, waveform, _ := media.AnalyzeOggOpus(b2, cpath)
+			// Prefer the real amplitude envelope; the placeholder only covers decode failures
+			if wf, err := media.AudioWaveform(path); err == nil {
+				waveform = wf
+			}
 			m.AudioMessage = &waE2E.AudioMessage{
 				Mimetype:      protoString("audio/ogg; codecs=opus"),
 				URL:           &up2.URL,
@@ -179,7 +197,10 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 				ContextInfo:   quotedCtx,
 			}
 		} else {
-			dur, waveform, _ := media.AnalyzeOggOpus(b)
+			dur, waveform, _ := media.AnalyzeOggOpus(b, path)
+			if wf, err := media.AudioWaveform(path); err == nil {
+				waveform = wf
+			}
 			m.AudioMessage = &waE2E.AudioMessage{
 				Mimetype:      protoString(mime),
 				URL:           &up.URL,
@@ -200,6 +221,8 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 	if err != nil {
 		return &SendMessageResult{Success: false, Message: err.Error()}, err
 	}
+	c.storeSent(jid, resp.ID, m, resp.Timestamp)
+	c.markChatRead(jid.String(), resp.Timestamp)
 
 	return &SendMessageResult{
 		Success:   true,
@@ -210,14 +233,60 @@ func (c *Client) SendMedia(recipient, path, caption, replyToMessageID string) (*
 	}, nil
 }
 
+// addImagePreview sets the thumbnail and dimensions on an image message, so
+// recipients see a preview before downloading. Skipped if the image can't be decoded.
+func (c *Client) addImagePreview(img *waE2E.ImageMessage, path string) {
+	thumb, width, height, err := media.ImageThumbnail(path)
+	if err != nil {
+		c.Logger.Debug("image thumbnail unavailable", "path", path, "err", err)
+		return
+	}
+	img.JPEGThumbnail = thumb
+	img.Width = protoUint32(uint32(width))
+	img.Height = protoUint32(uint32(height))
+}
+
+// addVideoPreview sets the thumbnail, dimensions and duration on a video message.
+// Each is best-effort and skipped when ffmpeg/ffprobe are unavailable.
+func (c *Client) addVideoPreview(video *waE2E.VideoMessage, path string) {
+	if thumb, width, height, err := media.VideoThumbnail(path); err == nil {
+		video.JPEGThumbnail = thumb
+		video.Width = protoUint32(uint32(width))
+		video.Height = protoUint32(uint32(height))
+	} else {
+		c.Logger.Debug("video thumbnail unavailable", "path", path, "err", err)
+	}
+	if d, err := media.ProbeDuration(path); err == nil {
+		video.Seconds = protoUint32(uint32(math.Round(d)))
+	} else {
+		c.Logger.Debug("video duration unavailable", "path", path, "err", err)
+	}
+}
+
+// addPDFPreview sets the page count and first-page thumbnail on a PDF document
+// message. Both are best-effort and skipped when the tools are unavailable.
+func (c *Client) addPDFPreview(doc *waE2E.DocumentMessage, path string) {
+	if pages, err := media.PDFPageCount(path); err == nil {
+		doc.PageCount = protoUint32(uint32(pages))
+	} else {
+		c.Logger.Debug("pdf page count unavailable", "path", path, "err", err)
+	}
+	if thumb, err := media.PDFThumbnail(path); err == nil {
+		doc.JPEGThumbnail = thumb
+	} else {
+		c.Logger.Debug("pdf thumbnail unavailable", "path", path, "err", err)
+	}
+}
+
 // DownloadMedia looks up media from DB and downloads via whatsmeow.
 func (c *Client) DownloadMedia(messageID, chatJID string) (*DownloadMediaResult, error) {
 	var mediaType, filename, url string
+	var mimeType sql.NullString
 	var mediaKey, fileSHA256, fileEncSHA256 []byte
 	var fileLength uint64
 
-	row := c.Store.Messages.QueryRow("SELECT media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length FROM messages WHERE id = ? AND chat_jid = ?", messageID, chatJID)
-	if err := row.Scan(&mediaType, &filename, &url, &mediaKey, &fileSHA256, &fileEncSHA256, &fileLength); err != nil {
+	row := c.Store.Messages.QueryRow("SELECT media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length, mime_type FROM messages WHERE id = ? AND chat_jid = ?", messageID, chatJID)
+	if err := row.Scan(&mediaType, &filename, &url, &mediaKey, &fileSHA256, &fileEncSHA256, &fileLength, &mimeType); err != nil {
 		return &DownloadMediaResult{Success: false}, err
 	}
 
@@ -246,7 +315,15 @@ func (c *Client) DownloadMedia(messageID, chatJID string) (*DownloadMediaResult,
 		return &DownloadMediaResult{Success: false}, err
 	}
 
+	filename = mediaFilename(messageID, mediaType, filename, mimeType.String)
+	if existing, err := os.ReadFile(filepath.Join(outDir, filename)); err == nil && !sameContent(existing, fileSHA256) {
+		// Another message's file already has this name; keep both
+		filename = suffixFilename(filename, sanitizeFilename(messageID, "dup"))
+	}
 	out := filepath.Join(outDir, filename)
+	if filepath.Dir(out) != filepath.Clean(outDir) {
+		return &DownloadMediaResult{Success: false}, fmt.Errorf("refusing to write outside the chat directory")
+	}
 	if err := os.WriteFile(out, data, fs.FileMode(0644)); err != nil {
 		return &DownloadMediaResult{Success: false}, err
 	}
@@ -260,6 +337,62 @@ func (c *Client) DownloadMedia(messageID, chatJID string) (*DownloadMediaResult,
 	}, nil
 }
 
+// IsMediaExpired reports whether a DownloadMedia error means WhatsApp no longer
+// has the file, so retrying won't help.
+func IsMediaExpired(err error) bool {
+	return errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith403) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410)
+}
+
+// mediaFilename returns the name DownloadMedia saves a message's media under,
+// before any suffix added to avoid clashing with another message's file.
+func mediaFilename(messageID, mediaType, filename, mimeType string) string {
+	return withMimeExtension(sanitizeFilename(filename, fmt.Sprintf("%s_%s", mediaType, messageID)), mimeType)
+}
+
+// LocateMedia sets the local filename of a listed media item and, if
+// DownloadMedia has already saved it to the chat's folder, its path. A file only
+// counts when its size matches the message's, since a same-named file may belong
+// to another message.
+func (c *Client) LocateMedia(item *domain.MediaItem) {
+	outDir := filepath.Join(c.BaseDir, strings.ReplaceAll(item.ChatJID, ":", "_"))
+	name := mediaFilename(item.MessageID, item.MediaType, item.Filename, item.MimeType)
+	item.Filename = name
+
+	for _, n := range []string{name, suffixFilename(name, sanitizeFilename(item.MessageID, "dup"))} {
+		info, err := os.Stat(filepath.Join(outDir, n))
+		if err != nil || !info.Mode().IsRegular() || (item.SizeBytes > 0 && uint64(info.Size()) != item.SizeBytes) {
+			continue
+		}
+		item.Filename = n
+		item.Downloaded = true
+		item.Path, _ = filepath.Abs(filepath.Join(outDir, n))
+		return
+	}
+}
+
+// documentMessage builds the message for an uploaded document. The recipient sees
+// filename when set, otherwise the path's base name.
+func documentMessage(path, filename, mime, caption string, up whatsmeow.UploadResponse, quotedCtx *waE2E.ContextInfo) *waE2E.DocumentMessage {
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	return &waE2E.DocumentMessage{
+		Title:         protoString(filename),
+		FileName:      protoString(filename),
+		Caption:       protoString(caption),
+		Mimetype:      protoString(mime),
+		URL:           &up.URL,
+		DirectPath:    &up.DirectPath,
+		MediaKey:      up.MediaKey,
+		FileEncSHA256: up.FileEncSHA256,
+		FileSHA256:    up.FileSHA256,
+		FileLength:    &up.FileLength,
+		ContextInfo:   quotedCtx,
+	}
+}
+
 // protoString returns a pointer to a string (for protobuf).
 func protoString(s string) *string { return &s }
 
@@ -277,40 +410,133 @@ func parseRecipient(recipient string) (types.JID, error) {
 	return types.JID{User: recipient, Server: "s.whatsapp.net"}, nil
 }
 
+// buildContextInfo constructs the ContextInfo for a reply and/or mentions.
+// Returns nil if the message is neither a reply nor mentions anyone.
+func (c *Client) buildContextInfo(chatJID string, opts SendOptions) (*waE2E.ContextInfo, error) {
+	var ctx *waE2E.ContextInfo
+	if opts.ReplyToMessageID != "" {
+		quoted, err := c.buildQuotedMessage(opts.ReplyToMessageID, chatJID)
+		if errors.Is(err, errQuotedMessageNotStored) && opts.QuotedSender != "" && opts.QuotedText != "" {
+			// Quote from caller-provided data, e.g. for messages from before the history sync
+			quoted, err = &waE2E.ContextInfo{
+				StanzaID:      protoString(opts.ReplyToMessageID),
+				Participant:   protoString(opts.QuotedSender),
+				QuotedMessage: &waE2E.Message{Conversation: protoString(opts.QuotedText)},
+			}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ctx = quoted
+	}
+
+	if len(opts.Mentions) > 0 {
+		if ctx == nil {
+			ctx = &waE2E.ContextInfo{}
+		}
+		ctx.MentionedJID = opts.Mentions
+	}
+
+	return ctx, nil
+}
+
+// withMentionTokens ensures text contains an "@<number>" token for each mentioned JID,
+// prepending any that are missing so WhatsApp renders and notifies the mention.
+func withMentionTokens(text string, mentions []string) string {
+	var missing []string
+	for _, m := range mentions {
+		user := m
+		if i := strings.Index(m, "@"); i > 0 {
+			user = m[:i]
+		}
+		if !hasMentionToken(text, user) {
+			missing = append(missing, "@"+user)
+		}
+	}
+	if len(missing) == 0 {
+		return text
+	}
+	if text == "" {
+		return strings.Join(missing, " ")
+	}
+	return strings.Join(missing, " ") + " " + text
+}
+
+// hasMentionToken reports whether text contains "@<user>" as a whole token, so
+// "@1234" doesn't count as a mention of 123 and "me@123" isn't a mention at all.
+func hasMentionToken(text, user string) bool {
+	token := "@" + user
+	for i := 0; ; {
+		j := strings.Index(text[i:], token)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(token)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// isWordRune reports whether r can be part of a word or number.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// errQuotedMessageNotStored is returned when a reply target isn't in the local store.
+var errQuotedMessageNotStored = errors.New("message to reply to was not found")
+
 // buildQuotedMessage fetches the message being replied to and constructs a ContextInfo.
+// The quoted message must exist in the local store; replying to an unknown ID is an
+// error rather than silently sending an unquoted message.
 func (c *Client) buildQuotedMessage(messageID, chatJID string) (*waE2E.ContextInfo, error) {
-	var sender, content string
+	var sender, content, mediaType string
 	var isFromMe bool
-	var mediaType *string
 
 	// Query the message from the database
 	row := c.Store.Messages.QueryRow(`
-		SELECT sender, content, is_from_me, media_type
+		SELECT COALESCE(sender, ''), COALESCE(content, ''), COALESCE(is_from_me, 0), COALESCE(media_type, '')
 		FROM messages
 		WHERE id = ? AND chat_jid = ?
 	`, messageID, chatJID)
 
 	err := row.Scan(&sender, &content, &isFromMe, &mediaType)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s in chat %s (provide the quoted sender and text to quote it anyway)", errQuotedMessageNotStored, messageID, chatJID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find quoted message: %w", err)
 	}
 
-	// Build the participant JID
-	participantJID := ""
-	if strings.HasSuffix(chatJID, "@g.us") {
-		// For group messages, participant is the sender's JID
-		if !strings.Contains(sender, "@") {
-			participantJID = sender + "@s.whatsapp.net"
-		} else {
-			participantJID = sender
+	chat, err := types.ParseJID(chatJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat JID: %w", err)
+	}
+
+	// The participant is the quoted message's author: ourselves, the group
+	// participant who sent it, or the other side of a direct chat
+	var participant types.JID
+	switch {
+	case isFromMe:
+		if c.WA.Store.ID != nil {
+			participant = c.WA.Store.ID.ToNonAD()
 		}
+	case chat.Server == types.GroupServer:
+		if sender != "" {
+			participant = c.participantJID(sender)
+		}
+	default:
+		participant = chat.ToNonAD()
 	}
 
 	// Construct the quoted message based on type
 	quotedMsg := &waE2E.Message{}
-	if mediaType != nil && *mediaType != "" {
+	if mediaType != "" {
 		// For media messages, use the media type emoji as placeholder
-		quotedMsg.Conversation = protoString(getMediaEmoji(*mediaType))
+		quotedMsg.Conversation = protoString(getMediaEmoji(mediaType))
 	} else {
 		quotedMsg.Conversation = protoString(content)
 	}
@@ -320,8 +546,8 @@ func (c *Client) buildQuotedMessage(messageID, chatJID string) (*waE2E.ContextIn
 		QuotedMessage: quotedMsg,
 	}
 
-	if participantJID != "" {
-		ctx.Participant = protoString(participantJID)
+	if !participant.IsEmpty() {
+		ctx.Participant = protoString(participant.String())
 	}
 
 	return ctx, nil
@@ -343,8 +569,67 @@ func getMediaEmoji(mediaType string) string {
 	}
 }
 
-// classify determines WhatsApp media type and MIME type from file extension.
+// MediaTypeOf returns the kind of message SendMedia sends a file as, based on its
+// contents and extension: "image", "video", "audio" or "document".
+func MediaTypeOf(path string) string {
+	switch t, _ := classify(path); t {
+	case whatsmeow.MediaImage:
+		return "image"
+	case whatsmeow.MediaVideo:
+		return "video"
+	case whatsmeow.MediaAudio:
+		return "audio"
+	default:
+		return "document"
+	}
+}
+
+// classify determines WhatsApp media type and MIME type from the file's contents,
+// so a mislabeled file is still sent as what it really is. The extension decides
+// only when sniffing is inconclusive, and .ogg files are always PTT audio. Any
+// other audio (MP3, WAV, M4A) classifies as audio too, so SendMedia converts it
+// to Ogg Opus and sends it as a PTT voice note rather than as a document.
 func classify(path string) (whatsmeow.MediaType, string) {
+	if isOgg(path) {
+		return whatsmeow.MediaAudio, "audio/ogg; codecs=opus"
+	}
+	if head, err := readHead(path, 512); err == nil {
+		switch detected := http.DetectContentType(head); detected {
+		case "image/jpeg", "image/png", "image/gif", "image/webp":
+			return whatsmeow.MediaImage, detected
+		case "video/mp4", "video/avi":
+			// M4A audio shares the MP4 container and sniffs as video
+			if strings.EqualFold(filepath.Ext(path), ".m4a") {
+				return whatsmeow.MediaAudio, "audio/mp4"
+			}
+			return whatsmeow.MediaVideo, detected
+		case "audio/mpeg", "audio/wave", "application/ogg":
+			return whatsmeow.MediaAudio, detected
+		case "application/pdf":
+			return whatsmeow.MediaDocument, detected
+		}
+	}
+	return classifyExtension(path)
+}
+
+// readHead returns up to n bytes from the start of a file.
+func readHead(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// classifyExtension determines WhatsApp media type and MIME type from file extension.
+func classifyExtension(path string) (whatsmeow.MediaType, string) {
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
 	case ".jpg", ".jpeg":
@@ -363,6 +648,14 @@ func classify(path string) (whatsmeow.MediaType, string) {
 		return whatsmeow.MediaVideo, "video/quicktime"
 	case ".ogg":
 		return whatsmeow.MediaAudio, "audio/ogg; codecs=opus"
+	case ".mp3":
+		return whatsmeow.MediaAudio, "audio/mpeg"
+	case ".wav":
+		return whatsmeow.MediaAudio, "audio/wav"
+	case ".m4a":
+		return whatsmeow.MediaAudio, "audio/mp4"
+	case ".pdf":
+		return whatsmeow.MediaDocument, "application/pdf"
 	default:
 		return whatsmeow.MediaDocument, "application/octet-stream"
 	}
@@ -0,0 +1,93 @@
+package wa
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+func TestWithMentionTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		mentions []string
+		want     string
+	}{
+		{name: "token present", text: "hi @123", mentions: []string{"123@s.whatsapp.net"}, want: "hi @123"},
+		{name: "token missing", text: "hi", mentions: []string{"123@s.whatsapp.net"}, want: "@123 hi"},
+		{name: "longer number doesn't count", text: "hi @1234", mentions: []string{"123@s.whatsapp.net"}, want: "@123 hi @1234"},
+		{name: "email-like text doesn't count", text: "mail me@123", mentions: []string{"123@s.whatsapp.net"}, want: "@123 mail me@123"},
+		{name: "token before punctuation", text: "thanks @123!", mentions: []string{"123@s.whatsapp.net"}, want: "thanks @123!"},
+		{name: "token after a false match", text: "@1234 and @123", mentions: []string{"123@s.whatsapp.net"}, want: "@1234 and @123"},
+		{name: "empty text", text: "", mentions: []string{"123@s.whatsapp.net", "456@lid"}, want: "@123 @456"},
+		{name: "no mentions", text: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withMentionTokens(tt.text, tt.mentions); got != tt.want {
+				t.Errorf("withMentionTokens(%q, %v) = %q, want %q", tt.text, tt.mentions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildContextInfoMentions(t *testing.T) {
+	c := &Client{}
+	mentions := []string{"447700900001@s.whatsapp.net", "98765432109876@lid"}
+
+	ctx, err := c.buildContextInfo("120363000000000001@g.us", SendOptions{Mentions: mentions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx == nil || !slices.Equal(ctx.GetMentionedJID(), mentions) {
+		t.Errorf("MentionedJID = %v, want %v", ctx.GetMentionedJID(), mentions)
+	}
+
+	ctx, err = c.buildContextInfo("120363000000000001@g.us", SendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx != nil {
+		t.Errorf("context info without reply or mentions = %v, want nil", ctx)
+	}
+}
+
+func TestDocumentMessageFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{name: "path base name", want: "tmp-8f3a.pdf"},
+		{name: "override", filename: "Q3 Report.pdf", want: "Q3 Report.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := documentMessage("/tmp/exports/tmp-8f3a.pdf", tt.filename, "application/pdf", "", whatsmeow.UploadResponse{}, nil)
+			if got := doc.GetTitle(); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+			if got := doc.GetFileName(); got != tt.want {
+				t.Errorf("file name = %q, want %q", got, tt.want)
+			}
+
+			// The sent copy is stored under the name the recipient sees
+			c := newTestClient(t)
+			ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			if err := c.Store.UpsertChat(testChat, "Test", ts); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.insertMessage("d1", testChat, "", &waE2E.Message{DocumentMessage: doc}, ts, true); err != nil {
+				t.Fatal(err)
+			}
+			if got := storedFilename(t, c, testChat, "d1"); got != tt.want {
+				t.Errorf("stored filename = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package wa
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// ErrNoProfilePicture is returned when a contact or group has no profile picture set.
+var ErrNoProfilePicture = whatsmeow.ErrProfilePictureNotSet
+
+// ErrProfilePictureHidden is returned when a user's privacy settings hide their
+// profile picture from the logged-in account.
+var ErrProfilePictureHidden = whatsmeow.ErrProfilePictureUnauthorized
+
+// DownloadProfilePicture fetches a contact's or group's profile picture, either the
+// full-size image or the low-resolution preview, and saves it under the chat's
+// media folder. The last download is remembered per JID and resolution: if WhatsApp
+// reports the picture ID is unchanged and the file is still on disk, it is returned
+// as cached without downloading again. Returns ErrNoProfilePicture if no picture is
+// set, or ErrProfilePictureHidden if it is hidden by privacy settings.
+func (c *Client) DownloadProfilePicture(chatJID string, preview bool) (*domain.ProfilePicture, error) {
+	jid, err := types.ParseJID(chatJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JID: %w", err)
+	}
+	if err := c.requireLoggedIn(); err != nil {
+		return nil, err
+	}
+	jid = jid.ToNonAD()
+
+	cached, err := c.Store.GetProfilePicture(jid.String(), preview)
+	if err != nil {
+		c.Logger.Debug("failed to read cached profile picture", "jid", chatJID, "err", err)
+	}
+	params := &whatsmeow.GetProfilePictureParams{Preview: preview}
+	if cached != nil {
+		if _, err := os.Stat(cached.Path); err == nil {
+			params.ExistingID = cached.PictureID
+		}
+	}
+
+	info, err := c.WA.GetProfilePictureInfo(jid, params)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil && params.ExistingID != "" {
+		cached.Cached = true
+		return cached, nil
+	}
+	if info == nil || info.URL == "" {
+		return nil, ErrNoProfilePicture
+	}
+
+	name := "profile_picture"
+	if preview {
+		name += "_preview"
+	}
+	path, err := c.savePicture(info.URL, jid.String(), fmt.Sprintf("%s_%s.jpg", name, info.ID), name+".jpg")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	pic := &domain.ProfilePicture{
+		JID:        jid.String(),
+		HasPicture: true,
+		Preview:    preview,
+		PictureID:  info.ID,
+		URL:        info.URL,
+		Path:       path,
+		FetchedAt:  &now,
+	}
+	if err := c.Store.SaveProfilePicture(*pic); err != nil {
+		c.Logger.Warn("failed to cache profile picture", "jid", chatJID, "err", err)
+	}
+	return pic, nil
+}
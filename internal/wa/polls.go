@@ -0,0 +1,134 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+)
+
+// SendPoll creates a poll in a chat and stores it so later votes can be matched
+// to its options. whatsmeow generates the poll's message secret, which voters'
+// clients use to encrypt their votes, and keeps it in the session store.
+func (c *Client) SendPoll(recipient, question string, options []string, allowMultiple bool) (*SendMessageResult, error) {
+	if !c.WA.IsConnected() {
+		return &SendMessageResult{Success: false, Message: "not connected"}, fmt.Errorf("not connected")
+	}
+
+	jid, err := parseRecipient(recipient)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: "invalid recipient"}, err
+	}
+
+	// A selectable count of 0 lets voters pick any number of options.
+	selectable := 1
+	if allowMultiple {
+		selectable = 0
+	}
+	msg := c.WA.BuildPollCreation(question, options, selectable)
+
+	resp, err := c.WA.SendMessage(context.Background(), jid, msg)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: err.Error()}, err
+	}
+	c.storeSent(jid, resp.ID, msg, resp.Timestamp)
+	c.markChatRead(jid.String(), resp.Timestamp)
+	c.storePoll(jid.String(), resp.ID, c.ownUser(), msg, resp.Timestamp)
+
+	return &SendMessageResult{
+		Success:   true,
+		Message:   fmt.Sprintf("poll sent to %s", recipient),
+		MessageID: resp.ID,
+		ChatJID:   jid.String(),
+		Timestamp: resp.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// pollCreation returns a message's poll, whichever poll creation version carries it.
+func pollCreation(m *waE2E.Message) *waE2E.PollCreationMessage {
+	for _, p := range []*waE2E.PollCreationMessage{m.GetPollCreationMessage(), m.GetPollCreationMessageV2(), m.GetPollCreationMessageV3()} {
+		if p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// storePoll records the poll carried by m, if any.
+func (c *Client) storePoll(chatJID, messageID, sender string, m *waE2E.Message, t time.Time) {
+	poll := pollCreation(m)
+	if poll == nil || messageID == "" {
+		return
+	}
+
+	options := make([]string, 0, len(poll.GetOptions()))
+	for _, o := range poll.GetOptions() {
+		options = append(options, o.GetOptionName())
+	}
+
+	if err := c.Store.SavePoll(domain.Poll{
+		ChatJID:       chatJID,
+		MessageID:     messageID,
+		Sender:        sender,
+		Question:      poll.GetName(),
+		Options:       options,
+		AllowMultiple: poll.GetSelectableOptionsCount() != 1,
+		CreatedAt:     t,
+	}); err != nil {
+		c.Logger.Warn("failed to store poll", "id", messageID, "chat_jid", chatJID, "err", err)
+	}
+}
+
+// handlePollUpdate decrypts an incoming poll vote with the poll's message secret
+// and records the voter's current selections.
+func (c *Client) handlePollUpdate(msg *events.Message, chatJID, voter string) {
+	update := msg.Message.GetPollUpdateMessage()
+	pollID := update.GetPollCreationMessageKey().GetID()
+
+	vote, err := c.WA.DecryptPollVote(context.Background(), msg)
+	if err != nil {
+		c.Logger.Warn("failed to decrypt poll vote", "poll", pollID, "chat_jid", chatJID, "err", err)
+		return
+	}
+
+	t := msg.Info.Timestamp
+	if ms := update.GetSenderTimestampMS(); ms > 0 {
+		t = time.UnixMilli(ms)
+	}
+	c.storePollVote(chatJID, pollID, voter, vote.GetSelectedOptions(), t)
+}
+
+// storePollVote maps a vote's selected option hashes back to the stored poll's
+// option names and replaces the voter's previous selections.
+func (c *Client) storePollVote(chatJID, pollID, voter string, selected [][]byte, t time.Time) {
+	poll, err := c.Store.GetPoll(chatJID, pollID)
+	if err != nil {
+		c.Logger.Warn("failed to load poll", "poll", pollID, "chat_jid", chatJID, "err", err)
+		return
+	}
+	if poll == nil {
+		c.Logger.Debug("vote for unknown poll", "poll", pollID, "chat_jid", chatJID)
+		return
+	}
+
+	var options []string
+	for _, option := range poll.Options {
+		hash := sha256.Sum256([]byte(option))
+		for _, s := range selected {
+			if bytes.Equal(s, hash[:]) {
+				options = append(options, option)
+				break
+			}
+		}
+	}
+
+	if err := c.Store.ReplacePollVotes(chatJID, pollID, voter, options, t); err != nil {
+		c.Logger.Warn("failed to store poll vote", "poll", pollID, "chat_jid", chatJID, "err", err)
+	}
+}
@@ -0,0 +1,63 @@
+package wa
+
+import (
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Typing states accepted by SetTyping.
+const (
+	TypingComposing = "composing"
+	TypingRecording = "recording"
+	TypingPaused    = "paused"
+)
+
+// SetTyping shows a typing or recording indicator in a chat, or clears it with
+// TypingPaused. WhatsApp only delivers chat presence to contacts whose presence
+// we subscribe to, so the first indicator sent to each contact subscribes first.
+func (c *Client) SetTyping(chatJID, state string) error {
+	jid, err := types.ParseJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("invalid chat JID: %w", err)
+	}
+	if err := c.requireLoggedIn(); err != nil {
+		return err
+	}
+
+	var presence types.ChatPresence
+	media := types.ChatPresenceMediaText
+	switch state {
+	case TypingComposing:
+		presence = types.ChatPresenceComposing
+	case TypingRecording:
+		presence, media = types.ChatPresenceComposing, types.ChatPresenceMediaAudio
+	case TypingPaused:
+		presence = types.ChatPresencePaused
+	default:
+		return fmt.Errorf("invalid typing state %q", state)
+	}
+
+	if jid.Server != types.GroupServer {
+		if _, done := c.presenceSubscribed.LoadOrStore(jid.ToNonAD().String(), true); !done {
+			if err := c.WA.SubscribePresence(jid.ToNonAD()); err != nil {
+				c.presenceSubscribed.Delete(jid.ToNonAD().String())
+				c.Logger.Debug("failed to subscribe to presence", "jid", chatJID, "err", err)
+			}
+		}
+	}
+
+	return c.WA.SendChatPresence(jid, presence, media)
+}
+
+// SetPresence marks the account as online (available) or offline (unavailable)
+// for all contacts.
+func (c *Client) SetPresence(available bool) error {
+	if err := c.requireLoggedIn(); err != nil {
+		return err
+	}
+	if available {
+		return c.WA.SendPresence(types.PresenceAvailable)
+	}
+	return c.WA.SendPresence(types.PresenceUnavailable)
+}
@@ -0,0 +1,42 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/appstate"
+)
+
+// SetProfileName updates the logged-in account's push name (the display name
+// other users see) by sending a settings app state patch.
+func (c *Client) SetProfileName(name string) error {
+	if err := c.requireLoggedIn(); err != nil {
+		return err
+	}
+
+	if err := c.WA.SendAppState(context.Background(), appstate.BuildSettingPushName(name)); err != nil {
+		return err
+	}
+	c.WA.Store.PushName = name
+	return nil
+}
+
+// SetStatus updates the logged-in account's about/status text.
+func (c *Client) SetStatus(text string) error {
+	if err := c.requireLoggedIn(); err != nil {
+		return err
+	}
+
+	return c.WA.SetStatusMessage(text)
+}
+
+// requireLoggedIn returns an error unless the client is connected with a paired account.
+func (c *Client) requireLoggedIn() error {
+	if !c.WA.IsConnected() {
+		return fmt.Errorf("not connected")
+	}
+	if !c.WA.IsLoggedIn() {
+		return fmt.Errorf("not logged in")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SendReaction reacts to a stored message with an emoji. An empty emoji removes
+// a previous reaction. The target message's sender is looked up in the database.
+func (c *Client) SendReaction(chatJID, messageID, emoji string) (*SendMessageResult, error) {
+	if !c.WA.IsConnected() {
+		return &SendMessageResult{Success: false, Message: "not connected"}, fmt.Errorf("not connected")
+	}
+
+	chat, err := types.ParseJID(chatJID)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: "invalid chat JID"}, err
+	}
+
+	var sender string
+	var isFromMe bool
+	if err := c.Store.Messages.QueryRow(`
+		SELECT sender, is_from_me FROM messages WHERE id = ? AND chat_jid = ?`,
+		messageID, chatJID).Scan(&sender, &isFromMe); err != nil {
+		return &SendMessageResult{Success: false, Message: "message not found"}, fmt.Errorf("failed to find message to react to: %w", err)
+	}
+
+	senderJID := types.EmptyJID
+	if !isFromMe {
+		if chat.Server == types.GroupServer {
+			senderJID = c.participantJID(sender)
+		} else {
+			senderJID = chat
+		}
+	}
+
+	resp, err := c.WA.SendMessage(context.Background(), chat, c.WA.BuildReaction(chat, senderJID, messageID, emoji))
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: err.Error()}, err
+	}
+
+	return &SendMessageResult{
+		Success:   true,
+		Message:   fmt.Sprintf("reacted to %s in %s", messageID, chatJID),
+		MessageID: resp.ID,
+		ChatJID:   chat.String(),
+		Timestamp: resp.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// participantJID rebuilds a group participant's JID from the stored sender user part.
+// Senders in LID-addressed groups are stored by their LID number, so a known LID
+// mapping selects the hidden-user server; otherwise the phone-number server is used.
+func (c *Client) participantJID(user string) types.JID {
+	lid := types.JID{User: user, Server: types.HiddenUserServer}
+	if pn, err := c.WA.Store.LIDs.GetPNForLID(context.Background(), lid); err == nil && !pn.IsEmpty() {
+		return lid
+	}
+	return types.JID{User: user, Server: types.DefaultUserServer}
+}
@@ -0,0 +1,129 @@
+package wa
+
+import (
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// markChatRead advances a chat's last-read marker, logging failures.
+func (c *Client) markChatRead(chatJID string, t time.Time) {
+	if err := c.Store.MarkChatRead(chatJID, t); err != nil {
+		c.Logger.Warn("failed to update read marker", "jid", chatJID, "err", err)
+	}
+}
+
+// markReadLimit caps how many incoming messages one MarkRead call acknowledges.
+const markReadLimit = 100
+
+// MarkRead sends read receipts for a chat's incoming messages newer than its
+// read marker, up to and including upToMessageID (or the latest message when
+// empty), and advances the local marker. Returns the number of messages marked.
+func (c *Client) MarkRead(chatJID, upToMessageID string) (int, error) {
+	chat, err := types.ParseJID(chatJID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chat JID: %w", err)
+	}
+	if !c.WA.IsConnected() {
+		return 0, fmt.Errorf("not connected")
+	}
+
+	upTo := time.Now()
+	if upToMessageID != "" {
+		msg, err := c.Store.GetMessage(chatJID, upToMessageID)
+		if err != nil {
+			return 0, err
+		}
+		if msg == nil {
+			return 0, fmt.Errorf("message %s not found in chat %s", upToMessageID, chatJID)
+		}
+		upTo = msg.Timestamp
+	}
+
+	var after time.Time
+	readTime, err := c.Store.GetChatReadTime(chatJID)
+	if err != nil {
+		return 0, err
+	}
+	if readTime != nil {
+		after = *readTime
+	}
+
+	messages, err := c.Store.ListIncomingMessages(chatJID, after, upTo, markReadLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	// Receipts can only cover messages from one sender at a time
+	var senders []string
+	ids := map[string][]types.MessageID{}
+	for _, m := range messages {
+		if _, ok := ids[m.Sender]; !ok {
+			senders = append(senders, m.Sender)
+		}
+		ids[m.Sender] = append(ids[m.Sender], m.ID)
+	}
+
+	marked := 0
+	for _, sender := range senders {
+		senderJID := types.EmptyJID
+		if chat.Server == types.GroupServer && sender != "" {
+			senderJID = c.participantJID(sender)
+		}
+		if err := c.WA.MarkRead(ids[sender], time.Now(), chat, senderJID); err != nil {
+			return marked, err
+		}
+		marked += len(ids[sender])
+	}
+
+	if len(messages) > 0 && upToMessageID == "" {
+		upTo = messages[0].Timestamp
+	}
+	c.markChatRead(chatJID, upTo)
+
+	return marked, nil
+}
+
+// handleReceipt tracks read receipts sent by the logged-in account's other devices.
+func (c *Client) handleReceipt(evt *events.Receipt) {
+	if !evt.IsFromMe {
+		return
+	}
+	if evt.Type != types.ReceiptTypeRead && evt.Type != types.ReceiptTypeReadSelf {
+		return
+	}
+	c.markChatRead(evt.Chat.String(), evt.Timestamp)
+}
+
+// handleMarkChatAsRead applies "mark as read/unread" actions synced from other devices.
+func (c *Client) handleMarkChatAsRead(evt *events.MarkChatAsRead) {
+	chatJID := evt.JID.String()
+	if evt.Action.GetRead() {
+		c.markChatRead(chatJID, evt.Timestamp)
+		return
+	}
+	if err := c.Store.MarkChatUnread(chatJID); err != nil {
+		c.Logger.Warn("failed to mark chat unread", "jid", chatJID, "err", err)
+	}
+}
+
+// seedReadMarker initialises a chat's read marker from history sync metadata.
+// Conversation messages are newest first, so the newest read message sits just
+// past the unread ones.
+func (c *Client) seedReadMarker(chatJID string, conv *waHistorySync.Conversation) {
+	if conv.GetMarkedAsUnread() {
+		return
+	}
+	unread := int(conv.GetUnreadCount())
+	if unread >= len(conv.Messages) {
+		return
+	}
+	m := conv.Messages[unread]
+	if m == nil || m.Message == nil || m.Message.GetMessageTimestamp() == 0 {
+		return
+	}
+	c.markChatRead(chatJID, time.Unix(int64(m.Message.GetMessageTimestamp()), 0))
+}
@@ -3,9 +3,11 @@ package wa
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"go.mau.fi/whatsmeow/types"
 )
@@ -45,6 +47,10 @@ func (c *Client) getChatName(jid types.JID, chatJID string, conversation any, se
 		return fmt.Sprintf("Group %s", jid.User)
 	}
 
+	if name, _ := c.Store.GetContactName(jid.ToNonAD().String()); name != "" {
+		return name
+	}
+
 	if contact, err := c.WA.Store.Contacts.GetContact(context.Background(), jid); err == nil {
 		if contact.FullName != "" {
 			return contact.FullName
@@ -87,9 +93,70 @@ func (c *Client) resolvePreferredName(jid types.JID) string {
 		}
 	}
 
+	if name, _ := c.Store.GetContactName(jid.ToNonAD().String()); name != "" {
+		return name
+	}
+
 	return jid.User
 }
 
+// upsertContact caches a sender's names in the contacts table, combining the
+// live contact store with the push name seen on the message. Senders addressed
+// by LID are stored under their @lid JID, matching how their messages record the
+// sender, and under their phone number too when the mapping is known.
+func (c *Client) upsertContact(sender types.JID, pushName string) {
+	sender = sender.ToNonAD()
+	if sender.Server != types.HiddenUserServer {
+		c.saveContact(types.JID{User: sender.User, Server: types.DefaultUserServer}, sender.User, pushName)
+		return
+	}
+
+	phone := ""
+	if pn, ok := c.pnForLID(sender.User); ok {
+		phone = pn.User
+		c.saveContact(pn, phone, pushName)
+	}
+	c.saveContact(sender, phone, pushName)
+}
+
+// saveContact upserts one contacts row for jid.
+func (c *Client) saveContact(jid types.JID, phone, pushName string) {
+	var fullName, businessName string
+	if contact, err := c.WA.Store.Contacts.GetContact(context.Background(), jid); err == nil {
+		fullName = contact.FullName
+		businessName = contact.BusinessName
+		if pushName == "" {
+			pushName = contact.PushName
+		}
+	}
+
+	if err := c.Store.UpsertContact(jid.String(), phone, fullName, pushName, businessName); err != nil {
+		c.Logger.Warn("failed to upsert contact", "jid", jid.String(), "err", err)
+	}
+}
+
+// backfillContacts refreshes cached contacts that have no saved or business name
+// using the live contact store once it is available post-connect.
+func (c *Client) backfillContacts() {
+	if c.Store == nil || c.Store.Messages == nil {
+		return
+	}
+
+	jids, err := c.Store.ListUnnamedContacts()
+	if err != nil {
+		c.Logger.Warn("backfill: query contacts failed", "err", err)
+		return
+	}
+
+	for _, jidStr := range jids {
+		parsed, err := types.ParseJID(jidStr)
+		if err != nil {
+			continue
+		}
+		c.upsertContact(parsed, "")
+	}
+}
+
 // ResolveRecipient attempts to resolve a recipient string (phone, JID, or name) to a WhatsApp JID.
 // Returns the resolved JID string, or an error if not found or ambiguous.
 func (c *Client) ResolveRecipient(recipient string) (string, error) {
@@ -119,17 +186,17 @@ func (c *Client) ResolveRecipient(recipient string) (string, error) {
 	rows, err := c.Store.Messages.Query(`
 		SELECT jid, name FROM chats
 		WHERE LOWER(name) LIKE ?
-		ORDER BY name LIMIT 10`, pattern)
+		UNION
+		SELECT jid, COALESCE(full_name, business_name) FROM contacts
+		WHERE (LOWER(full_name) LIKE ? OR LOWER(business_name) LIKE ?)
+			AND jid NOT IN (SELECT jid FROM chats)
+		ORDER BY 2 LIMIT 10`, pattern, pattern, pattern)
 	if err != nil {
 		return "", fmt.Errorf("search failed: %w", err)
 	}
 	defer rows.Close()
 
-	type match struct {
-		jid  string
-		name string
-	}
-	var matches []match
+	var matches []RecipientCandidate
 
 	for rows.Next() {
 		var jid string
@@ -137,7 +204,7 @@ func (c *Client) ResolveRecipient(recipient string) (string, error) {
 		if err := rows.Scan(&jid, &name); err != nil {
 			continue
 		}
-		matches = append(matches, match{jid: jid, name: name.String})
+		matches = append(matches, RecipientCandidate{JID: jid, Name: name.String})
 	}
 
 	if len(matches) == 0 {
@@ -145,18 +212,152 @@ func (c *Client) ResolveRecipient(recipient string) (string, error) {
 	}
 
 	if len(matches) == 1 {
-		return matches[0].jid, nil
+		return matches[0].JID, nil
 	}
 
+	return "", &AmbiguousRecipientError{Recipient: recipient, Candidates: matches}
+}
+
+// GroupMember is a person seen posting in group history, resolved to the
+// direct-message JID for their number.
+type GroupMember struct {
+	JID           string `json:"jid"`
+	Name          string `json:"name,omitempty"`
+	HasDirectChat bool   `json:"has_direct_chat"`
+}
+
+// ResolveGroupMember finds a group participant by name or number among the
+// distinct senders in group history, for members who aren't saved contacts and
+// so can't be found by ResolveRecipient. Returns an *AmbiguousRecipientError
+// when more than one sender matches.
+func (c *Client) ResolveGroupMember(query string) (*GroupMember, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("recipient cannot be empty")
+	}
+
+	senders, err := c.Store.FindGroupSenders(query, 10)
+	if err != nil {
+		return nil, fmt.Errorf("group member search failed: %w", err)
+	}
+	if len(senders) == 0 {
+		return nil, fmt.Errorf("no group member found matching '%s'", query)
+	}
+
+	// The same person can have posted under both their LID and phone number
+	candidates := make([]RecipientCandidate, 0, len(senders))
+	seen := make(map[string]bool, len(senders))
+	for _, s := range senders {
+		jid := c.memberJID(s.Sender).String()
+		if seen[jid] {
+			continue
+		}
+		seen[jid] = true
+		candidate := RecipientCandidate{JID: jid}
+		if s.Name != nil {
+			candidate.Name = *s.Name
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) > 1 {
+		return nil, &AmbiguousRecipientError{Recipient: query, Candidates: candidates}
+	}
+
+	member := &GroupMember{JID: candidates[0].JID, Name: candidates[0].Name}
+	if _, err := c.Store.GetChat(member.JID, false); err == nil {
+		member.HasDirectChat = true
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return member, nil
+}
+
+// memberJID returns the phone-number JID for a group sender's user part. Senders
+// in LID-addressed groups are stored by LID, which is mapped back to the phone
+// number when the mapping is known.
+func (c *Client) memberJID(user string) types.JID {
+	if pn, ok := c.pnForLID(user); ok {
+		return pn
+	}
+	return types.JID{User: user, Server: types.DefaultUserServer}
+}
+
+// pnForLID returns the phone-number JID mapped to a LID user, if known.
+func (c *Client) pnForLID(user string) (types.JID, bool) {
+	if c.WA == nil || c.WA.Store == nil || c.WA.Store.LIDs == nil {
+		return types.JID{}, false
+	}
+	pn, err := c.WA.Store.LIDs.GetPNForLID(context.Background(), types.JID{User: user, Server: types.HiddenUserServer})
+	if err != nil || pn.IsEmpty() {
+		return types.JID{}, false
+	}
+	return pn.ToNonAD(), true
+}
+
+// Outcomes of resolving a recipient in a batch.
+const (
+	ResolutionResolved  = "resolved"
+	ResolutionAmbiguous = "ambiguous"
+	ResolutionNotFound  = "not_found"
+)
+
+// RecipientResolution is the outcome of resolving one recipient in a batch.
+type RecipientResolution struct {
+	Recipient  string               `json:"recipient"`
+	Status     string               `json:"status"`
+	JID        string               `json:"jid,omitempty"`
+	IsGroup    bool                 `json:"is_group,omitempty"`
+	Candidates []RecipientCandidate `json:"candidates,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// ResolveRecipients resolves each recipient with ResolveRecipient, returning one
+// result per input in the same order. Ambiguous names carry their candidates.
+func (c *Client) ResolveRecipients(recipients []string) []RecipientResolution {
+	results := make([]RecipientResolution, len(recipients))
+	for i, r := range recipients {
+		results[i].Recipient = r
+
+		jid, err := c.ResolveRecipient(r)
+		var ambiguous *AmbiguousRecipientError
+		switch {
+		case errors.As(err, &ambiguous):
+			results[i].Status = ResolutionAmbiguous
+			results[i].Candidates = ambiguous.Candidates
+		case err != nil:
+			results[i].Status = ResolutionNotFound
+			results[i].Error = err.Error()
+		default:
+			results[i].Status = ResolutionResolved
+			results[i].JID = jid
+			results[i].IsGroup = strings.HasSuffix(jid, "@g.us")
+		}
+	}
+	return results
+}
+
+// RecipientCandidate is one possible match for an ambiguous recipient name.
+type RecipientCandidate struct {
+	JID  string `json:"jid"`
+	Name string `json:"name,omitempty"`
+}
+
+// AmbiguousRecipientError is returned by ResolveRecipient when a name matches
+// more than one chat or contact. Callers can inspect Candidates via errors.As.
+type AmbiguousRecipientError struct {
+	Recipient  string
+	Candidates []RecipientCandidate
+}
+
+func (e *AmbiguousRecipientError) Error() string {
 	var suggestions []string
-	for _, m := range matches {
-		if m.name != "" {
-			suggestions = append(suggestions, fmt.Sprintf("%s (%s)", m.name, m.jid))
+	for _, m := range e.Candidates {
+		if m.Name != "" {
+			suggestions = append(suggestions, fmt.Sprintf("%s (%s)", m.Name, m.JID))
 		} else {
-			suggestions = append(suggestions, m.jid)
+			suggestions = append(suggestions, m.JID)
 		}
 	}
-	return "", fmt.Errorf("multiple matches found for '%s': %s. Please use the full JID to disambiguate", recipient, strings.Join(suggestions, ", "))
+	return fmt.Sprintf("multiple matches found for '%s': %s. Please use the full JID to disambiguate", e.Recipient, strings.Join(suggestions, ", "))
 }
 
 // backfillChatNames finds chats without a proper name and updates them using
@@ -230,4 +431,15 @@ func (c *Client) backfillChatNames() {
 	if updated > 0 {
 		c.Logger.Info("backfill: updated chat names", "count", updated)
 	}
+	c.chatNamesBackfilled.Store(time.Now().UnixNano())
+}
+
+// ChatNamesBackfilled returns when backfillChatNames last finished, or the zero
+// time if it hasn't since startup.
+func (c *Client) ChatNamesBackfilled() time.Time {
+	n := c.chatNamesBackfilled.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
 }
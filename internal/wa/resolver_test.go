@@ -0,0 +1,114 @@
+package wa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wastore "go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// lidMappings is a whatsmeow LID store holding known LID to phone number mappings.
+type lidMappings struct {
+	wastore.LIDStore
+	pn map[string]string
+}
+
+func (l lidMappings) GetPNForLID(_ context.Context, lid types.JID) (types.JID, error) {
+	if user, ok := l.pn[lid.User]; ok {
+		return types.JID{User: user, Server: types.DefaultUserServer}, nil
+	}
+	return types.JID{}, nil
+}
+
+func TestUpsertContactLIDSenders(t *testing.T) {
+	const (
+		lid    = "99887766554433"
+		mapped = "11223344556677"
+		phone  = "447700900002"
+	)
+
+	tests := []struct {
+		name       string
+		sender     types.JID
+		wantRows   map[string]string // contact JID -> phone
+		wantAbsent []string
+	}{
+		{
+			name:     "phone number sender",
+			sender:   types.JID{User: phone, Server: types.DefaultUserServer, Device: 3},
+			wantRows: map[string]string{phone + "@s.whatsapp.net": phone},
+		},
+		{
+			name:       "unmapped LID stays a LID",
+			sender:     types.JID{User: lid, Server: types.HiddenUserServer},
+			wantRows:   map[string]string{lid + "@lid": ""},
+			wantAbsent: []string{lid + "@s.whatsapp.net"},
+		},
+		{
+			name:       "mapped LID also stored by phone number",
+			sender:     types.JID{User: mapped, Server: types.HiddenUserServer},
+			wantRows:   map[string]string{mapped + "@lid": phone, phone + "@s.whatsapp.net": phone},
+			wantAbsent: []string{mapped + "@s.whatsapp.net"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			setOwnNumber(c, "447700900000")
+			c.WA.Store.LIDs = lidMappings{pn: map[string]string{mapped: phone}}
+
+			c.upsertContact(tt.sender, "Sam")
+
+			for jid, wantPhone := range tt.wantRows {
+				var gotPhone, name string
+				if err := c.Store.Messages.QueryRow(`SELECT phone, push_name FROM contacts WHERE jid = ?`, jid).Scan(&gotPhone, &name); err != nil {
+					t.Errorf("contact %s: %v", jid, err)
+					continue
+				}
+				if gotPhone != wantPhone || name != "Sam" {
+					t.Errorf("contact %s = phone %q, name %q; want %q, Sam", jid, gotPhone, name, wantPhone)
+				}
+			}
+			for _, jid := range tt.wantAbsent {
+				var n int
+				if err := c.Store.Messages.QueryRow(`SELECT COUNT(*) FROM contacts WHERE jid = ?`, jid).Scan(&n); err != nil {
+					t.Fatal(err)
+				}
+				if n != 0 {
+					t.Errorf("bogus contact %s stored", jid)
+				}
+			}
+		})
+	}
+}
+
+func TestHistorySyncLIDSenderNamed(t *testing.T) {
+	const (
+		group = "120363000000000001@g.us"
+		lid   = "99887766554433"
+	)
+	c := newTestClient(t)
+	setOwnNumber(c, "447700900000")
+
+	msg := historyMessage("in", lid+"@lid", false, "hello", time.Now().Add(-time.Hour))
+	msg.Message.PushName = protoString("Sam")
+	c.handleHistorySync(historySync(group, "Group", msg))
+
+	senders, err := c.Store.FindGroupSenders("sam", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(senders) != 1 || senders[0].Sender != lid {
+		t.Fatalf("FindGroupSenders = %+v, want the LID sender", senders)
+	}
+	var n int
+	if err := c.Store.Messages.QueryRow(`SELECT COUNT(*) FROM contacts WHERE jid = ?`, lid+"@s.whatsapp.net").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Error("LID sender stored as a phone number contact")
+	}
+}
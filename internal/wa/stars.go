@@ -0,0 +1,52 @@
+package wa
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// SetMessageStarred stars or unstars a stored message on all linked devices,
+// then records the new state locally. The message's sender is looked up in the
+// database, since WhatsApp identifies a starred message by chat, ID and sender.
+func (c *Client) SetMessageStarred(chatJID, messageID string, starred bool) error {
+	chat, err := c.chatForAppState(chatJID)
+	if err != nil {
+		return err
+	}
+
+	var sender string
+	var isFromMe bool
+	err = c.Store.Messages.QueryRow(`SELECT sender, is_from_me FROM messages WHERE id = ? AND chat_jid = ?`, messageID, chatJID).Scan(&sender, &isFromMe)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("message %s not found in %s", messageID, chatJID)
+	} else if err != nil {
+		return err
+	}
+
+	// BuildStar leaves the sender out when it matches the chat, as WhatsApp
+	// expects for direct chats and for your own messages
+	senderJID := chat
+	if !isFromMe && chat.Server == types.GroupServer {
+		senderJID = c.participantJID(sender)
+	}
+
+	if err := c.sendAppState(appstate.BuildStar(chat, senderJID, messageID, isFromMe, starred)); err != nil {
+		return err
+	}
+	if _, err := c.Store.SetMessageStarred(chatJID, messageID, starred); err != nil {
+		c.Logger.Warn("failed to store starred state", "id", messageID, "chat_jid", chatJID, "err", err)
+	}
+	return nil
+}
+
+// handleStar applies star changes synced from other devices.
+func (c *Client) handleStar(evt *events.Star) {
+	if _, err := c.Store.SetMessageStarred(evt.ChatJID.String(), evt.MessageID, evt.Action.GetStarred()); err != nil {
+		c.Logger.Warn("failed to store starred state", "id", evt.MessageID, "chat_jid", evt.ChatJID, "err", err)
+	}
+}
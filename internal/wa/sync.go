@@ -1,62 +1,231 @@
 package wa
 
 import (
-	"database/sql"
 	"strings"
 	"time"
 
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/webhook"
 )
 
 // handleMessage processes real-time incoming messages and persists them.
 func (c *Client) handleMessage(msg *events.Message) {
 	chatJID := msg.Info.Chat.String()
-	sender := msg.Info.Sender.User
+	sender := c.senderUser(msg.Info.IsFromMe, msg.Info.Chat, msg.Info.Sender.String())
+
+	if reaction := msg.Message.GetReactionMessage(); reaction != nil {
+		c.storeReaction(chatJID, sender, reaction, msg.Info.Timestamp)
+		return
+	}
+	if msg.Message.GetPollUpdateMessage() != nil {
+		c.handlePollUpdate(msg, chatJID, sender)
+		return
+	}
+	if pm := msg.Message.GetProtocolMessage(); pm != nil {
+		switch pm.GetType() {
+		case waE2E.ProtocolMessage_REVOKE:
+			c.markRevoked(chatJID, pm.GetKey().GetID())
+			return
+		case waE2E.ProtocolMessage_MESSAGE_EDIT:
+			c.applyEdit(chatJID, pm.GetKey().GetID(), extractTextContent(pm.GetEditedMessage()), editTime(pm, msg.Info.Timestamp))
+			return
+		}
+	}
+
 	content := extractTextContent(msg.Message)
-	mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength := extractMediaInfo(msg.Message)
+	mediaType, _, _, _, _, _, _ := extractMediaInfo(msg.Message)
 
 	if content == "" && mediaType == "" {
 		return
 	}
 
-	// Ensure we have a per-sender chat entry with a friendly name for name lookups
-	if sender != "" {
-		indiv := types.JID{User: sender, Server: "s.whatsapp.net"}
-		var existing sql.NullString
-		_ = c.Store.Messages.QueryRow("SELECT name FROM chats WHERE jid = ?", indiv.String()).Scan(&existing)
-		if !existing.Valid {
-			resolved := c.resolvePreferredName(indiv)
-			_, _ = c.Store.Messages.Exec("INSERT INTO chats (jid, name) VALUES (?, ?)", indiv.String(), resolved)
-		} else if existing.String == "" {
-			resolved := c.resolvePreferredName(indiv)
-			if resolved != "" {
-				_, _ = c.Store.Messages.Exec("UPDATE chats SET name = ? WHERE jid = ?", resolved, indiv.String())
-			}
-		}
+	// Keep the sender's contact names cached for name lookups
+	if sender != "" && !msg.Info.IsFromMe {
+		c.upsertContact(msg.Info.Sender, msg.Info.PushName)
 	}
 
 	name := c.getChatName(msg.Info.Chat, chatJID, nil, sender)
-	if _, err := c.Store.Messages.Exec("INSERT OR REPLACE INTO chats (jid, name, last_message_time) VALUES (?, ?, ?)", chatJID, name, msg.Info.Timestamp); err != nil {
+	if err := c.Store.UpsertChat(chatJID, name, msg.Info.Timestamp); err != nil {
 		c.Logger.Warn("failed to upsert chat", "jid", chatJID, "err", err)
 	}
 
-	if _, err := c.Store.Messages.Exec(`INSERT OR REPLACE INTO messages
-		(id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		msg.Info.ID, chatJID, sender, content, msg.Info.Timestamp, msg.Info.IsFromMe, mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength,
-	); err != nil {
+	if err := c.insertMessage(msg.Info.ID, chatJID, sender, msg.Message, msg.Info.Timestamp, msg.Info.IsFromMe); err != nil {
 		c.Logger.Warn("failed to store message", "id", msg.Info.ID, "chat_jid", chatJID, "err", err)
+		return
+	}
+
+	c.storePoll(chatJID, msg.Info.ID, sender, msg.Message, msg.Info.Timestamp)
+
+	// Sending from another device implies everything before it was read
+	if msg.Info.IsFromMe {
+		c.markChatRead(chatJID, msg.Info.Timestamp)
+	}
+
+	c.notifyMessage(msg.Info.Chat, msg.Info.ID, name, sender, msg.Info.PushName, content, mediaType, msg.Info.Timestamp, msg.Info.IsFromMe)
+	if !msg.Info.IsFromMe {
+		if c.OnMessage != nil {
+			c.OnMessage(IncomingMessage{
+				ID:        msg.Info.ID,
+				ChatJID:   chatJID,
+				ChatName:  name,
+				Sender:    sender,
+				Content:   content,
+				MediaType: mediaType,
+				Timestamp: msg.Info.Timestamp,
+			})
+		}
 	}
+
+	c.pruneChat(chatJID)
+}
+
+// insertMessage stores a message with its text, media and reply metadata. Rows
+// are keyed by chat and message ID, so storing the same message again (e.g. a
+// sent message later delivered by history sync) updates the existing row. The
+// upsert fires the update trigger, so the FTS index follows the stored text.
+//
+// A redelivery carries the message as originally sent, so once a message has
+// been revoked or edited locally its content and media keys are kept rather
+// than restored. The stored filename is never replaced, since LocateMedia finds
+// downloaded files by it.
+func (c *Client) insertMessage(id, chatJID, sender string, m *waE2E.Message, t time.Time, fromMe bool) error {
+	mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength := extractMediaInfo(m)
+	replyToID, replyToMe, mentionsMe := c.involvement(m)
+	const changed = "COALESCE(messages.is_deleted, 0) = 1 OR messages.edited_at IS NOT NULL"
+	_, err := c.Store.Messages.Exec(`INSERT INTO messages
+		(id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length, mime_type, reply_to_id, reply_to_me, mentions_me)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id, chat_jid) DO UPDATE SET
+			sender = excluded.sender, timestamp = excluded.timestamp, is_from_me = excluded.is_from_me,
+			content = CASE WHEN `+changed+` THEN messages.content ELSE excluded.content END,
+			url = CASE WHEN `+changed+` THEN messages.url ELSE excluded.url END,
+			media_key = CASE WHEN `+changed+` THEN messages.media_key ELSE excluded.media_key END,
+			file_sha256 = CASE WHEN `+changed+` THEN messages.file_sha256 ELSE excluded.file_sha256 END,
+			file_enc_sha256 = CASE WHEN `+changed+` THEN messages.file_enc_sha256 ELSE excluded.file_enc_sha256 END,
+			filename = COALESCE(NULLIF(messages.filename, ''), excluded.filename),
+			media_type = excluded.media_type, file_length = excluded.file_length, mime_type = excluded.mime_type,
+			reply_to_id = excluded.reply_to_id, reply_to_me = excluded.reply_to_me, mentions_me = excluded.mentions_me`,
+		id, chatJID, sender, extractTextContent(m), store.FormatTime(t), fromMe, mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength, mediaMimeType(m), replyToID, replyToMe, mentionsMe,
+	)
+	return err
+}
+
+// storeSent records a message this client just sent. WhatsApp doesn't echo it
+// back as a message event, so without this it would only appear after a later
+// history sync.
+func (c *Client) storeSent(chat types.JID, id string, m *waE2E.Message, t time.Time) {
+	chatJID := chat.String()
+	name := c.getChatName(chat, chatJID, nil, "")
+	if err := c.Store.UpsertChat(chatJID, name, t); err != nil {
+		c.Logger.Warn("failed to upsert chat", "jid", chatJID, "err", err)
+	}
+	if err := c.insertMessage(id, chatJID, c.ownUser(), m, t, true); err != nil {
+		c.Logger.Warn("failed to store sent message", "id", id, "chat_jid", chatJID, "err", err)
+		return
+	}
+	mediaType, _, _, _, _, _, _ := extractMediaInfo(m)
+	c.notifyMessage(chat, id, name, c.ownUser(), "", extractTextContent(m), mediaType, t, true)
+	c.pruneChat(chatJID)
+}
+
+// pruneChat drops a chat's oldest messages beyond MaxMessagesPerChat, if set.
+func (c *Client) pruneChat(chatJID string) {
+	if c.MaxMessagesPerChat <= 0 {
+		return
+	}
+	if pruned, err := c.Store.PruneChatMessages(chatJID, c.MaxMessagesPerChat); err != nil {
+		c.Logger.Warn("failed to prune chat messages", "chat_jid", chatJID, "err", err)
+	} else if pruned > 0 {
+		c.Logger.Debug("pruned old chat messages", "chat_jid", chatJID, "count", pruned)
+	}
+}
+
+// storeReaction records (or, for an empty emoji, removes) a sender's reaction to a message.
+func (c *Client) storeReaction(chatJID, sender string, reaction *waE2E.ReactionMessage, t time.Time) {
+	if ms := reaction.GetSenderTimestampMS(); ms > 0 {
+		t = time.UnixMilli(ms)
+	}
+	target := reaction.GetKey().GetID()
+	if err := c.Store.UpsertReaction(chatJID, target, sender, reaction.GetText(), t); err != nil {
+		c.Logger.Warn("failed to store reaction", "target", target, "chat_jid", chatJID, "err", err)
+	}
+}
+
+// markRevoked flags a message its sender deleted for everyone.
+func (c *Client) markRevoked(chatJID, messageID string) {
+	if messageID == "" {
+		return
+	}
+	found, err := c.Store.MarkMessageDeleted(chatJID, messageID)
+	if err != nil {
+		c.Logger.Warn("failed to mark message deleted", "id", messageID, "chat_jid", chatJID, "err", err)
+		return
+	}
+	if !found {
+		c.Logger.Debug("revoked message not stored", "id", messageID, "chat_jid", chatJID)
+	}
+}
+
+// webhookPreviewLength caps the content preview sent in message webhook events.
+const webhookPreviewLength = 200
+
+// notifyMessage emits a message webhook event for a persisted message, whether
+// received, sent from another device, or sent by this client. The event carries
+// the full text in content plus a shortened preview, both omitted when
+// WebhookRedactContent is set.
+func (c *Client) notifyMessage(chat types.JID, id, chatName, sender, pushName, content, mediaType string, t time.Time, fromMe bool) {
+	if c.Webhook == nil {
+		return
+	}
+
+	senderName := pushName
+	if sender != "" {
+		if name, _ := c.Store.GetContactName(types.JID{User: sender, Server: types.DefaultUserServer}.String()); name != "" {
+			senderName = name
+		}
+	}
+
+	data := map[string]any{
+		"id":          id,
+		"chat_jid":    chat.String(),
+		"chat_name":   chatName,
+		"is_group":    chat.Server == types.GroupServer,
+		"sender":      sender,
+		"sender_name": senderName,
+		"is_from_me":  fromMe,
+		"timestamp":   t.UTC().Format(time.RFC3339),
+	}
+	if mediaType != "" {
+		data["media_type"] = mediaType
+	}
+	if !c.WebhookRedactContent && content != "" {
+		preview := []rune(content)
+		if len(preview) > webhookPreviewLength {
+			preview = append(preview[:webhookPreviewLength], '…')
+		}
+		data["content"] = content
+		data["preview"] = string(preview)
+	}
+
+	c.Webhook.Notify(webhook.EventMessage, data)
 }
 
 // handleHistorySync persists conversations and messages received during a history sync.
 func (c *Client) handleHistorySync(hs *events.HistorySync) {
-	if hs == nil || hs.Data.Conversations == nil {
+	if hs == nil || hs.Data == nil {
 		return
 	}
 
-	synced := 0
+	var cutoff time.Time
+	if c.HistoryMaxAge > 0 {
+		cutoff = time.Now().Add(-c.HistoryMaxAge)
+	}
+
+	synced, tooOld := 0, 0
 	for _, conv := range hs.Data.Conversations {
 		if conv == nil || conv.ID == nil {
 			continue
@@ -75,9 +244,16 @@ func (c *Client) handleHistorySync(hs *events.HistorySync) {
 			ts := conv.Messages[0].Message.GetMessageTimestamp()
 			if ts != 0 {
 				t := time.Unix(int64(ts), 0)
-				if _, err := c.Store.Messages.Exec("INSERT OR REPLACE INTO chats (jid, name, last_message_time) VALUES (?, ?, ?)", chatJID, name, t); err != nil {
+				if err := c.Store.UpsertChat(chatJID, name, t); err != nil {
 					c.Logger.Warn("history sync: failed to upsert chat", "jid", chatJID, "err", err)
 				}
+				c.seedReadMarker(chatJID, conv)
+				if err := c.Store.SetChatArchived(chatJID, conv.GetArchived()); err != nil {
+					c.Logger.Warn("history sync: failed to store archived state", "jid", chatJID, "err", err)
+				}
+				if err := c.Store.SetChatPinned(chatJID, conv.GetPinned() > 0); err != nil {
+					c.Logger.Warn("history sync: failed to store pinned state", "jid", chatJID, "err", err)
+				}
 			}
 		}
 
@@ -86,81 +262,188 @@ func (c *Client) handleHistorySync(hs *events.HistorySync) {
 				continue
 			}
 
-			var text string
-			if m.Message.Message != nil {
-				text = extractTextContent(m.Message.Message)
-			}
-
-			mt, fn, u, mk, sha, enc, fl := "", "", "", ([]byte)(nil), ([]byte)(nil), ([]byte)(nil), uint64(0)
-			if m.Message.Message != nil {
-				mt, fn, u, mk, sha, enc, fl = extractMediaInfo(m.Message.Message)
-			}
-
-			if text == "" && mt == "" {
-				c.Logger.Debug("history sync: skipping non-text/non-media message", "key", m.Message.Key)
-				continue
-			}
-
 			fromMe := false
-			snd := jid.User
+			participant := m.Message.GetParticipant()
 			if m.Message.Key != nil {
 				if m.Message.Key.FromMe != nil {
 					fromMe = *m.Message.Key.FromMe
 				}
-				if !fromMe && m.Message.Key.Participant != nil && *m.Message.Key.Participant != "" {
-					snd = *m.Message.Key.Participant
-				}
-				if fromMe && c.WA != nil && c.WA.Store != nil && c.WA.Store.ID != nil {
-					snd = c.WA.Store.ID.User
+				if m.Message.Key.Participant != nil && *m.Message.Key.Participant != "" {
+					participant = *m.Message.Key.Participant
 				}
 			}
+			snd := c.senderUser(fromMe, jid, participant)
 
-			if strings.Contains(snd, "@") {
-				if pj, err := types.ParseJID(snd); err == nil {
-					snd = pj.User
-				} else {
-					if i := strings.Index(snd, "@"); i > 0 {
-						snd = snd[:i]
-					}
+			id := ""
+			if m.Message.Key != nil && m.Message.Key.ID != nil {
+				id = *m.Message.Key.ID
+			}
+
+			ts := m.Message.GetMessageTimestamp()
+
+			if reaction := m.Message.GetMessage().GetReactionMessage(); reaction != nil {
+				c.storeReaction(chatJID, snd, reaction, time.Unix(int64(ts), 0))
+				continue
+			}
+			if pm := m.Message.GetMessage().GetProtocolMessage(); pm != nil {
+				switch pm.GetType() {
+				case waE2E.ProtocolMessage_REVOKE:
+					c.markRevoked(chatJID, pm.GetKey().GetID())
+					continue
+				case waE2E.ProtocolMessage_MESSAGE_EDIT:
+					c.applyEdit(chatJID, pm.GetKey().GetID(), extractTextContent(pm.GetEditedMessage()), editTime(pm, time.Unix(int64(ts), 0)))
+					continue
 				}
 			}
 
-			// Upsert a per-sender chat entry for name resolution
-			if !fromMe && snd != "" {
-				indiv := types.JID{User: snd, Server: "s.whatsapp.net"}
-				var existing sql.NullString
-				_ = c.Store.Messages.QueryRow("SELECT name FROM chats WHERE jid = ?", indiv.String()).Scan(&existing)
-				if !existing.Valid {
-					resolved := c.resolvePreferredName(indiv)
-					_, _ = c.Store.Messages.Exec("INSERT INTO chats (jid, name) VALUES (?, ?)", indiv.String(), resolved)
-				} else if existing.String == "" {
-					resolved := c.resolvePreferredName(indiv)
-					if resolved != "" {
-						_, _ = c.Store.Messages.Exec("UPDATE chats SET name = ? WHERE jid = ?", resolved, indiv.String())
-					}
+			// Reactions to this message arrive attached to it rather than as separate messages
+			for _, r := range m.Message.GetReactions() {
+				key := r.GetKey()
+				reactor := c.senderUser(key.GetFromMe(), jid, key.GetParticipant())
+				if err := c.Store.UpsertReaction(chatJID, id, reactor, r.GetText(), time.UnixMilli(r.GetSenderTimestampMS())); err != nil {
+					c.Logger.Warn("history sync: failed to store reaction", "id", id, "chat_jid", chatJID, "err", err)
 				}
 			}
 
-			id := ""
-			if m.Message.Key != nil && m.Message.Key.ID != nil {
-				id = *m.Message.Key.ID
+			text := extractTextContent(m.Message.Message)
+			mt, _, _, _, _, _, _ := extractMediaInfo(m.Message.Message)
+
+			if text == "" && mt == "" {
+				c.Logger.Debug("history sync: skipping non-text/non-media message", "key", m.Message.Key)
+				continue
+			}
+
+			// Keep the sender's contact names cached for name resolution
+			if !fromMe && snd != "" {
+				c.upsertContact(senderJID(jid, participant, snd), m.Message.GetPushName())
 			}
 
-			ts := m.Message.GetMessageTimestamp()
 			if ts == 0 {
 				continue
 			}
 			t := time.Unix(int64(ts), 0)
+			if t.Before(cutoff) {
+				tooOld++
+				continue
+			}
 
-			if _, err := c.Store.Messages.Exec(`INSERT OR REPLACE INTO messages
-				(id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length)
-				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, id, chatJID, snd, text, t, fromMe, mt, fn, u, mk, sha, enc, fl); err != nil {
+			if err := c.insertMessage(id, chatJID, snd, m.Message.Message, t, fromMe); err != nil {
 				c.Logger.Warn("history sync: failed to store message", "id", id, "chat_jid", chatJID, "err", err)
 				continue
 			}
+			c.storePoll(chatJID, id, snd, m.Message.Message, t)
+
+			// Like reactions, history sync delivers a poll's votes already decrypted on the poll itself
+			for _, u := range m.Message.GetPollUpdates() {
+				key := u.GetPollUpdateMessageKey()
+				voter := c.senderUser(key.GetFromMe(), jid, key.GetParticipant())
+				c.storePollVote(chatJID, id, voter, u.GetVote().GetSelectedOptions(), time.UnixMilli(u.GetSenderTimestampMS()))
+			}
 			synced++
 		}
 	}
 
 	c.Logger.Info("history sync persisted messages", "count", synced)
+	if tooOld > 0 {
+		c.Logger.Debug("history sync skipped messages older than HISTORY_SYNC_MAX_AGE", "count", tooOld, "cutoff", cutoff)
+	}
+
+	c.historyBatches.Add(1)
+	c.historyProgress.Store(hs.Data.GetProgress())
+	c.historyOnce.Do(func() { close(c.historySynced) })
+
+	if hs.Data.GetProgress() >= 100 {
+		c.historyComplete.Store(true)
+		c.Webhook.Notify(webhook.EventHistorySyncComplete, map[string]any{"sync_type": hs.Data.GetSyncType().String()})
+	}
+}
+
+// HistorySynced returns a channel that is closed once the first history sync
+// batch has been persisted.
+func (c *Client) HistorySynced() <-chan struct{} {
+	return c.historySynced
+}
+
+// HistorySyncProgress reports how many history sync batches have been persisted
+// and the completion percentage WhatsApp reported with the latest one.
+func (c *Client) HistorySyncProgress() (batches int, percent int) {
+	return int(c.historyBatches.Load()), int(c.historyProgress.Load())
+}
+
+// HistorySyncComplete reports whether a history sync batch has reported 100%
+// progress since startup. Restarts with an existing session usually receive no
+// history sync, so this stays false.
+func (c *Client) HistorySyncComplete() bool {
+	return c.historyComplete.Load()
+}
+
+// senderUser returns the user part to record as a message's sender.
+// Messages sent by the logged-in account always record the account's own number,
+// regardless of how the participant was addressed (e.g. LID in groups); incoming
+// group messages use the participant, and direct messages use the chat's user.
+func (c *Client) senderUser(fromMe bool, chat types.JID, participant string) string {
+	if fromMe {
+		if own := c.ownUser(); own != "" {
+			return own
+		}
+	}
+
+	if participant != "" {
+		if pj, err := types.ParseJID(participant); err == nil && pj.User != "" {
+			return pj.User
+		}
+		if i := strings.Index(participant, "@"); i > 0 {
+			return participant[:i]
+		}
+		return participant
+	}
+
+	if fromMe || chat.Server == types.GroupServer {
+		return ""
+	}
+	return chat.User
+}
+
+// involvement returns the ID of the message a message replies to (empty if it
+// isn't a reply), whether that quoted message is ours, and whether it @mentions us.
+func (c *Client) involvement(m *waE2E.Message) (replyToID string, replyToMe, mentionsMe bool) {
+	ctx := messageContextInfo(m)
+	if ctx == nil {
+		return "", false, false
+	}
+	if ctx.GetStanzaID() != "" {
+		replyToID = ctx.GetStanzaID()
+		if jid, err := types.ParseJID(ctx.GetParticipant()); err == nil {
+			replyToMe = c.isOwnJID(jid.ToNonAD())
+		}
+	}
+	for _, mentioned := range ctx.GetMentionedJID() {
+		if jid, err := types.ParseJID(mentioned); err == nil && c.isOwnJID(jid.ToNonAD()) {
+			mentionsMe = true
+			break
+		}
+	}
+	return replyToID, replyToMe, mentionsMe
+}
+
+// senderJID returns the JID of an incoming history message's sender: the
+// participant when it parses, otherwise the phone-number JID of user.
+func senderJID(chat types.JID, participant, user string) types.JID {
+	if participant != "" {
+		if pj, err := types.ParseJID(participant); err == nil && pj.User != "" {
+			return pj
+		}
+	}
+	if chat.Server == types.DefaultUserServer || chat.Server == types.HiddenUserServer {
+		return chat
+	}
+	return types.JID{User: user, Server: types.DefaultUserServer}
+}
+
+// ownUser returns the logged-in account's phone number user part, if known.
+func (c *Client) ownUser() string {
+	if c.WA == nil || c.WA.Store == nil || c.WA.Store.ID == nil {
+		return ""
+	}
+	return c.WA.Store.ID.User
 }
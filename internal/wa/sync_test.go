@@ -0,0 +1,402 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	wastore "go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/eddmann/whatsapp-mcp/internal/domain"
+	"github.com/eddmann/whatsapp-mcp/internal/store"
+	"github.com/eddmann/whatsapp-mcp/internal/webhook"
+)
+
+const testChat = "447700900001@s.whatsapp.net"
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	db, err := store.Open(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &Client{Store: db, Logger: slog.New(slog.DiscardHandler), historySynced: make(chan struct{})}
+}
+
+func textMessage(text string) *waE2E.Message {
+	return &waE2E.Message{Conversation: protoString(text)}
+}
+
+// emptyContacts is a whatsmeow contact store that knows nobody.
+type emptyContacts struct{ wastore.ContactStore }
+
+func (emptyContacts) GetContact(context.Context, types.JID) (types.ContactInfo, error) {
+	return types.ContactInfo{}, nil
+}
+
+// setOwnNumber gives c a logged-in account with the given phone number.
+func setOwnNumber(c *Client, user string) {
+	c.WA = &whatsmeow.Client{Store: &wastore.Device{
+		ID:       &types.JID{User: user, Server: types.DefaultUserServer},
+		Contacts: emptyContacts{},
+	}}
+}
+
+// historyMessage is a history sync text message sent by participant.
+func historyMessage(id, participant string, fromMe bool, text string, t time.Time) *waHistorySync.HistorySyncMsg {
+	ts := uint64(t.Unix())
+	return &waHistorySync.HistorySyncMsg{Message: &waWeb.WebMessageInfo{
+		Key:              &waCommon.MessageKey{ID: protoString(id), FromMe: protoBool(fromMe), Participant: protoString(participant)},
+		Message:          textMessage(text),
+		MessageTimestamp: &ts,
+	}}
+}
+
+// historySync wraps one conversation's messages in a history sync event.
+func historySync(chatJID, name string, msgs ...*waHistorySync.HistorySyncMsg) *events.HistorySync {
+	return &events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType:      waHistorySync.HistorySync_INITIAL_BOOTSTRAP.Enum(),
+		Conversations: []*waHistorySync.Conversation{{ID: protoString(chatJID), Name: protoString(name), Messages: msgs}},
+	}}
+}
+
+func storedFilename(t *testing.T, c *Client, chatJID, id string) string {
+	t.Helper()
+	var filename string
+	if err := c.Store.Messages.QueryRow(`SELECT filename FROM messages WHERE chat_jid = ? AND id = ?`, chatJID, id).Scan(&filename); err != nil {
+		t.Fatalf("read filename: %v", err)
+	}
+	return filename
+}
+
+func TestInsertMessageUpsert(t *testing.T) {
+	ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		local func(c *Client) error
+		want  string
+	}{
+		{
+			name:  "redelivery refreshes content",
+			local: func(c *Client) error { return nil },
+			want:  "redelivered",
+		},
+		{
+			name: "revoked message stays cleared",
+			local: func(c *Client) error {
+				_, err := c.Store.MarkMessageDeleted(testChat, "m1")
+				return err
+			},
+			want: "",
+		},
+		{
+			name: "edited message keeps its edit",
+			local: func(c *Client) error {
+				_, err := c.Store.EditMessageContent(testChat, "m1", "edited", ts.Add(time.Minute))
+				return err
+			},
+			want: "edited",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			if err := c.Store.UpsertChat(testChat, "Test", ts); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.insertMessage("m1", testChat, "447700900001", textMessage("original"), ts, false); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.local(c); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.insertMessage("m1", testChat, "447700900001", textMessage("redelivered"), ts, false); err != nil {
+				t.Fatal(err)
+			}
+
+			msg, err := c.Store.GetMessage(testChat, "m1")
+			if err != nil || msg == nil {
+				t.Fatalf("GetMessage = %v, %v", msg, err)
+			}
+			got := ""
+			if msg.Content != nil {
+				got = *msg.Content
+			}
+			if got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertMessageKeepsFilename(t *testing.T) {
+	c := newTestClient(t)
+	ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := c.Store.UpsertChat(testChat, "Test", ts); err != nil {
+		t.Fatal(err)
+	}
+	doc := func(name string) *waE2E.Message {
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{FileName: protoString(name), Mimetype: protoString("application/pdf")}}
+	}
+
+	if err := c.insertMessage("m1", testChat, "447700900001", doc("first.pdf"), ts, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.insertMessage("m1", testChat, "447700900001", doc("second.pdf"), ts, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := storedFilename(t, c, testChat, "m1"); got != "first.pdf" {
+		t.Errorf("filename = %q, want first.pdf", got)
+	}
+}
+
+func TestStoreSentNotifiesWebhook(t *testing.T) {
+	long := strings.Repeat("x", webhookPreviewLength+50)
+
+	tests := []struct {
+		name        string
+		redact      bool
+		wantContent string
+	}{
+		{name: "full content", wantContent: long},
+		{name: "redacted", redact: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make(chan webhook.Event, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var ev webhook.Event
+				if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+					t.Errorf("decode event: %v", err)
+				}
+				events <- ev
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t)
+			c.Webhook = webhook.New(srv.URL, "", slog.Default())
+			c.WebhookRedactContent = tt.redact
+			ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			if err := c.Store.UpsertChat(testChat, "Test", ts); err != nil {
+				t.Fatal(err)
+			}
+
+			chat, err := types.ParseJID(testChat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.storeSent(chat, "sent1", textMessage(long), ts)
+
+			var ev webhook.Event
+			select {
+			case ev = <-events:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no webhook event delivered")
+			}
+			if ev.Event != webhook.EventMessage {
+				t.Errorf("event = %q, want %q", ev.Event, webhook.EventMessage)
+			}
+			if ev.Data["is_from_me"] != true {
+				t.Errorf("is_from_me = %v, want true", ev.Data["is_from_me"])
+			}
+			content, _ := ev.Data["content"].(string)
+			if content != tt.wantContent {
+				t.Errorf("content has %d chars, want %d", len(content), len(tt.wantContent))
+			}
+			if _, ok := ev.Data["preview"]; ok == tt.redact {
+				t.Errorf("preview present = %v, want %v", ok, !tt.redact)
+			}
+		})
+	}
+}
+
+func TestStoreSentEnforcesChatCap(t *testing.T) {
+	c := newTestClient(t)
+	c.MaxMessagesPerChat = 5
+	chat, err := types.ParseJID(testChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Store.UpsertChat(testChat, "Test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 200; i++ {
+		c.storeSent(chat, fmt.Sprintf("m%03d", i), textMessage("hi"), start.Add(time.Duration(i)*time.Second))
+	}
+
+	var n int
+	if err := c.Store.Messages.QueryRow(`SELECT COUNT(*) FROM messages WHERE chat_jid = ?`, testChat).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	// Pruning runs in batches, so the chat may sit above the cap by less than a batch
+	if n < c.MaxMessagesPerChat || n >= c.MaxMessagesPerChat+50 {
+		t.Errorf("chat holds %d messages, want between %d and %d", n, c.MaxMessagesPerChat, c.MaxMessagesPerChat+49)
+	}
+}
+
+func TestMediaMessageJSONOmitsDownloadMetadata(t *testing.T) {
+	c := newTestClient(t)
+	ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := c.Store.UpsertChat(testChat, "Test", ts); err != nil {
+		t.Fatal(err)
+	}
+
+	blob := func(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+	msgs := map[string]*waE2E.Message{
+		"image": {ImageMessage: &waE2E.ImageMessage{
+			Caption: protoString("photo"), Mimetype: protoString("image/jpeg"), URL: protoString("https://mmg.whatsapp.net/x"),
+			MediaKey: blob(1), FileSHA256: blob(2), FileEncSHA256: blob(3), JPEGThumbnail: blob(4),
+		}},
+		"audio": {AudioMessage: &waE2E.AudioMessage{
+			Mimetype: protoString("audio/ogg; codecs=opus"), PTT: protoBool(true),
+			MediaKey: blob(5), FileSHA256: blob(6), FileEncSHA256: blob(7), Waveform: blob(8),
+		}},
+	}
+	for id, m := range msgs {
+		if err := c.insertMessage(id, testChat, "447700900001", m, ts, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listed, err := c.Store.ListMessages(domain.ListMessagesOptions{ChatJID: testChat, IncludeSystem: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	media, err := c.Store.ListMedia(domain.ListMediaOptions{ChatJID: testChat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(msgs) || len(media) != len(msgs) {
+		t.Fatalf("listed %d messages and %d media items, want %d of each", len(listed), len(media), len(msgs))
+	}
+
+	for name, v := range map[string]any{"messages": listed, "media": media} {
+		out, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{"media_key", "file_sha256", "file_enc_sha256", "waveform", "url"} {
+			if bytes.Contains(out, []byte(`"`+field+`"`)) {
+				t.Errorf("%s JSON has a %q field: %s", name, field, out)
+			}
+		}
+		for b := byte(1); b <= 8; b++ {
+			if bytes.Contains(out, []byte(base64.StdEncoding.EncodeToString(blob(b)))) {
+				t.Errorf("%s JSON contains base64 media metadata: %s", name, out)
+			}
+		}
+	}
+}
+
+func TestHistorySyncGroupSenders(t *testing.T) {
+	const (
+		group = "120363000000000001@g.us"
+		own   = "447700900000"
+	)
+	c := newTestClient(t)
+	setOwnNumber(c, own)
+
+	ts := time.Now().Add(-time.Hour).Truncate(time.Second)
+	c.handleHistorySync(historySync(group, "Group",
+		historyMessage("out-lid", "99887766554433@lid", true, "sent from linked device", ts),
+		historyMessage("out-pn", own+"@s.whatsapp.net", true, "sent from phone", ts.Add(time.Second)),
+		historyMessage("out-none", "", true, "sent without participant", ts.Add(2*time.Second)),
+		historyMessage("in", "447700900002@s.whatsapp.net", false, "received", ts.Add(3*time.Second)),
+	))
+
+	tests := []struct {
+		id         string
+		wantSender string
+		wantFromMe bool
+	}{
+		{id: "out-lid", wantSender: own, wantFromMe: true},
+		{id: "out-pn", wantSender: own, wantFromMe: true},
+		{id: "out-none", wantSender: own, wantFromMe: true},
+		{id: "in", wantSender: "447700900002", wantFromMe: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			msg, err := c.Store.GetMessage(group, tt.id)
+			if err != nil || msg == nil {
+				t.Fatalf("GetMessage = %v, %v", msg, err)
+			}
+			if msg.Sender != tt.wantSender {
+				t.Errorf("sender = %q, want %q", msg.Sender, tt.wantSender)
+			}
+			if msg.IsFromMe != tt.wantFromMe {
+				t.Errorf("is_from_me = %v, want %v", msg.IsFromMe, tt.wantFromMe)
+			}
+		})
+	}
+}
+
+func TestHistorySyncMaxAge(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	ages := map[string]time.Duration{
+		"recent":  time.Hour,
+		"weeks":   20 * 24 * time.Hour,
+		"old":     40 * 24 * time.Hour,
+		"ancient": 400 * 24 * time.Hour,
+	}
+
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		want   []string
+	}{
+		{name: "no limit keeps everything", want: []string{"recent", "weeks", "old", "ancient"}},
+		{name: "30 days", maxAge: 30 * 24 * time.Hour, want: []string{"recent", "weeks"}},
+		{name: "1 day", maxAge: 24 * time.Hour, want: []string{"recent"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			setOwnNumber(c, "447700900000")
+			c.HistoryMaxAge = tt.maxAge
+
+			// Conversations list their newest message first
+			var msgs []*waHistorySync.HistorySyncMsg
+			for _, id := range []string{"recent", "weeks", "old", "ancient"} {
+				msgs = append(msgs, historyMessage(id, "", false, "message "+id, now.Add(-ages[id])))
+			}
+			c.handleHistorySync(historySync(testChat, "Test", msgs...))
+
+			for id := range ages {
+				msg, err := c.Store.GetMessage(testChat, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if stored, want := msg != nil, slices.Contains(tt.want, id); stored != want {
+					t.Errorf("message %s stored = %v, want %v", id, stored, want)
+				}
+			}
+			if chat, err := c.Store.GetChat(testChat, false); err != nil || chat == nil {
+				t.Errorf("GetChat = %v, %v; want the chat kept", chat, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// userInfoBatchSize caps how many JIDs are sent in a single usync query.
+const userInfoBatchSize = 50
+
+// UserInfoResult represents profile information fetched for a single JID.
+type UserInfoResult struct {
+	JID          string
+	Status       string
+	PictureID    string
+	Devices      []string
+	VerifiedName string
+	// VerifiedIssuer is the business certificate issuer ("smb" or "ent"); empty for non-business accounts.
+	VerifiedIssuer string
+	Err            error
+}
+
+// GetUserInfo fetches status, picture ID, device list and verified business name
+// for many JIDs using batched GetUserInfo calls. Results are returned in input order.
+// A failed batch only marks its own JIDs as failed, and a rate-limit response stops
+// further batches so whatever already succeeded is still returned.
+func (c *Client) GetUserInfo(jids []string) ([]UserInfoResult, error) {
+	if !c.WA.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	results := make([]UserInfoResult, len(jids))
+	var pending []int
+	parsed := make(map[int]types.JID, len(jids))
+
+	for i, s := range jids {
+		results[i].JID = s
+		jid, err := types.ParseJID(s)
+		if err != nil {
+			results[i].Err = fmt.Errorf("invalid JID: %w", err)
+			continue
+		}
+		if jid.Server == types.GroupServer {
+			results[i].Err = fmt.Errorf("user info is only available for individual contacts")
+			continue
+		}
+		parsed[i] = jid.ToNonAD()
+		pending = append(pending, i)
+	}
+
+	for start := 0; start < len(pending); start += userInfoBatchSize {
+		end := min(start+userInfoBatchSize, len(pending))
+		batch := pending[start:end]
+
+		query := make([]types.JID, 0, len(batch))
+		for _, i := range batch {
+			query = append(query, parsed[i])
+		}
+
+		info, err := c.WA.GetUserInfo(query)
+		if err != nil {
+			if errors.Is(err, whatsmeow.ErrIQRateOverLimit) {
+				c.Logger.Warn("user info: rate limited, returning partial results", "fetched", start, "total", len(pending))
+				for _, i := range pending[start:] {
+					results[i].Err = fmt.Errorf("rate limited by WhatsApp, try again later")
+				}
+				break
+			}
+			for _, i := range batch {
+				results[i].Err = err
+			}
+			continue
+		}
+
+		for _, i := range batch {
+			ui, ok := info[parsed[i]]
+			if !ok {
+				results[i].Err = fmt.Errorf("not on WhatsApp or no info returned")
+				continue
+			}
+			results[i].Status = ui.Status
+			results[i].PictureID = ui.PictureID
+			for _, d := range ui.Devices {
+				results[i].Devices = append(results[i].Devices, d.String())
+			}
+			if ui.VerifiedName != nil && ui.VerifiedName.Details != nil {
+				results[i].VerifiedName = ui.VerifiedName.Details.GetVerifiedName()
+				results[i].VerifiedIssuer = ui.VerifiedName.Details.GetIssuer()
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// ContactBusinessName returns the business name from the local contact store, if any.
+func (c *Client) ContactBusinessName(jidStr string) string {
+	jid, err := types.ParseJID(jidStr)
+	if err != nil {
+		return ""
+	}
+	contact, err := c.WA.Store.Contacts.GetContact(context.Background(), jid)
+	if err != nil {
+		return ""
+	}
+	return contact.BusinessName
+}
@@ -0,0 +1,125 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// SendContact shares a contact card with the given display name and phone
+// numbers. Phones must be international numbers as digits only, e.g. "447123456789".
+func (c *Client) SendContact(recipient, name string, phones []string) (*SendMessageResult, error) {
+	if !c.WA.IsConnected() {
+		return &SendMessageResult{Success: false, Message: "not connected"}, fmt.Errorf("not connected")
+	}
+
+	jid, err := parseRecipient(recipient)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: "invalid recipient"}, err
+	}
+
+	msg := &waE2E.Message{
+		ContactMessage: &waE2E.ContactMessage{
+			DisplayName: protoString(name),
+			Vcard:       protoString(buildVCard(name, phones)),
+		},
+	}
+
+	resp, err := c.WA.SendMessage(context.Background(), jid, msg)
+	if err != nil {
+		return &SendMessageResult{Success: false, Message: err.Error()}, err
+	}
+	c.storeSent(jid, resp.ID, msg, resp.Timestamp)
+	c.markChatRead(jid.String(), resp.Timestamp)
+
+	return &SendMessageResult{
+		Success:   true,
+		Message:   fmt.Sprintf("contact sent to %s", recipient),
+		MessageID: resp.ID,
+		ChatJID:   jid.String(),
+		Timestamp: resp.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// buildVCard returns a vCard 3.0 block for a contact. Each phone gets a waid
+// parameter so WhatsApp links the card to the number's account.
+func buildVCard(name string, phones []string) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\nVERSION:3.0\n")
+	fmt.Fprintf(&b, "N:;%s;;;\n", escapeVCard(name))
+	fmt.Fprintf(&b, "FN:%s\n", escapeVCard(name))
+	for _, phone := range phones {
+		fmt.Fprintf(&b, "TEL;type=CELL;type=VOICE;waid=%s:+%s\n", phone, phone)
+	}
+	b.WriteString("END:VCARD")
+	return b.String()
+}
+
+// parseVCard extracts the formatted name and phone numbers from a vCard.
+// Phones are returned as digits, preferring the waid parameter when present.
+func parseVCard(vcard string) (name string, phones []string) {
+	// Unfold continuation lines (RFC 6350 §3.2) before splitting.
+	vcard = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(vcard)
+
+	for _, line := range strings.Split(vcard, "\n") {
+		line = strings.TrimRight(line, "\r")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := strings.Split(key, ";")
+		switch strings.ToUpper(params[0]) {
+		case "FN":
+			name = unescapeVCard(value)
+		case "TEL":
+			phone := digitsOnly(value)
+			for _, p := range params[1:] {
+				if k, v, ok := strings.Cut(p, "="); ok && strings.EqualFold(k, "waid") && v != "" {
+					phone = v
+				}
+			}
+			if phone != "" {
+				phones = append(phones, phone)
+			}
+		}
+	}
+	return name, phones
+}
+
+// contactText renders a received contact card as searchable message text.
+func contactText(contact *waE2E.ContactMessage) string {
+	name, phones := parseVCard(contact.GetVcard())
+	if name == "" {
+		name = contact.GetDisplayName()
+	}
+	if name == "" {
+		name = "Contact"
+	}
+	if len(phones) == 0 {
+		return fmt.Sprintf("👤 %s", name)
+	}
+	for i, p := range phones {
+		phones[i] = "+" + p
+	}
+	return fmt.Sprintf("👤 %s: %s", name, strings.Join(phones, ", "))
+}
+
+func escapeVCard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+func unescapeVCard(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// requestTimeout bounds each webhook POST so a slow endpoint cannot pile up requests.
+const requestTimeout = 10 * time.Second
+
+// queueSize bounds pending events; when full, new events are dropped so a slow
+// endpoint can never block message sync.
+const queueSize = 256
+
+// maxAttempts and retryDelay control redelivery: a failed POST is retried after
+// retryDelay, doubling each time, up to maxAttempts in total.
+const (
+	maxAttempts = 3
+	retryDelay  = time.Second
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed
+// with "sha256=", when a secret is configured.
+const SignatureHeader = "X-Webhook-Signature"
+
+// Lifecycle event names.
+const (
+	EventConnected           = "connected"
+	EventDisconnected        = "disconnected"
+	EventLoggedOut           = "logged_out"
+	EventHistorySyncComplete = "history_sync_complete"
+	EventMessage             = "message"
+)
+
+// Event is the JSON body POSTed to the webhook URL.
+type Event struct {
+	Event     string         `json:"event"`
+	Timestamp string         `json:"timestamp"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// Notifier POSTs JSON events to a configured URL from a single background worker.
+// Delivery is best-effort: failures are retried a couple of times, then logged
+// and never returned to the caller. A nil *Notifier is valid and discards all events.
+type Notifier struct {
+	url    string
+	secret []byte
+	client *http.Client
+	logger *slog.Logger
+	queue  chan Event
+}
+
+// New creates a Notifier that posts to url and starts its delivery worker. If
+// secret is set, each request is signed with it in SignatureHeader.
+func New(url, secret string, logger *slog.Logger) *Notifier {
+	n := &Notifier{
+		url:    url,
+		client: &http.Client{Timeout: requestTimeout},
+		logger: logger,
+		queue:  make(chan Event, queueSize),
+	}
+	if secret != "" {
+		n.secret = []byte(secret)
+	}
+	go n.run()
+	return n
+}
+
+// Notify queues an event for delivery without blocking the caller.
+// Events are dropped (and logged) when the queue is full.
+func (n *Notifier) Notify(event string, data map[string]any) {
+	if n == nil {
+		return
+	}
+
+	evt := Event{
+		Event:     event,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	}
+
+	select {
+	case n.queue <- evt:
+	default:
+		n.logger.Warn("webhook queue full, dropping event", "event", event)
+	}
+}
+
+// run delivers queued events in order.
+func (n *Notifier) run() {
+	for evt := range n.queue {
+		if err := n.deliver(evt); err != nil {
+			n.logger.Warn("webhook delivery failed", "event", evt.Event, "err", err)
+		}
+	}
+}
+
+// errPermanent marks a failure that retrying won't fix, such as a 4xx response.
+var errPermanent = errors.New("not retried")
+
+// deliver posts an event, retrying with backoff on network errors, 5xx and 429
+// responses. The body is encoded once so every attempt carries the same signature.
+func (n *Notifier) deliver(evt Event) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err = n.post(body)
+		if err == nil || errors.Is(err, errPermanent) || attempt == maxAttempts {
+			return err
+		}
+		n.logger.Debug("webhook delivery failed, retrying", "event", evt.Event, "attempt", attempt, "delay", delay, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends one request with an encoded event body.
+func (n *Notifier) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != nil {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status %d: %w", resp.StatusCode, errPermanent)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as sent in
+// SignatureHeader. Receivers should recompute it over the raw request body and
+// compare with hmac.Equal.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}